@@ -0,0 +1,13 @@
+data "kcl_render" "app" {
+  source_dir = "${path.module}/kcl/app"
+
+  variables = {
+    env      = "prod"
+    replicas = 3
+    labels   = { team = "platform" }
+  }
+}
+
+locals {
+  app = jsondecode(data.kcl_render.app.result)
+}
@@ -0,0 +1,12 @@
+data "kcl_run" "app" {
+  source_dir = "${path.module}/kcl/app"
+  args       = ["-D", "env=prod", "--format", "json"]
+
+  environment = {
+    KCL_FAST_EVAL = "1"
+  }
+}
+
+locals {
+  app = jsondecode(data.kcl_run.app.stdout)
+}
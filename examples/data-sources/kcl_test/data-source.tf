@@ -0,0 +1,15 @@
+data "kcl_test" "app" {
+  source_dir = "${path.module}/kcl/app"
+  coverage   = true
+}
+
+resource "terraform_data" "release" {
+  input = data.kcl_test.app.passed
+
+  lifecycle {
+    precondition {
+      condition     = data.kcl_test.app.all_passed && data.kcl_test.app.coverage_percent >= 80
+      error_message = "KCL tests must pass with at least 80% coverage."
+    }
+  }
+}
@@ -0,0 +1,5 @@
+data "kcl_version" "current" {}
+
+output "kcl_version" {
+  value = data.kcl_version.current.version
+}
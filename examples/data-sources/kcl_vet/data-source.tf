@@ -0,0 +1,16 @@
+data "kcl_vet" "values" {
+  data_file   = "${path.module}/values.json"
+  schema_file = "${path.module}/kcl/schema.k"
+  schema_name = "Values"
+}
+
+resource "terraform_data" "deploy" {
+  input = file("${path.module}/values.json")
+
+  lifecycle {
+    precondition {
+      condition     = data.kcl_vet.values.valid
+      error_message = join("\n", data.kcl_vet.values.errors)
+    }
+  }
+}
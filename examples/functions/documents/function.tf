@@ -0,0 +1,11 @@
+resource "kcl_exec" "manifests" {
+  source_dir = "${path.module}/kcl/manifests"
+}
+
+resource "kubernetes_manifest" "this" {
+  for_each = {
+    for doc in provider::kcl::documents(kcl_exec.manifests.stdout) : "${doc.kind}/${doc.metadata.name}" => doc
+  }
+
+  manifest = each.value
+}
@@ -0,0 +1,8 @@
+variable "kcl_code" {
+  type = string
+
+  validation {
+    condition     = provider::kcl::is_valid(var.kcl_code)
+    error_message = "kcl_code must be valid KCL."
+  }
+}
@@ -0,0 +1,10 @@
+locals {
+  app_code = <<-EOT
+    app = {
+        name = option("name")
+        replicas = 3
+    }
+  EOT
+
+  app = jsondecode(provider::kcl::render(local.app_code, { name = "web" }))
+}
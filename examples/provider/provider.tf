@@ -1,3 +1,9 @@
-provider "scaffolding" {
-  # example configuration here
+provider "kcl" {
+  # Use the kcl executable on PATH, or else install the pinned release
+  auto_install = true
+  kcl_version  = "0.11.0"
+  min_version  = "0.10.0"
+
+  # Reuse unchanged results and downloaded packages between runs
+  cache_dir = "${path.root}/.kcl-cache"
 }
@@ -0,0 +1,5 @@
+resource "kcl_doc" "app" {
+  source_dir = "${path.module}/kcl/app"
+  output_dir = "${path.module}/docs/app"
+  format     = "md"
+}
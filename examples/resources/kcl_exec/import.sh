@@ -0,0 +1,3 @@
+# The ID is the source directory, optionally pinned to the expected source_hash
+terraform import kcl_exec.app "./kcl/app"
+terraform import kcl_exec.app "./kcl/app@3f8a1c0e9b7d6a5f4e3d2c1b0a9f8e7d6c5b4a3f2e1d0c9b8a7f6e5d4c3b2a19"
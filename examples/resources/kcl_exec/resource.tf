@@ -0,0 +1,20 @@
+resource "kcl_exec" "app" {
+  source_dir = "${path.module}/kcl/app"
+  format     = "json"
+
+  arguments = {
+    env      = "prod"
+    replicas = "3"
+  }
+}
+
+output "app_name" {
+  value = kcl_exec.app.result.app.name
+}
+
+# Render Kubernetes manifests, one file per document
+resource "kcl_exec" "manifests" {
+  source_dir        = "${path.module}/kcl/manifests"
+  output_dir        = "${path.module}/rendered"
+  filename_template = "{{ .Index }}-{{ .Kind }}-{{ .Name }}.yaml"
+}
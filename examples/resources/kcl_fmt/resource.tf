@@ -0,0 +1,3 @@
+resource "kcl_fmt" "app" {
+  source_dir = "${path.module}/kcl/app"
+}
@@ -0,0 +1,5 @@
+resource "kcl_import" "values" {
+  input_file  = "${path.module}/values.yaml"
+  output_file = "${path.module}/kcl/app/values.k"
+  mode        = "yaml"
+}
@@ -0,0 +1,11 @@
+resource "kcl_mod" "app" {
+  source_dir = "${path.module}/kcl/app"
+  name       = "app"
+  version    = "0.1.0"
+  edition    = "v0.11.0"
+
+  dependencies = {
+    k8s  = "1.31"
+    base = "{ git = \"https://github.com/org/kcl-base\", tag = \"v0.2.0\" }"
+  }
+}
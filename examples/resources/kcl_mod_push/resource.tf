@@ -0,0 +1,8 @@
+resource "kcl_mod_push" "app" {
+  working_dir = "${path.module}/kcl/app"
+  oci_url     = "oci://ghcr.io/org/app"
+}
+
+output "app_package" {
+  value = "${kcl_mod_push.app.id}@${kcl_mod_push.app.digest}"
+}
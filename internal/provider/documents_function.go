@@ -0,0 +1,73 @@
+// internal/provider/documents_function.go
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var (
+	_ function.Function = &DocumentsFunction{}
+)
+
+// NewDocumentsFunction returns the documents function, which needs no KCL
+// executable.
+func NewDocumentsFunction() function.Function {
+	return &DocumentsFunction{}
+}
+
+type DocumentsFunction struct{}
+
+func (f *DocumentsFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "documents"
+}
+
+func (f *DocumentsFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Split and decode a multi-document YAML stream",
+		MarkdownDescription: "Splits a YAML stream at its `---` and `...` markers, like `documents` of `kcl_exec`, and returns " +
+			"a tuple of the decoded documents. Documents holding only comments are dropped. Malformed YAML fails with the " +
+			"index of the offending document",
+
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:                "yaml",
+				MarkdownDescription: "YAML stream, e.g. the `output` of a `kcl_exec` resource",
+			},
+		},
+		Return: function.DynamicReturn{},
+	}
+}
+
+func (f *DocumentsFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var stream string
+	resp.Error = req.Arguments.Get(ctx, &stream)
+	if resp.Error != nil {
+		return
+	}
+
+	docs := splitYAMLDocuments(stream)
+	elemTypes := make([]attr.Type, len(docs))
+	elems := make([]attr.Value, len(docs))
+	for i, doc := range docs {
+		value, err := parseResult(doc, resultFormatYAML)
+		if err != nil {
+			resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Invalid YAML in document %d: %v", i, err))
+			return
+		}
+		elemTypes[i], elems[i] = value.Type(ctx), value
+	}
+
+	tuple, diags := types.TupleValue(elemTypes, elems)
+	if diags.HasError() {
+		resp.Error = function.FuncErrorFromDiags(ctx, diags)
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, types.DynamicValue(tuple))
+}
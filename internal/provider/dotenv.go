@@ -0,0 +1,130 @@
+// internal/provider/dotenv.go
+package provider
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// dotenvKey matches a variable name in a dotenv file.
+var dotenvKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// readDotenvFiles parses the dotenv files in order and merges their
+// variables, later files winning. Relative paths resolve against dir.
+func readDotenvFiles(files []string, dir string) (map[string]string, error) {
+	vars := make(map[string]string)
+	for _, file := range files {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+
+		content, err := os.ReadFile(file)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, fmt.Errorf("environment file does not exist: %s", file)
+			}
+			return nil, fmt.Errorf("reading environment file %s: %w", file, err)
+		}
+
+		parsed, err := parseDotenv(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		for k, v := range parsed {
+			vars[k] = v
+		}
+	}
+	return vars, nil
+}
+
+// parseDotenv parses KEY=VALUE lines. Blank lines and lines starting with #
+// are skipped and an "export " prefix is ignored. Unquoted values are
+// trimmed and end at " #"; single-quoted values are taken literally;
+// double-quoted values may span lines and support \n, \r, \t, \", \\ and \$
+// escapes. A later assignment of the same key wins.
+func parseDotenv(content string) (map[string]string, error) {
+	vars := make(map[string]string)
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		lineNo := i + 1
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", lineNo)
+		}
+		key = strings.TrimSpace(key)
+		if !dotenvKey.MatchString(key) {
+			return nil, fmt.Errorf("line %d: invalid variable name %q", lineNo, key)
+		}
+		value = strings.TrimSpace(value)
+
+		switch {
+		case strings.HasPrefix(value, "'"):
+			end := strings.Index(value[1:], "'")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated single-quoted value for %s", lineNo, key)
+			}
+			value = value[1 : end+1]
+		case strings.HasPrefix(value, `"`):
+			// Collect following lines until the closing quote
+			raw := value[1:]
+			for {
+				decoded, ok := unquoteDotenv(raw)
+				if ok {
+					value = decoded
+					break
+				}
+				if i+1 >= len(lines) {
+					return nil, fmt.Errorf("line %d: unterminated double-quoted value for %s", lineNo, key)
+				}
+				i++
+				raw += "\n" + lines[i]
+			}
+		default:
+			if j := strings.Index(value, " #"); j >= 0 {
+				value = strings.TrimSpace(value[:j])
+			}
+		}
+		vars[key] = value
+	}
+	return vars, nil
+}
+
+// unquoteDotenv decodes raw, the text after an opening double quote, up to
+// the closing quote. ok is false when raw holds no closing quote.
+func unquoteDotenv(raw string) (string, bool) {
+	var b strings.Builder
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		switch {
+		case c == '"':
+			return b.String(), true
+		case c == '\\' && i+1 < len(raw):
+			i++
+			switch raw[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '"', '\\', '$':
+				b.WriteByte(raw[i])
+			default:
+				b.WriteByte('\\')
+				b.WriteByte(raw[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,103 @@
+// internal/provider/exec_cache.go
+package provider
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// cachedResult is a successful KCL run stored in the provider's cache_dir.
+type cachedResult struct {
+	Combined []byte `json:"combined"`
+	Stdout   []byte `json:"stdout"`
+	Stderr   []byte `json:"stderr"`
+}
+
+func cacheEntryPath(cacheDir, key string) string {
+	return filepath.Join(cacheDir, key+".json")
+}
+
+// loadCachedResult returns the result stored under key. ok is false when
+// there is no entry; an unreadable entry is reported as an error.
+func loadCachedResult(cacheDir, key string) (commandOutput, bool, error) {
+	content, err := os.ReadFile(cacheEntryPath(cacheDir, key))
+	if errors.Is(err, os.ErrNotExist) {
+		return commandOutput{}, false, nil
+	}
+	if err != nil {
+		return commandOutput{}, false, err
+	}
+
+	var entry cachedResult
+	if err := json.Unmarshal(content, &entry); err != nil {
+		return commandOutput{}, false, fmt.Errorf("decoding cache entry %s: %w", key, err)
+	}
+	return commandOutput{
+		Combined: entry.Combined,
+		Stdout:   entry.Stdout,
+		Stderr:   entry.Stderr,
+	}, true, nil
+}
+
+// storeCachedResult saves result under key, replacing any existing entry
+// atomically so concurrent runs never read a partial entry.
+func storeCachedResult(cacheDir, key string, result commandOutput) error {
+	content, err := json.Marshal(cachedResult{
+		Combined: result.Combined,
+		Stdout:   result.Stdout,
+		Stderr:   result.Stderr,
+	})
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(cacheEntryPath(cacheDir, key), string(content), 0o600)
+}
+
+// execCacheKey derives a cache key from every input that can influence a
+// run, separated so that adjacent parts cannot run together.
+func execCacheKey(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// hashFiles hashes the contents of files, resolving relative paths against
+// dir. Settings and entry files may live outside the hashed source
+// directory, so their contents are part of the cache key on their own.
+func hashFiles(dir string, files []string) (string, error) {
+	h := sha256.New()
+	for _, file := range files {
+		full := file
+		if !filepath.IsAbs(file) {
+			full = filepath.Join(dir, file)
+		}
+		content, err := os.ReadFile(full)
+		if err != nil {
+			return "", err
+		}
+		sum := sha256.Sum256(content)
+		fmt.Fprintf(h, "%s\x00%x\x00", file, sum)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// cacheWorkDir returns the working directory as it goes into the cache key:
+// relative to the source root when below it, since code, http_source,
+// oci_ref and git sources are fetched to a new temporary directory on every
+// run, and absolute otherwise.
+func cacheWorkDir(sourceRoot, workDir string) string {
+	rel, err := filepath.Rel(sourceRoot, workDir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return workDir
+	}
+	return filepath.ToSlash(rel)
+}
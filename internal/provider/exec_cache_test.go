@@ -0,0 +1,132 @@
+// internal/provider/exec_cache_test.go
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// countingKcl returns a fake KCL that prints how often it has run.
+func countingKcl(t *testing.T) string {
+	t.Helper()
+	runs := filepath.Join(t.TempDir(), "runs")
+	return fakeKcl(t, `case "$1" in
+version) echo "0.11.0" ;;
+*) echo run >> "`+runs+`"; printf 'run %s\n' $(wc -l < "`+runs+`") ;;
+esac`)
+}
+
+// newCachingHarness returns a harness whose provider caches results in
+// cacheDir.
+func newCachingHarness(t *testing.T, kclBinary, cacheDir string) *execHarness {
+	t.Helper()
+	h := newExecHarness(t, kclBinary)
+	h.resource.provider.CacheDir = cacheDir
+	return h
+}
+
+func TestKclExecResource_CacheReusesResult(t *testing.T) {
+	kcl, cacheDir := countingKcl(t), t.TempDir()
+	dir := writeTestFiles(t, map[string]string{"main.k": "a = 1\n"})
+	config := map[string]attr.Value{"source_dir": types.StringValue(dir)}
+
+	first := newCachingHarness(t, kcl, cacheDir).mustApply(config)
+	second := newCachingHarness(t, kcl, cacheDir).mustApply(config)
+	if got := second.Stdout.ValueString(); got != "run 1" || !second.Stdout.Equal(first.Stdout) {
+		t.Errorf("stdout = %q, want the cached first run", got)
+	}
+}
+
+func TestKclExecResource_CacheReusesInlineCodeResult(t *testing.T) {
+	kcl, cacheDir := countingKcl(t), t.TempDir()
+	config := map[string]attr.Value{"code": types.StringValue("a = 1\n")}
+
+	// Each run writes the code to a new temporary directory
+	first := newCachingHarness(t, kcl, cacheDir).mustApply(config)
+	second := newCachingHarness(t, kcl, cacheDir).mustApply(config)
+	if got := second.Stdout.ValueString(); got != "run 1" || !second.Stdout.Equal(first.Stdout) {
+		t.Errorf("stdout = %q, want the cached first run", got)
+	}
+}
+
+func TestCacheWorkDir(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "src")
+	tests := []struct {
+		workDir string
+		want    string
+	}{
+		{root, "."},
+		{filepath.Join(root, "app", "prod"), "app/prod"},
+		{filepath.Dir(root), filepath.Dir(root)},
+		{filepath.Join(filepath.Dir(root), "src2"), filepath.Join(filepath.Dir(root), "src2")},
+	}
+	for _, tt := range tests {
+		if got := cacheWorkDir(root, tt.workDir); got != tt.want {
+			t.Errorf("cacheWorkDir(%q, %q) = %q, want %q", root, tt.workDir, got, tt.want)
+		}
+	}
+}
+
+func TestKclExecResource_CacheSkipsFailedSuccessMarker(t *testing.T) {
+	kcl, cacheDir := countingKcl(t), t.TempDir()
+	dir := writeTestFiles(t, map[string]string{"main.k": "a = 1\n"})
+	config := map[string]attr.Value{
+		"source_dir":     types.StringValue(dir),
+		"success_marker": types.StringValue("run 2"),
+	}
+
+	if _, diags := newCachingHarness(t, kcl, cacheDir).apply(config); !diags.HasError() {
+		t.Fatal("the first run passed the success marker")
+	}
+	model := newCachingHarness(t, kcl, cacheDir).mustApply(config)
+	if got := model.Stdout.ValueString(); got != "run 2" {
+		t.Errorf("stdout = %q, want a second run instead of the cached failure", got)
+	}
+}
+
+func TestKclExecResource_CacheKeyCoversFilesOutsideSource(t *testing.T) {
+	for _, attribute := range []string{"settings_files", "entry_files"} {
+		t.Run(attribute, func(t *testing.T) {
+			kcl, cacheDir := countingKcl(t), t.TempDir()
+			dir := writeTestFiles(t, map[string]string{"main.k": "a = 1\n"})
+			outside := filepath.Join(writeTestFiles(t, map[string]string{"extra": "b = 1\n"}), "extra")
+			config := map[string]attr.Value{
+				"source_dir": types.StringValue(dir),
+				attribute:    types.ListValueMust(types.StringType, []attr.Value{types.StringValue(outside)}),
+			}
+
+			first := newCachingHarness(t, kcl, cacheDir).mustApply(config)
+			if err := os.WriteFile(outside, []byte("b = 2\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			second := newCachingHarness(t, kcl, cacheDir).mustApply(config)
+			if second.Stdout.Equal(first.Stdout) {
+				t.Errorf("editing the file in %s reused the cached result %s", attribute, first.Stdout)
+			}
+		})
+	}
+}
+
+func TestHashFiles(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{"a.k": "a = 1\n", "b.k": "b = 1\n"})
+	first, err := hashFiles(dir, []string{"a.k", filepath.Join(dir, "b.k")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if swapped, _ := hashFiles(dir, []string{filepath.Join(dir, "b.k"), "a.k"}); swapped == first {
+		t.Errorf("hashFiles ignored the file order")
+	}
+	if err := os.WriteFile(filepath.Join(dir, "b.k"), []byte("b = 2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if edited, _ := hashFiles(dir, []string{"a.k", filepath.Join(dir, "b.k")}); edited == first {
+		t.Errorf("hashFiles ignored a content change")
+	}
+	if _, err := hashFiles(dir, []string{"missing.k"}); err == nil {
+		t.Errorf("hashFiles of a missing file succeeded")
+	}
+}
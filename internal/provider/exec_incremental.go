@@ -0,0 +1,112 @@
+// internal/provider/exec_incremental.go
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+// kclExecEntriesKey is the private state key holding the entryState of an
+// incremental kcl_exec.
+const kclExecEntriesKey = "entries"
+
+// entryState records what an incremental run evaluated, so the next run
+// can reuse the output of every entry that did not change.
+type entryState struct {
+	// DependencyHash covers every input besides the entry files themselves:
+	// the other sources, the arguments and the environment.
+	DependencyHash string `json:"dependency_hash"`
+	// Entries holds the content hash and stdout of each entry by path.
+	Entries map[string]entryRecord `json:"entries"`
+}
+
+type entryRecord struct {
+	Hash   string `json:"hash"`
+	Stdout []byte `json:"stdout"`
+}
+
+// privateStateGetter is the private state of a request, e.g. UpdateRequest.
+type privateStateGetter interface {
+	GetKey(ctx context.Context, key string) ([]byte, diag.Diagnostics)
+}
+
+// privateStateSetter is the private state of a response, e.g.
+// UpdateResponse.
+type privateStateSetter interface {
+	SetKey(ctx context.Context, key string, value []byte) diag.Diagnostics
+}
+
+// loadEntryState returns the entryState recorded in private, the zero
+// value when there is none or it cannot be decoded, in which case every
+// entry runs.
+func loadEntryState(ctx context.Context, private privateStateGetter) (entryState, diag.Diagnostics) {
+	content, diags := private.GetKey(ctx, kclExecEntriesKey)
+	if diags.HasError() || len(content) == 0 {
+		return entryState{}, diags
+	}
+	var state entryState
+	if err := json.Unmarshal(content, &state); err != nil {
+		return entryState{}, diags
+	}
+	return state, diags
+}
+
+// storeEntryState records state in private, or removes the record when
+// state holds no entries.
+func storeEntryState(ctx context.Context, private privateStateSetter, state entryState) diag.Diagnostics {
+	if state.Entries == nil {
+		return private.SetKey(ctx, kclExecEntriesKey, nil)
+	}
+	content, err := json.Marshal(state)
+	if err != nil {
+		var diags diag.Diagnostics
+		diags.AddError("Private State Error", "Unable to encode the entry hashes: "+err.Error())
+		return diags
+	}
+	return private.SetKey(ctx, kclExecEntriesKey, content)
+}
+
+// hashEntryFiles returns the content hash of each entry, resolved against
+// workDir. The "-" entry is the stdin content, hashed as stdinHash.
+func hashEntryFiles(workDir string, entries []string, stdinHash string) (map[string]string, error) {
+	hashes := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		if entry == "-" {
+			hashes[entry] = stdinHash
+			continue
+		}
+		file := entry
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(workDir, file)
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(content)
+		hashes[entry] = hex.EncodeToString(sum[:])
+	}
+	return hashes, nil
+}
+
+// entryFilesBelow returns the entries that lie below sourceRoot, keyed by
+// slash-separated path relative to it, for hashSourceDirWithout.
+func entryFilesBelow(sourceRoot, workDir string, entries []string) map[string]bool {
+	below := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		file := entry
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(workDir, file)
+		}
+		if rel, err := filepath.Rel(sourceRoot, file); err == nil {
+			below[filepath.ToSlash(rel)] = true
+		}
+	}
+	return below
+}
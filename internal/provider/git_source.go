@@ -0,0 +1,170 @@
+// internal/provider/git_source.go
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// gitTimeout bounds every git command run for a git source.
+const gitTimeout = 300 * time.Second
+
+// gitCommitPattern matches a full hex commit SHA.
+var gitCommitPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// KclGitModel configures a Git repository to take the KCL sources from.
+type KclGitModel struct {
+	URL          types.String `tfsdk:"url"`
+	Ref          types.String `tfsdk:"ref"`
+	Subdirectory types.String `tfsdk:"subdirectory"`
+}
+
+// gitRef returns the configured ref, defaulting to the remote HEAD.
+func (m *KclGitModel) gitRef() string {
+	if m.Ref.IsNull() || m.Ref.ValueString() == "" {
+		return "HEAD"
+	}
+	return m.Ref.ValueString()
+}
+
+// resolveGitCommit returns the commit ref currently points to in the remote
+// repository, without cloning it. A ref that already is a full commit SHA
+// is returned as-is.
+func resolveGitCommit(ctx context.Context, p *kclProvider, repoURL, ref string) (string, error) {
+	if gitCommitPattern.MatchString(ref) {
+		return ref, nil
+	}
+
+	// Ask for the peeled entry too, it is only listed when requested
+	output, err := runGit(ctx, p, repoURL, "", "ls-remote", repoURL, ref, ref+"^{}")
+	if err != nil {
+		return "", err
+	}
+
+	// Annotated tags are listed twice; the peeled "^{}" entry is the commit
+	var commit string
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		if strings.HasSuffix(fields[1], "^{}") {
+			return fields[0], nil
+		}
+		if commit == "" {
+			commit = fields[0]
+		}
+	}
+	if commit == "" {
+		return "", fmt.Errorf("ref %q not found in %s", ref, repoURL)
+	}
+	return commit, nil
+}
+
+// fetchGitSource makes a shallow checkout of ref from repoURL in a new
+// temporary directory. The caller owns the returned directory and must
+// remove it. It also returns the directory to run KCL in, which is
+// subdirectory within the checkout, and the checked out commit SHA.
+func fetchGitSource(ctx context.Context, p *kclProvider, repoURL, ref, subdirectory string) (string, string, string, error) {
+	dir, err := os.MkdirTemp("", "kcl-git-*")
+	if err != nil {
+		return "", "", "", err
+	}
+
+	steps := [][]string{
+		{"init", "--quiet"},
+		{"remote", "add", "origin", repoURL},
+		{"fetch", "--quiet", "--depth", "1", "origin", ref},
+		{"checkout", "--quiet", "FETCH_HEAD"},
+	}
+	for _, args := range steps {
+		if _, err := runGit(ctx, p, repoURL, dir, args...); err != nil {
+			os.RemoveAll(dir)
+			return "", "", "", err
+		}
+	}
+
+	commit, err := runGit(ctx, p, repoURL, dir, "rev-parse", "HEAD")
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", "", "", err
+	}
+
+	workDir := dir
+	if subdirectory != "" {
+		workDir = filepath.Join(dir, filepath.FromSlash(subdirectory))
+		rel, err := filepath.Rel(dir, workDir)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			os.RemoveAll(dir)
+			return "", "", "", fmt.Errorf("subdirectory %q escapes the repository", subdirectory)
+		}
+		if info, err := os.Stat(workDir); err != nil || !info.IsDir() {
+			os.RemoveAll(dir)
+			return "", "", "", fmt.Errorf("subdirectory %q does not exist at commit %s", subdirectory, commit)
+		}
+	}
+
+	return dir, workDir, commit, nil
+}
+
+// runGit runs git with args in dir and returns its trimmed standard output.
+// Credentials are passed through the environment, never the command line.
+func runGit(ctx context.Context, p *kclProvider, repoURL, dir string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, gitTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), gitAuthEnv(p, repoURL)...)
+	// Fail instead of waiting for a password prompt nobody can answer
+	cmd.Env = append(cmd.Env, "GIT_TERMINAL_PROMPT=0")
+	configureGracefulStop(cmd)
+
+	tflog.Debug(ctx, "Running git", map[string]interface{}{
+		"arguments": args,
+		"directory": dir,
+	})
+
+	result, err := runCapturingOutput(ctx, cmd)
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w\nOutput: %s", strings.Join(args, " "), err,
+			strings.TrimSpace(string(result.Combined)))
+	}
+	return strings.TrimSpace(string(result.Stdout)), nil
+}
+
+// gitAuthEnv returns git configuration, as environment variables, that
+// authenticates HTTP(S) requests to repoURL with the provider's registry
+// credentials. Credentials are only sent when the repository is hosted on
+// the configured registry host, so they never leak to other servers.
+func gitAuthEnv(p *kclProvider, repoURL string) []string {
+	if p == nil || p.Registry == "" || p.RegistryPassword == "" {
+		return nil
+	}
+
+	u, err := url.Parse(repoURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return nil
+	}
+	if !strings.EqualFold(u.Host, registryHost(p.Registry)) {
+		return nil
+	}
+
+	token := base64.StdEncoding.EncodeToString([]byte(p.RegistryUsername + ":" + p.RegistryPassword))
+	return []string{
+		"GIT_CONFIG_COUNT=1",
+		"GIT_CONFIG_KEY_0=http.extraHeader",
+		"GIT_CONFIG_VALUE_0=Authorization: Basic " + token,
+	}
+}
@@ -0,0 +1,131 @@
+// internal/provider/http_source.go
+package provider
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// fetchHTTPSource downloads a single .k file or a .tar.gz archive of KCL
+// sources into a new temporary directory. The caller owns the returned
+// directory and must remove it. The returned hash is the hex SHA-256 of the
+// downloaded bytes; when expectedSHA256 is set it must match.
+func fetchHTTPSource(ctx context.Context, rawURL string, headers map[string]string, expectedSHA256 string) (string, string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", "", fmt.Errorf("unsupported URL scheme %q, expected http or https", u.Scheme)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", "", err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("unexpected HTTP status %s", res.Status)
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", "", fmt.Errorf("reading response: %w", err)
+	}
+
+	sum := sha256.Sum256(body)
+	contentHash := hex.EncodeToString(sum[:])
+	if expectedSHA256 != "" && !strings.EqualFold(expectedSHA256, contentHash) {
+		return "", "", fmt.Errorf("checksum mismatch: expected %s, got %s", expectedSHA256, contentHash)
+	}
+
+	dir, err := os.MkdirTemp("", "kclx-http-*")
+	if err != nil {
+		return "", "", err
+	}
+
+	name := path.Base(u.Path)
+	if strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tgz") {
+		err = extractTarGz(body, dir)
+	} else {
+		if name == "" || name == "/" || name == "." || !strings.HasSuffix(name, ".k") {
+			name = "main.k"
+		}
+		err = os.WriteFile(filepath.Join(dir, name), body, 0o644)
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", "", err
+	}
+
+	return dir, contentHash, nil
+}
+
+// extractTarGz unpacks a gzip-compressed tarball into dir, rejecting entries
+// that would escape it.
+func extractTarGz(data []byte, dir string) error {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("opening archive: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading archive: %w", err)
+		}
+
+		target := filepath.Join(dir, filepath.FromSlash(hdr.Name))
+		if target != dir && !strings.HasPrefix(target, dir+string(os.PathSeparator)) {
+			return fmt.Errorf("archive entry %q escapes the extraction directory", hdr.Name)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(f, tr); err != nil {
+				f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+		}
+	}
+}
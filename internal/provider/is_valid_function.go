@@ -0,0 +1,66 @@
+// internal/provider/is_valid_function.go
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var (
+	_ function.Function = &IsValidFunction{}
+)
+
+// NewIsValidFunction returns a factory for the is_valid function.
+func NewIsValidFunction(p *kclProvider) func() function.Function {
+	return func() function.Function {
+		return &IsValidFunction{provider: p}
+	}
+}
+
+type IsValidFunction struct {
+	provider *kclProvider
+}
+
+func (f *IsValidFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "is_valid"
+}
+
+func (f *IsValidFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Check KCL code for syntax errors",
+		MarkdownDescription: "Returns whether the code parses as KCL, without evaluating it. The check runs `kcl fmt` on a " +
+			"temporary copy, so it is cheap enough for `validation` blocks. Type and evaluation errors are not detected",
+
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:                "code",
+				MarkdownDescription: "KCL source code to check",
+			},
+		},
+		Return: function.BoolReturn{},
+	}
+}
+
+func (f *IsValidFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var code string
+	resp.Error = req.Arguments.Get(ctx, &code)
+	if resp.Error != nil {
+		return
+	}
+
+	// A non-zero exit means the code did not parse; anything else means
+	// the check itself could not be made
+	result, err := runInlineKcl(ctx, f.provider, code, []string{"fmt", inlineCodeFileName})
+	exitCode, runErr := exitCodeOf(err)
+	if runErr != nil {
+		resp.Error = function.NewFuncError(fmt.Sprintf("Unable to check KCL code: %v\n%s", runErr,
+			strings.TrimSpace(string(result.Combined))))
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, exitCode == 0)
+}
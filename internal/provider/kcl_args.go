@@ -0,0 +1,287 @@
+// internal/provider/kcl_args.go
+package provider
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// defaultSubcommand is run when neither the subcommand attribute nor the
+// arguments name one.
+const defaultSubcommand = "run"
+
+// validSubcommands are the values accepted by the subcommand attribute.
+var validSubcommands = []string{"run", "vet", "fmt", "test", "doc"}
+
+// kclSubcommands are all subcommands of the KCL CLI. Arguments starting with
+// one of them already choose what runs, so no subcommand is prepended.
+var kclSubcommands = map[string]bool{
+	"run": true, "vet": true, "fmt": true, "test": true, "doc": true, "lint": true,
+	"mod": true, "registry": true, "import": true, "clean": true, "server": true, "version": true,
+}
+
+// validateSubcommand returns an error unless subcommand is one of
+// validSubcommands.
+func validateSubcommand(subcommand string) error {
+	for _, v := range validSubcommands {
+		if subcommand == v {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown subcommand %q, expected one of: %s",
+		subcommand, strings.Join(validSubcommands, ", "))
+}
+
+// effectiveSubcommand returns the subcommand to prepend to args: the
+// configured one, or defaultSubcommand unless args already start with a
+// subcommand, as configurations written before the attribute existed do.
+func effectiveSubcommand(configured string, args []string) string {
+	if configured != "" {
+		return configured
+	}
+	if len(args) > 0 && kclSubcommands[args[0]] {
+		return ""
+	}
+	return defaultSubcommand
+}
+
+// kclArgs collects every source of command-line arguments for a KCL run.
+type kclArgs struct {
+	// Subcommand is the KCL subcommand, placed first when set.
+	Subcommand string
+	// EntryFiles are KCL files to run, passed positionally in order.
+	EntryFiles []string
+	// DefaultArgs are the provider-level default_args, placed before Args.
+	DefaultArgs []string
+	// Args are the user-supplied arguments, passed through verbatim.
+	Args []string
+	// Arguments are top-level arguments, each passed as -D key=value.
+	Arguments map[string]string
+	// SettingsFiles are KCL settings files, each passed as -Y <path>.
+	SettingsFiles []string
+	// ExternalPackages map package names to local directories, each passed
+	// as -E name=path.
+	ExternalPackages map[string]string
+	// PathSelectors select parts of the result, each passed as -S <path>.
+	PathSelectors []string
+	// Overrides patch the program, each passed as -O <override>.
+	Overrides []string
+	// Flags are boolean flags enabled through dedicated attributes. A flag
+	// already present in DefaultArgs or Args is not repeated.
+	Flags []string
+	// Format is the output format, passed as --format <value> unless
+	// DefaultArgs or Args already choose one.
+	Format string
+	// CodeFile is the file holding inline code, the file source_dir names,
+	// relative to the working directory, or the oci_ref package URL.
+	CodeFile string
+	// Tag is the oci_ref package tag, passed as --tag <tag> after CodeFile.
+	Tag string
+	// InputFile is the temporary file holding input_from, if any.
+	InputFile string
+}
+
+// buildArgs assembles the final argument list. The order is fixed so the
+// command line, and therefore the resource ID, is deterministic:
+//
+//  1. the subcommand
+//  2. entry files, as positional arguments in configured order
+//  3. provider default args, verbatim
+//  4. user args, verbatim
+//  5. top-level arguments (-D key=value), sorted by key
+//  6. settings files (-Y <path>), in configured order
+//  7. external packages (-E name=path), sorted by name
+//  8. path selectors (-S <path>), in configured order
+//  9. overrides (-O <override>), in configured order
+//  10. boolean flags, in attribute order, unless already given
+//  11. the output format (--format <value>), unless already given
+//  12. the code file, as a positional argument
+//  13. the package tag (--tag <tag>)
+//  14. the input_from top-level argument (-D input_from_file=<path>)
+func buildArgs(a kclArgs) []string {
+	args := []string{}
+	if a.Subcommand != "" {
+		args = append(args, a.Subcommand)
+	}
+	args = append(args, a.EntryFiles...)
+	args = append(args, a.DefaultArgs...)
+	args = append(args, a.Args...)
+
+	// Each pair is a single argv element, so values need no quoting
+	for _, k := range sortedKeys(a.Arguments) {
+		args = append(args, "-D", k+"="+a.Arguments[k])
+	}
+
+	for _, f := range a.SettingsFiles {
+		args = append(args, "-Y", f)
+	}
+
+	for _, name := range sortedKeys(a.ExternalPackages) {
+		args = append(args, "-E", name+"="+a.ExternalPackages[name])
+	}
+
+	for _, selector := range a.PathSelectors {
+		args = append(args, "-S", selector)
+	}
+
+	for _, override := range a.Overrides {
+		args = append(args, "-O", override)
+	}
+
+	for _, flag := range a.Flags {
+		if !containsString(a.DefaultArgs, flag) && !containsString(a.Args, flag) {
+			args = append(args, flag)
+		}
+	}
+
+	_, inDefaults := flagValue(a.DefaultArgs, "--format")
+	_, inArgs := flagValue(a.Args, "--format")
+	if a.Format != "" && !inDefaults && !inArgs {
+		args = append(args, "--format", a.Format)
+	}
+
+	if a.CodeFile != "" {
+		args = append(args, a.CodeFile)
+	}
+
+	if a.Tag != "" {
+		args = append(args, "--tag", a.Tag)
+	}
+
+	if a.InputFile != "" {
+		args = append(args, "-D", inputFromOption+"="+a.InputFile)
+	}
+
+	return args
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// validateOverride checks the basic shape of a KCL override,
+// "pkg:path.field=value" to set a field or "pkg:path.field-" to delete it.
+// The package part may be empty, but the colon is required.
+func validateOverride(override string) error {
+	if strings.ContainsAny(override, "\r\n") {
+		return fmt.Errorf("override %q contains a line break", override)
+	}
+
+	_, target, ok := strings.Cut(override, ":")
+	if !ok {
+		return fmt.Errorf("override %q has no package separator, expected pkg:path.field=value", override)
+	}
+
+	field, _, isSet := strings.Cut(target, "=")
+	if !isSet {
+		if !strings.HasSuffix(target, "-") {
+			return fmt.Errorf("override %q neither sets a value with = nor deletes the field with a trailing -, "+
+				"expected pkg:path.field=value or pkg:path.field-", override)
+		}
+		field = strings.TrimSuffix(target, "-")
+	}
+	if strings.TrimSpace(field) == "" {
+		return fmt.Errorf("override %q names no field, expected pkg:path.field=value", override)
+	}
+	return nil
+}
+
+// validateArgument returns an error unless key=value is a well-formed
+// top-level argument for -D.
+func validateArgument(key, value string) error {
+	if key == "" || strings.ContainsAny(key, "= \t\r\n") {
+		return fmt.Errorf("argument name %q must be non-empty and contain no =, whitespace or line breaks", key)
+	}
+	if strings.ContainsAny(value, "\r\n") {
+		return fmt.Errorf("argument %q has a value with a line break, which KCL does not accept on the command line", key)
+	}
+	return nil
+}
+
+// flagValue returns the value given to flag in args, either as the next
+// element or as flag=value.
+func flagValue(args []string, flag string) (string, bool) {
+	for i, arg := range args {
+		if arg == flag && i+1 < len(args) {
+			return args[i+1], true
+		}
+		if value, ok := strings.CutPrefix(arg, flag+"="); ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// attributeFlags maps the KCL flags that a kcl_exec attribute controls to
+// that attribute. Flags taking a value may also be written flag=value, and
+// short ones with the value attached, e.g. -Dkey=value.
+var attributeFlags = []struct {
+	flags      []string
+	takesValue bool
+	attribute  string
+}{
+	{[]string{"-D", "--argument"}, true, "arguments"},
+	{[]string{"-Y", "--setting"}, true, "settings_files"},
+	{[]string{"-E", "--external"}, true, "external_packages"},
+	{[]string{"-S", "--path_selector"}, true, "path_selectors"},
+	{[]string{"-O", "--overrides"}, true, "overrides"},
+	{[]string{"--format"}, true, "format"},
+	{[]string{"-k", "--sort_keys"}, false, "sort_keys"},
+	{[]string{"-n", "--disable_none"}, false, "disable_none"},
+	{[]string{"-r", "--strict_range_check"}, false, "strict_range_check"},
+	{[]string{"-d", "--debug"}, false, "debug"},
+	{[]string{"-q", "--quiet"}, false, "quiet"},
+	{[]string{"--vendor"}, false, "vendor"},
+}
+
+// attributeForFlag returns the kcl_exec attribute controlling the flag arg
+// passes, if any.
+func attributeForFlag(arg string) (string, bool) {
+	for _, entry := range attributeFlags {
+		for _, flag := range entry.flags {
+			if arg == flag {
+				return entry.attribute, true
+			}
+			if !entry.takesValue {
+				continue
+			}
+			if strings.HasPrefix(arg, flag+"=") || (len(flag) == 2 && strings.HasPrefix(arg, flag)) {
+				return entry.attribute, true
+			}
+		}
+	}
+	return "", false
+}
+
+// redactedValue replaces secret values in arguments shown to users.
+const redactedValue = "(sensitive)"
+
+// redactArgs returns a copy of args with every occurrence of a secret
+// replaced by redactedValue.
+func redactArgs(args, secrets []string) []string {
+	redacted := make([]string, len(args))
+	for i, arg := range args {
+		for _, secret := range secrets {
+			if secret != "" {
+				arg = strings.ReplaceAll(arg, secret, redactedValue)
+			}
+		}
+		redacted[i] = arg
+	}
+	return redacted
+}
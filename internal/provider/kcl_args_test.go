@@ -0,0 +1,109 @@
+// internal/provider/kcl_args_test.go
+package provider
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args kclArgs
+		want []string
+	}{
+		{"empty", kclArgs{}, []string{}},
+		{"subcommand", kclArgs{Subcommand: "vet"}, []string{"vet"}},
+		{"entry files in configured order", kclArgs{EntryFiles: []string{"b.k", "a.k"}}, []string{"b.k", "a.k"}},
+		{"default args before args", kclArgs{DefaultArgs: []string{"--vendor"}, Args: []string{"-k", "x.k"}}, []string{"--vendor", "-k", "x.k"}},
+		{"arguments sorted by key", kclArgs{Arguments: map[string]string{"b": "2", "a": "1 with space"}}, []string{"-D", "a=1 with space", "-D", "b=2"}},
+		{"settings files in configured order", kclArgs{SettingsFiles: []string{"z.yaml", "a.yaml"}}, []string{"-Y", "z.yaml", "-Y", "a.yaml"}},
+		{"external packages sorted by name", kclArgs{ExternalPackages: map[string]string{"k8s": "/k", "base": "/b"}}, []string{"-E", "base=/b", "-E", "k8s=/k"}},
+		{"path selectors in configured order", kclArgs{PathSelectors: []string{"b", "a.c"}}, []string{"-S", "b", "-S", "a.c"}},
+		{"overrides in configured order", kclArgs{Overrides: []string{":b=1", ":a-"}}, []string{"-O", ":b=1", "-O", ":a-"}},
+		{"flags in order", kclArgs{Flags: []string{"--sort_keys", "--debug"}}, []string{"--sort_keys", "--debug"}},
+		{"flag already in default args", kclArgs{DefaultArgs: []string{"--sort_keys"}, Flags: []string{"--sort_keys", "--debug"}}, []string{"--sort_keys", "--debug"}},
+		{"flag already in args", kclArgs{Args: []string{"--debug"}, Flags: []string{"--debug"}}, []string{"--debug"}},
+		{"quiet flag", kclArgs{Flags: []string{"--debug", "-q"}}, []string{"--debug", "-q"}},
+		{"quiet flag already in args", kclArgs{Args: []string{"-q"}, Flags: []string{"-q"}}, []string{"-q"}},
+		{"format", kclArgs{Format: "json"}, []string{"--format", "json"}},
+		{"format already in default args", kclArgs{DefaultArgs: []string{"--format", "yaml"}, Format: "json"}, []string{"--format", "yaml"}},
+		{"format already in args", kclArgs{Args: []string{"--format=yaml"}, Format: "json"}, []string{"--format=yaml"}},
+		{"code file", kclArgs{CodeFile: "main.k"}, []string{"main.k"}},
+		{"tag", kclArgs{CodeFile: "oci://ghcr.io/kcl-lang/app", Tag: "0.1.0"}, []string{"oci://ghcr.io/kcl-lang/app", "--tag", "0.1.0"}},
+		{"input file", kclArgs{InputFile: "/tmp/in.json"}, []string{"-D", inputFromOption + "=/tmp/in.json"}},
+		{
+			"every source in order",
+			kclArgs{
+				Subcommand:       "run",
+				EntryFiles:       []string{"a.k", "b.k"},
+				DefaultArgs:      []string{"--vendor"},
+				Args:             []string{"-n"},
+				Arguments:        map[string]string{"y": "2", "x": "1"},
+				SettingsFiles:    []string{"kcl.yaml"},
+				ExternalPackages: map[string]string{"pkg": "/pkg"},
+				PathSelectors:    []string{"app"},
+				Overrides:        []string{":app.replicas=3"},
+				Flags:            []string{"--sort_keys", "--vendor", "--strict_range_check"},
+				Format:           "yaml",
+				CodeFile:         "main.k",
+				Tag:              "1.0.0",
+				InputFile:        "in.json",
+			},
+			[]string{
+				"run",
+				"a.k", "b.k",
+				"--vendor",
+				"-n",
+				"-D", "x=1", "-D", "y=2",
+				"-Y", "kcl.yaml",
+				"-E", "pkg=/pkg",
+				"-S", "app",
+				"-O", ":app.replicas=3",
+				"--sort_keys", "--strict_range_check",
+				"--format", "yaml",
+				"main.k",
+				"--tag", "1.0.0",
+				"-D", inputFromOption + "=in.json",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildArgs(tt.args); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("buildArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildArgs_Deterministic(t *testing.T) {
+	a := kclArgs{
+		Arguments:        map[string]string{"c": "3", "a": "1", "b": "2", "d": "4"},
+		ExternalPackages: map[string]string{"z": "/z", "m": "/m", "a": "/a"},
+	}
+	first := buildArgs(a)
+	for i := 0; i < 20; i++ {
+		if got := buildArgs(a); !reflect.DeepEqual(got, first) {
+			t.Fatalf("buildArgs() = %q, then %q", first, got)
+		}
+	}
+}
+
+func TestEffectiveSubcommand(t *testing.T) {
+	tests := []struct {
+		configured string
+		args       []string
+		want       string
+	}{
+		{"", nil, defaultSubcommand},
+		{"", []string{"main.k"}, defaultSubcommand},
+		{"", []string{"vet", "main.k"}, ""},
+		{"fmt", []string{"vet"}, "fmt"},
+	}
+	for _, tt := range tests {
+		if got := effectiveSubcommand(tt.configured, tt.args); got != tt.want {
+			t.Errorf("effectiveSubcommand(%q, %q) = %q, want %q", tt.configured, tt.args, got, tt.want)
+		}
+	}
+}
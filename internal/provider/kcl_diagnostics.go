@@ -0,0 +1,325 @@
+// internal/provider/kcl_diagnostics.go
+package provider
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// kclCompileErrorPattern matches the error reports KCL prints for problems
+// in the program itself, e.g. "error[E2G22]: TypeError", as opposed to
+// failures of the environment such as an unreachable package registry.
+var kclCompileErrorPattern = regexp.MustCompile(`error\[E\w+\]|\b(CompileError|SyntaxError|TypeError|EvaluationError)\b`)
+
+// isKclCompileError reports whether output shows that the KCL program
+// itself is at fault, so running it again cannot succeed.
+func isKclCompileError(output string) bool {
+	if diags, _ := parseKclDiagnostics(output); len(diags) > 0 {
+		for _, d := range diags {
+			if d.IsFatal() {
+				return true
+			}
+		}
+	}
+	return kclCompileErrorPattern.MatchString(output)
+}
+
+var (
+	// kclTextErrorHeader matches the first line of a human-readable KCL
+	// error report in both the current format, "error[E2L23]: CompileError",
+	// and the older one, "KCL Compile Error[E2L23] : A compile error".
+	kclTextErrorHeader = regexp.MustCompile(`^(?:error(?:\[\w+\])?:\s*(.*)|KCL [\w ]*Error(?:\[\w+\])?\s*:\s*(.*))$`)
+
+	// kclTextWarningHeader matches the first line of a human-readable KCL
+	// warning, e.g. "warning[W2L26]: deprecated attribute" or
+	// "KCL Compile Warning[W2L26] : deprecated attribute".
+	kclTextWarningHeader = regexp.MustCompile(`^(?:warning(?:\[\w+\])?:\s*(.*)|KCL [\w ]*Warning(?:\[\w+\])?\s*:\s*(.*))$`)
+
+	// kclTextErrorLocation matches the location line following a header,
+	// e.g. " --> /src/main.k:3:5" or "---> File /src/main.k:3:5".
+	kclTextErrorLocation = regexp.MustCompile(`^-+>\s*(?:File\s+)?(.+?):(\d+)(?::(\d+))?\s*$`)
+
+	// kclTextErrorGutter matches a source excerpt line, e.g. "3 |     a = b"
+	// or "  |     ^ name 'b' is not defined".
+	kclTextErrorGutter = regexp.MustCompile(`^\s*\d*\s*\|(.*)$`)
+)
+
+// kclDiagnostic is a single structured error or warning reported by KCL.
+type kclDiagnostic struct {
+	Severity string `tfsdk:"severity"`
+	File     string `tfsdk:"file"`
+	Line     int64  `tfsdk:"line"`
+	Column   int64  `tfsdk:"column"`
+	Message  string `tfsdk:"message"`
+}
+
+// kclDiagnosticAttrTypes describes the object type of each element in the
+// computed diagnostics list.
+var kclDiagnosticAttrTypes = map[string]attr.Type{
+	"severity": types.StringType,
+	"file":     types.StringType,
+	"line":     types.Int64Type,
+	"column":   types.Int64Type,
+	"message":  types.StringType,
+}
+
+// IsFatal reports whether the diagnostic should fail the execution.
+func (d kclDiagnostic) IsFatal() bool {
+	switch strings.ToLower(d.Severity) {
+	case "error", "fatal":
+		return true
+	}
+	return false
+}
+
+// Position renders the diagnostic location as file:line:column, omitting
+// whatever parts KCL did not report.
+func (d kclDiagnostic) Position() string {
+	if d.File == "" {
+		return ""
+	}
+	switch {
+	case d.Line > 0 && d.Column > 0:
+		return fmt.Sprintf("%s:%d:%d", d.File, d.Line, d.Column)
+	case d.Line > 0:
+		return fmt.Sprintf("%s:%d", d.File, d.Line)
+	}
+	return d.File
+}
+
+// rawKclDiagnostic accepts the field name variations seen across KCL
+// releases before normalizing them into a kclDiagnostic.
+type rawKclDiagnostic struct {
+	Severity *string `json:"severity"`
+	Level    *string `json:"level"`
+	File     *string `json:"file"`
+	Filename *string `json:"filename"`
+	Line     *int64  `json:"line"`
+	Column   *int64  `json:"column"`
+	Col      *int64  `json:"col"`
+	Message  *string `json:"message"`
+	Msg      *string `json:"msg"`
+}
+
+func (r rawKclDiagnostic) normalize() (kclDiagnostic, bool) {
+	pick := func(values ...*string) string {
+		for _, v := range values {
+			if v != nil {
+				return *v
+			}
+		}
+		return ""
+	}
+	pickInt := func(values ...*int64) int64 {
+		for _, v := range values {
+			if v != nil {
+				return *v
+			}
+		}
+		return 0
+	}
+
+	d := kclDiagnostic{
+		Severity: strings.ToLower(pick(r.Severity, r.Level)),
+		File:     pick(r.File, r.Filename),
+		Line:     pickInt(r.Line),
+		Column:   pickInt(r.Column, r.Col),
+		Message:  pick(r.Message, r.Msg),
+	}
+	if d.Message == "" {
+		return d, false
+	}
+	if d.Severity == "" {
+		d.Severity = "error"
+	}
+	return d, true
+}
+
+// parseKclDiagnostics extracts the JSON diagnostics from KCL output. Each
+// line may hold a single diagnostic object or an array of them; lines that
+// are not diagnostics are returned untouched so regular program output is
+// preserved.
+func parseKclDiagnostics(output string) ([]kclDiagnostic, string) {
+	var (
+		diags []kclDiagnostic
+		rest  []string
+	)
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if parsed, ok := decodeKclDiagnosticLine(trimmed); ok {
+			diags = append(diags, parsed...)
+			continue
+		}
+		rest = append(rest, line)
+	}
+
+	return diags, strings.Join(rest, "\n")
+}
+
+func decodeKclDiagnosticLine(line string) ([]kclDiagnostic, bool) {
+	var raws []rawKclDiagnostic
+	switch {
+	case strings.HasPrefix(line, "{"):
+		var raw rawKclDiagnostic
+		if err := json.Unmarshal([]byte(line), &raw); err != nil {
+			return nil, false
+		}
+		raws = append(raws, raw)
+	case strings.HasPrefix(line, "["):
+		if err := json.Unmarshal([]byte(line), &raws); err != nil {
+			return nil, false
+		}
+	default:
+		return nil, false
+	}
+
+	diags := make([]kclDiagnostic, 0, len(raws))
+	for _, raw := range raws {
+		d, ok := raw.normalize()
+		if !ok {
+			return nil, false
+		}
+		diags = append(diags, d)
+	}
+	return diags, len(diags) > 0
+}
+
+// parseKclTextErrors extracts the errors from KCL's human-readable error
+// reports. Each report starts at a header line and runs to the next one; its
+// message is the error kind from the header followed by the text marked by
+// carets in the source excerpt and any free text. Output without a header
+// yields no diagnostics.
+func parseKclTextErrors(output string) []kclDiagnostic {
+	return parseKclTextReports(output, kclTextErrorHeader, "error", false)
+}
+
+// parseKclTextWarnings extracts the warnings from KCL's human-readable
+// output, which share the layout of error reports. Warnings appear in the
+// output of successful runs, so a report ends at the first blank line
+// instead of swallowing whatever else the run printed.
+func parseKclTextWarnings(output string) []kclDiagnostic {
+	return parseKclTextReports(output, kclTextWarningHeader, "warning", true)
+}
+
+// parseKclTextReports extracts the reports starting at lines matching
+// header, giving each the severity. With endAtBlank, a blank line ends the
+// current report.
+func parseKclTextReports(output string, header *regexp.Regexp, severity string, endAtBlank bool) []kclDiagnostic {
+	var (
+		diags   []kclDiagnostic
+		current *kclDiagnostic
+		message []string
+	)
+	flush := func() {
+		if current == nil {
+			return
+		}
+		current.Message = strings.Join(message, ": ")
+		if current.Message == "" {
+			current.Message = "unknown KCL " + severity
+		}
+		diags = append(diags, *current)
+		current, message = nil, nil
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if m := header.FindStringSubmatch(trimmed); m != nil {
+			flush()
+			current = &kclDiagnostic{Severity: severity}
+			if kind := strings.TrimSpace(m[1] + m[2]); kind != "" {
+				message = append(message, kind)
+			}
+			continue
+		}
+		if trimmed == "" && endAtBlank {
+			flush()
+		}
+		if current == nil || trimmed == "" {
+			continue
+		}
+
+		if m := kclTextErrorLocation.FindStringSubmatch(trimmed); m != nil {
+			if current.File == "" {
+				current.File = m[1]
+				fmt.Sscan(m[2], &current.Line)
+				if m[3] != "" {
+					fmt.Sscan(m[3], &current.Column)
+				}
+			}
+			continue
+		}
+
+		if m := kclTextErrorGutter.FindStringSubmatch(line); m != nil {
+			// Only the annotation after the carets is message text; the
+			// rest is an excerpt of the program
+			if i := strings.LastIndex(m[1], "^"); i >= 0 && strings.TrimSpace(m[1][:i+1]) != "" {
+				if note := strings.TrimSpace(m[1][i+1:]); note != "" {
+					message = append(message, strings.TrimSpace(strings.TrimPrefix(note, "->")))
+				}
+			}
+			continue
+		}
+
+		message = append(message, trimmed)
+	}
+	flush()
+
+	return diags
+}
+
+// reportKclDiagnostics adds a Terraform error for every fatal KCL diagnostic,
+// including its source position. It reports whether any error was added so
+// callers can fall back to a raw output dump otherwise.
+func reportKclDiagnostics(diags *diag.Diagnostics, kclDiags []kclDiagnostic) bool {
+	reported := false
+	for _, d := range kclDiags {
+		if !d.IsFatal() {
+			continue
+		}
+
+		detail := d.Message
+		if pos := d.Position(); pos != "" {
+			detail = pos + ": " + d.Message
+		}
+		diags.AddError("KCL Compilation Error", detail)
+		reported = true
+	}
+	return reported
+}
+
+// reportKclWarnings adds a Terraform warning for every KCL diagnostic that
+// is not fatal, or an error when failOnWarnings is set. It reports whether
+// any error was added.
+func reportKclWarnings(diags *diag.Diagnostics, kclDiags []kclDiagnostic, failOnWarnings bool) bool {
+	failed := false
+	for _, d := range kclDiags {
+		if d.IsFatal() {
+			continue
+		}
+
+		detail := d.Message
+		if pos := d.Position(); pos != "" {
+			detail = pos + ": " + d.Message
+		}
+		if failOnWarnings {
+			diags.AddError("KCL Warning", detail+"\n\nfail_on_warnings is set, so warnings fail the execution.")
+			failed = true
+			continue
+		}
+		diags.AddWarning("KCL Warning", detail)
+	}
+	return failed
+}
@@ -0,0 +1,99 @@
+// internal/provider/kcl_diagnostics_test.go
+package provider
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// kclErrorReport is an error report as KCL 0.11 prints it.
+const kclErrorReport = `error[E2L23]: CompileError
+ --> /src/main.k:3:5
+  |
+3 | a = b
+  |     ^ name 'b' is not defined
+  |
+`
+
+// kclWarningReport is a warning as KCL 0.11 prints it.
+const kclWarningReport = `warning[W2L26]: CompileWarning
+ --> /src/main.k:1:1
+  |
+1 | import units
+  | ^ Module 'units' imported but unused
+  |
+`
+
+func TestParseKclTextReports(t *testing.T) {
+	if got, want := parseKclTextErrors(kclErrorReport), []kclDiagnostic{
+		{Severity: "error", File: "/src/main.k", Line: 3, Column: 5, Message: "CompileError: name 'b' is not defined"},
+	}; !reflect.DeepEqual(got, want) {
+		t.Errorf("parseKclTextErrors() = %+v, want %+v", got, want)
+	}
+	if got, want := parseKclTextWarnings(kclWarningReport+"\na: 1\n"), []kclDiagnostic{
+		{Severity: "warning", File: "/src/main.k", Line: 1, Column: 1, Message: "CompileWarning: Module 'units' imported but unused"},
+	}; !reflect.DeepEqual(got, want) {
+		t.Errorf("parseKclTextWarnings() = %+v, want %+v", got, want)
+	}
+	if got := parseKclTextErrors("a: 1\n"); got != nil {
+		t.Errorf("parseKclTextErrors() of plain output = %+v, want none", got)
+	}
+}
+
+// diagnosticsKcl is a fake KCL that records its arguments in args, prints
+// a warning report and fails with an error report when main.k holds "fail".
+func diagnosticsKcl(t *testing.T, args string) string {
+	return fakeKcl(t, `[ "$1" = version ] && { echo "0.11.0"; exit; }
+echo "$*" > "`+args+`"
+cat >&2 <<'EOF'
+`+kclWarningReport+`EOF
+if grep -q fail main.k; then
+	cat >&2 <<'EOF'
+`+kclErrorReport+`EOF
+	exit 1
+fi
+echo "a: 1"`)
+}
+
+func TestKclExecResource_JSONDiagnostics(t *testing.T) {
+	args := t.TempDir() + "/args"
+	h := newExecHarness(t, diagnosticsKcl(t, args))
+	dir := writeTestFiles(t, map[string]string{"main.k": "a = 1\n"})
+
+	model := h.mustApply(map[string]attr.Value{
+		"source_dir":       types.StringValue(dir),
+		"json_diagnostics": types.BoolValue(true),
+	})
+	var diags []kclDiagnostic
+	if d := model.Diagnostics.ElementsAs(context.Background(), &diags, false); d.HasError() {
+		t.Fatal(d)
+	}
+	if len(diags) != 1 || diags[0].Severity != "warning" || diags[0].Line != 1 {
+		t.Errorf("diagnostics = %+v, want the warning", diags)
+	}
+	if model.Stdout.ValueString() != "a: 1" {
+		t.Errorf("stdout = %q, want the program output", model.Stdout.ValueString())
+	}
+	if got := readTestFile(t, args); strings.Contains(got, "json") {
+		t.Errorf("KCL was run with %q, which has no JSON diagnostics flag", got)
+	}
+}
+
+func TestKclExecResource_JSONDiagnosticsError(t *testing.T) {
+	h := newExecHarness(t, diagnosticsKcl(t, t.TempDir()+"/args"))
+	dir := writeTestFiles(t, map[string]string{"main.k": "fail\n"})
+
+	_, diags := h.apply(map[string]attr.Value{
+		"source_dir":       types.StringValue(dir),
+		"json_diagnostics": types.BoolValue(true),
+	})
+	if !diags.HasError() || diags.Errors()[0].Summary() != "KCL Compilation Error" ||
+		diags.Errors()[0].Detail() != "/src/main.k:3:5: CompileError: name 'b' is not defined" {
+		t.Fatalf("apply diagnostics = %v, want the compile error with its position", diags)
+	}
+}
@@ -0,0 +1,396 @@
+// internal/provider/kcl_doc.go
+package provider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// validDocFormats are the documentation formats `kcl doc generate` writes.
+var validDocFormats = []string{"md", "html", "openapi"}
+
+// Ensure provider defined types fully satisfy framework interfaces
+var (
+	_ resource.Resource                   = &KclDocResource{}
+	_ resource.ResourceWithConfigure      = &KclDocResource{}
+	_ resource.ResourceWithValidateConfig = &KclDocResource{}
+)
+
+func NewKclDocResource() resource.Resource {
+	return &KclDocResource{}
+}
+
+type KclDocResource struct {
+	provider *kclProvider
+}
+
+type KclDocResourceModel struct {
+	ID             types.String `tfsdk:"id"`
+	SourceDir      types.String `tfsdk:"source_dir"`
+	OutputDir      types.String `tfsdk:"output_dir"`
+	Format         types.String `tfsdk:"format"`
+	Timeout        types.Int64  `tfsdk:"timeout"`
+	GeneratedFiles types.List   `tfsdk:"generated_files"`
+}
+
+func (r *KclDocResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_doc"
+}
+
+func (r *KclDocResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Generates documentation for the schemas of a KCL package with `kcl doc generate`. On refresh the " +
+			"documentation is generated again into a scratch directory and compared with `output_dir`; when it is stale the " +
+			"resource is planned for creation again, so the next apply regenerates it",
+
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Absolute path of the output directory",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"source_dir": schema.StringAttribute{
+				Required:            true,
+				MarkdownDescription: "Path to the KCL package to document",
+			},
+			"output_dir": schema.StringAttribute{
+				Required:            true,
+				MarkdownDescription: "Directory the documentation is written to. Files not generated by KCL are left alone",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"format": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Documentation format: `md`, `html` or `openapi` (default: `md`)",
+			},
+			"timeout": schema.Int64Attribute{
+				Optional:            true,
+				MarkdownDescription: "Generation timeout in seconds (default: 300)",
+			},
+			"generated_files": schema.ListAttribute{
+				ElementType:         types.StringType,
+				Computed:            true,
+				MarkdownDescription: "Paths, relative to `output_dir`, of the generated files",
+			},
+		},
+	}
+}
+
+func (r *KclDocResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	provider, ok := req.ProviderData.(*kclProvider)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Provider Data Type",
+			fmt.Sprintf("Expected *kclProvider, got: %T", req.ProviderData),
+		)
+		return
+	}
+
+	r.provider = provider
+}
+
+func (r *KclDocResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config KclDocResourceModel
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if config.Format.IsNull() || config.Format.IsUnknown() {
+		return
+	}
+	for _, v := range validDocFormats {
+		if config.Format.ValueString() == v {
+			return
+		}
+	}
+	resp.Diagnostics.AddAttributeError(path.Root("format"), "Invalid Documentation Format",
+		fmt.Sprintf("unknown format %q, expected one of: %s", config.Format.ValueString(), strings.Join(validDocFormats, ", ")))
+}
+
+func (r *KclDocResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan KclDocResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(r.generate(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *KclDocResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state KclDocResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	outputDir := state.ID.ValueString()
+	if _, err := os.Stat(outputDir); os.IsNotExist(err) {
+		tflog.Warn(ctx, "Documentation directory no longer exists, removing from state", map[string]interface{}{
+			"path": outputDir,
+		})
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	sourceDir, err := filepath.Abs(state.SourceDir.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Path Resolution Error", "Invalid source directory path: "+err.Error())
+		return
+	}
+	if _, err := os.Stat(sourceDir); os.IsNotExist(err) {
+		tflog.Warn(ctx, "Documented source directory no longer exists, removing from state", map[string]interface{}{
+			"path": sourceDir,
+		})
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	kclCommand, err := r.provider.resolveKclCommand()
+	if err != nil {
+		resp.Diagnostics.AddError("KCL Executable Not Found", err.Error())
+		return
+	}
+
+	// Generate a scratch copy so checking never touches the real files
+	scratch, err := os.MkdirTemp("", "kclx-doc-*")
+	if err != nil {
+		resp.Diagnostics.AddError("KCL Documentation Check Failed", "Unable to create a temporary directory: "+err.Error())
+		return
+	}
+	defer os.RemoveAll(scratch)
+
+	output, err := generateKclDocs(ctx, r.provider, kclCommand, sourceDir, scratch, docFormat(state), docTimeout(state))
+	if err != nil {
+		resp.Diagnostics.AddError("KCL Documentation Check Failed", fmt.Sprintf("Error: %v\nOutput: %s", err, output))
+		return
+	}
+
+	stale, err := staleFiles(scratch, outputDir)
+	if err != nil {
+		resp.Diagnostics.AddError("KCL Documentation Check Failed", "Unable to compare documentation: "+err.Error())
+		return
+	}
+	if len(stale) > 0 {
+		tflog.Info(ctx, "KCL documentation is stale, planning to generate again", map[string]interface{}{
+			"path":  outputDir,
+			"files": stale,
+		})
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	diags = resp.State.Set(ctx, state)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *KclDocResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan KclDocResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(r.generate(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *KclDocResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	// Generated documentation is left in place
+}
+
+// generate runs `kcl doc generate` for plan into a scratch directory, copies
+// the result into output_dir and fills in the computed attributes of plan.
+// A failed generation leaves output_dir untouched.
+func (r *KclDocResource) generate(ctx context.Context, plan *KclDocResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	sourceDir, err := filepath.Abs(plan.SourceDir.ValueString())
+	if err != nil {
+		diags.AddError("Path Resolution Error", "Invalid source directory path: "+err.Error())
+		return diags
+	}
+	if _, err := os.Stat(sourceDir); os.IsNotExist(err) {
+		diags.AddError("Directory Not Found", "Source directory does not exist: "+sourceDir)
+		return diags
+	}
+
+	outputDir, err := filepath.Abs(plan.OutputDir.ValueString())
+	if err != nil {
+		diags.AddAttributeError(path.Root("output_dir"), "Path Resolution Error", "Invalid output directory path: "+err.Error())
+		return diags
+	}
+
+	kclCommand, err := r.provider.resolveKclCommand()
+	if err != nil {
+		diags.AddError("KCL Executable Not Found", err.Error())
+		return diags
+	}
+
+	scratch, err := os.MkdirTemp("", "kclx-doc-*")
+	if err != nil {
+		diags.AddError("KCL Documentation Failed", "Unable to create a temporary directory: "+err.Error())
+		return diags
+	}
+	defer os.RemoveAll(scratch)
+
+	output, err := generateKclDocs(ctx, r.provider, kclCommand, sourceDir, scratch, docFormat(*plan), docTimeout(*plan))
+	if err != nil {
+		diags.AddError("KCL Documentation Failed", fmt.Sprintf("Error: %v\nOutput: %s", err, output))
+		return diags
+	}
+
+	generated, err := copyTree(scratch, outputDir)
+	if err != nil {
+		diags.AddAttributeError(path.Root("output_dir"), "Documentation Write Error", err.Error())
+		return diags
+	}
+
+	plan.ID = types.StringValue(outputDir)
+	generatedFiles, listDiags := types.ListValueFrom(ctx, types.StringType, generated)
+	diags.Append(listDiags...)
+	plan.GeneratedFiles = generatedFiles
+	return diags
+}
+
+func docFormat(model KclDocResourceModel) string {
+	if model.Format.IsNull() {
+		return "md"
+	}
+	return model.Format.ValueString()
+}
+
+func docTimeout(model KclDocResourceModel) time.Duration {
+	if model.Timeout.IsNull() {
+		return 300 * time.Second
+	}
+	return time.Duration(model.Timeout.ValueInt64()) * time.Second
+}
+
+// generateKclDocs runs `kcl doc generate` for the package in sourceDir,
+// writing documentation in format to target.
+func generateKclDocs(ctx context.Context, p *kclProvider, kclCommand, sourceDir, target, format string, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, effectiveTimeout(ctx, timeout))
+	defer cancel()
+
+	args := []string{"doc", "generate", "--file-path", sourceDir, "--target", target, "--format", format}
+	cmd := exec.CommandContext(ctx, kclCommand, args...)
+	cmd.Dir = sourceDir
+	configureGracefulStop(cmd)
+
+	tflog.Info(ctx, "Generating KCL documentation", map[string]interface{}{
+		"command":   kclCommand,
+		"arguments": args,
+		"timeout":   timeout,
+	})
+
+	result, err := p.runKcl(ctx, cmd)
+	output := strings.TrimSpace(string(result.Combined))
+	if err != nil {
+		return output, fmt.Errorf("%s %s: %w", kclCommand, strings.Join(args, " "), err)
+	}
+	return output, nil
+}
+
+// walkFiles calls fn with the slash-separated path, relative to root, of
+// every regular file below root, in lexical order.
+func walkFiles(root string, fn func(rel, abs string) error) error {
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		return fn(filepath.ToSlash(rel), p)
+	})
+}
+
+// copyTree copies every file below src into dst, keeping their relative
+// paths, and returns those paths sorted.
+func copyTree(src, dst string) ([]string, error) {
+	copied := []string{}
+	err := walkFiles(src, func(rel, abs string) error {
+		content, err := os.ReadFile(abs)
+		if err != nil {
+			return err
+		}
+
+		perm, _ := parseFilePermission(defaultOutputFilePermission)
+		if err := writeFileAtomic(filepath.Join(dst, filepath.FromSlash(rel)), string(content), perm); err != nil {
+			return err
+		}
+		copied = append(copied, rel)
+		return nil
+	})
+	sort.Strings(copied)
+	return copied, err
+}
+
+// staleFiles returns the files below want whose counterpart below have is
+// missing or differs, as sorted slash-separated relative paths.
+func staleFiles(want, have string) ([]string, error) {
+	stale := []string{}
+	err := walkFiles(want, func(rel, abs string) error {
+		expected, err := os.ReadFile(abs)
+		if err != nil {
+			return err
+		}
+
+		actual, err := os.ReadFile(filepath.Join(have, filepath.FromSlash(rel)))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if err != nil || !bytes.Equal(expected, actual) {
+			stale = append(stale, rel)
+		}
+		return nil
+	})
+	sort.Strings(stale)
+	return stale, err
+}
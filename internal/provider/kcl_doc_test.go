@@ -0,0 +1,118 @@
+// internal/provider/kcl_doc_test.go
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// docKcl is a fake KCL whose doc generate writes the package's KCL files
+// to docs/schemas.<format> below the target.
+const docKcl = `[ "$1 $2" = "doc generate" ] || exit 1
+ls "$4"/*.k > /dev/null 2>&1 || { echo "no KCL files in $4" >&2; exit 1; }
+mkdir -p "$6/docs" && cat "$4"/*.k > "$6/docs/schemas.$8"`
+
+func newKclDocHarness(t *testing.T, kclBinary string) *resourceHarness {
+	t.Helper()
+	return newResourceHarness(t, &KclDocResource{provider: &kclProvider{KclPath: kclBinary, KclBinary: kclBinary}})
+}
+
+func TestKclDocResource_Create(t *testing.T) {
+	src := writeTestFiles(t, map[string]string{"main.k": "schema App:\n    name: str\n"})
+	out := writeTestFiles(t, map[string]string{"README.md": "kept\n"})
+	h := newKclDocHarness(t, fakeKcl(t, docKcl))
+
+	h.mustApply(map[string]attr.Value{
+		"source_dir": types.StringValue(src),
+		"output_dir": types.StringValue(out),
+		"format":     types.StringValue("html"),
+	})
+	var model KclDocResourceModel
+	h.model(&model)
+	var generated []string
+	model.GeneratedFiles.ElementsAs(h.ctx, &generated, false)
+	if want := []string{"docs/schemas.html"}; !reflect.DeepEqual(generated, want) {
+		t.Errorf("generated_files = %q, want %q", generated, want)
+	}
+	if got := readTestFile(t, filepath.Join(out, "docs", "schemas.html")); got != "schema App:\n    name: str\n" {
+		t.Errorf("docs/schemas.html = %q", got)
+	}
+	if got := readTestFile(t, filepath.Join(out, "README.md")); got != "kept\n" {
+		t.Errorf("README.md = %q, want files KCL did not generate left alone", got)
+	}
+}
+
+func TestKclDocResource_ReadDetectsDrift(t *testing.T) {
+	tests := []struct {
+		name  string
+		drift func(src, out string) error
+	}{
+		{"unchanged", func(string, string) error { return nil }},
+		{"schema changed", func(src, _ string) error {
+			return os.WriteFile(filepath.Join(src, "main.k"), []byte("schema App:\n    name: int\n"), 0o644)
+		}},
+		{"documentation edited", func(_, out string) error {
+			return os.WriteFile(filepath.Join(out, "docs", "schemas.md"), []byte("edited\n"), 0o644)
+		}},
+		{"documentation removed", func(_, out string) error { return os.RemoveAll(out) }},
+		{"source removed", func(src, _ string) error { return os.RemoveAll(src) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := writeTestFiles(t, map[string]string{"main.k": "schema App:\n    name: str\n"})
+			out := filepath.Join(t.TempDir(), "docs")
+			h := newKclDocHarness(t, fakeKcl(t, docKcl))
+			h.mustApply(map[string]attr.Value{
+				"source_dir": types.StringValue(src),
+				"output_dir": types.StringValue(out),
+			})
+
+			if err := tt.drift(src, out); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := h.mustRead(), tt.name == "unchanged"; got != want {
+				t.Errorf("resource kept in state = %t, want %t", got, want)
+			}
+		})
+	}
+}
+
+func TestKclDocResource_Errors(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "docs")
+	h := newKclDocHarness(t, fakeKcl(t, docKcl))
+
+	diags := h.apply(map[string]attr.Value{
+		"source_dir": types.StringValue(t.TempDir()),
+		"output_dir": types.StringValue(out),
+	})
+	if !diags.HasError() || diags.Errors()[0].Summary() != "KCL Documentation Failed" ||
+		!strings.Contains(diags.Errors()[0].Detail(), "no KCL files") {
+		t.Errorf("apply diagnostics = %v, want a generation error with KCL's output", diags)
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Errorf("a failed generation created output_dir: %v", err)
+	}
+
+	diags = h.apply(map[string]attr.Value{
+		"source_dir": types.StringValue(filepath.Join(t.TempDir(), "missing")),
+		"output_dir": types.StringValue(out),
+	})
+	if !diags.HasError() || diags.Errors()[0].Summary() != "Directory Not Found" {
+		t.Errorf("apply diagnostics = %v, want a missing directory error", diags)
+	}
+
+	diags = h.apply(map[string]attr.Value{
+		"source_dir": types.StringValue(t.TempDir()),
+		"output_dir": types.StringValue(out),
+		"format":     types.StringValue("pdf"),
+	})
+	if !diags.HasError() || diags.Errors()[0].Summary() != "Invalid Documentation Format" {
+		t.Errorf("apply diagnostics = %v, want an invalid format error", diags)
+	}
+}
@@ -2,28 +2,72 @@
 package provider
 
 import (
+	"bufio"
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
+	"text/template"
 	"time"
 
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/mapplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/objectplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
+const (
+	// inputFromOption is the top-level argument carrying the input_from file path.
+	inputFromOption = "input_from_file"
+
+	// inlineCodeFileName is the file the code attribute is written to.
+	inlineCodeFileName = "main.k"
+
+	// threadsEnvVar bounds the parallelism of the KCL CLI runtime.
+	threadsEnvVar = "GOMAXPROCS"
+
+	// pkgPathEnvVar points KCL at the local package storage.
+	pkgPathEnvVar = "KCL_PKG_PATH"
+
+	// noColorEnvVar disables colored output in KCL and the tools it runs.
+	noColorEnvVar = "NO_COLOR"
+
+	// metadataEnvPrefix starts the names of the variables inject_tf_metadata
+	// passes metadata in.
+	metadataEnvPrefix = "KCLX_META_"
+
+	// minKclTOMLVersion is the first KCL release printing TOML. Older ones
+	// run with --format json and the provider converts their output.
+	minKclTOMLVersion = "0.8.0"
+)
+
+// sourceHashPattern matches a source_hash value.
+var sourceHashPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
 // Ensure provider defined types fully satisfy framework interfaces
 var (
-	_ resource.Resource              = &KclExecResource{}
-	_ resource.ResourceWithConfigure = &KclExecResource{}
+	_ resource.Resource                   = &KclExecResource{}
+	_ resource.ResourceWithConfigure      = &KclExecResource{}
+	_ resource.ResourceWithValidateConfig = &KclExecResource{}
+	_ resource.ResourceWithModifyPlan     = &KclExecResource{}
+	_ resource.ResourceWithImportState    = &KclExecResource{}
+	_ resource.ResourceWithUpgradeState   = &KclExecResource{}
 )
 
 func NewKclExecResource() resource.Resource {
@@ -38,10 +82,142 @@ type KclExecResourceModel struct {
 	ID          types.String `tfsdk:"id"`
 	SourceDir   types.String `tfsdk:"source_dir"`
 	Output      types.String `tfsdk:"output"`
+	Stdout      types.String `tfsdk:"stdout"`
+	Stderr      types.String `tfsdk:"stderr"`
+	ExitCode    types.Int64  `tfsdk:"exit_code"`
 	Args        types.List   `tfsdk:"args"`
+	Subcommand  types.String `tfsdk:"subcommand"`
+	EntryFiles  types.List   `tfsdk:"entry_files"`
 	Triggers    types.Map    `tfsdk:"triggers"`
 	Timeout     types.Int64  `tfsdk:"timeout"`
+	KillTimeout types.Int64  `tfsdk:"kill_timeout"`
+	PreCommand  types.List   `tfsdk:"pre_command"`
+	PostCommand types.List   `tfsdk:"post_command"`
 	Environment types.Map    `tfsdk:"environment"`
+
+	EnvironmentFiles types.List `tfsdk:"environment_files"`
+
+	JSONDiagnostics types.Bool `tfsdk:"json_diagnostics"`
+	Diagnostics     types.List `tfsdk:"diagnostics"`
+
+	SuccessMarker  types.String `tfsdk:"success_marker"`
+	FailOnWarnings types.Bool   `tfsdk:"fail_on_warnings"`
+
+	EnvironmentFromFiles types.Map `tfsdk:"environment_from_files"`
+
+	OutputTransforms types.List `tfsdk:"output_transforms"`
+
+	InputFrom types.String `tfsdk:"input_from"`
+
+	Stdin types.String `tfsdk:"stdin"`
+
+	KeepTempOnFailure types.Bool `tfsdk:"keep_temp_on_failure"`
+
+	KclPath types.String `tfsdk:"kcl_path"`
+
+	WorkingDir types.String `tfsdk:"working_dir"`
+
+	DependencyClosureHash types.String `tfsdk:"dependency_closure_hash"`
+	Lockfile              types.String `tfsdk:"lockfile"`
+
+	OutputEncoding types.String `tfsdk:"output_encoding"`
+
+	Metadata         types.Map  `tfsdk:"metadata"`
+	InjectTFMetadata types.Bool `tfsdk:"inject_tf_metadata"`
+
+	Code types.String `tfsdk:"code"`
+
+	HTTPSource  types.String `tfsdk:"http_source"`
+	HTTPSHA256  types.String `tfsdk:"http_sha256"`
+	HTTPHeaders types.Map    `tfsdk:"http_headers"`
+
+	OCIRef    types.String `tfsdk:"oci_ref"`
+	OCIDigest types.String `tfsdk:"oci_digest"`
+
+	VerifyDeterministic types.Bool `tfsdk:"verify_deterministic"`
+
+	IndependentEntries     types.Bool `tfsdk:"independent_entries"`
+	EntryResults           types.Map  `tfsdk:"entry_results"`
+	Incremental            types.Bool `tfsdk:"incremental"`
+	FullOnDependencyChange types.Bool `tfsdk:"full_on_dependency_change"`
+
+	CommandLine types.List  `tfsdk:"command_line"`
+	DurationMs  types.Int64 `tfsdk:"duration_ms"`
+
+	Threads types.Int64 `tfsdk:"threads"`
+
+	MaxStateOutputBytes types.Int64 `tfsdk:"max_state_output_bytes"`
+
+	ResultCompactJSON types.String `tfsdk:"result_compact_json"`
+
+	Verify *KclVerifyModel `tfsdk:"verify"`
+
+	Git       *KclGitModel `tfsdk:"git"`
+	GitCommit types.String `tfsdk:"git_commit"`
+
+	AllowedExitCodes types.List `tfsdk:"allowed_exit_codes"`
+
+	Arguments     types.Map  `tfsdk:"arguments"`
+	SettingsFiles types.List `tfsdk:"settings_files"`
+
+	ExternalPackages types.Map `tfsdk:"external_packages"`
+
+	PathSelectors types.List `tfsdk:"path_selectors"`
+	Overrides     types.List `tfsdk:"overrides"`
+
+	SortKeys         types.Bool `tfsdk:"sort_keys"`
+	DisableNone      types.Bool `tfsdk:"disable_none"`
+	StrictRangeCheck types.Bool `tfsdk:"strict_range_check"`
+
+	Quiet   types.Bool `tfsdk:"quiet"`
+	Debug   types.Bool `tfsdk:"debug"`
+	NoStyle types.Bool `tfsdk:"no_style"`
+	DryRun  types.Bool `tfsdk:"dry_run"`
+
+	Vendor    types.Bool   `tfsdk:"vendor"`
+	VendorDir types.String `tfsdk:"vendor_dir"`
+
+	InheritEnvironment types.Bool `tfsdk:"inherit_environment"`
+
+	Force types.Bool `tfsdk:"force"`
+
+	Retries            types.Int64 `tfsdk:"retries"`
+	RetryInterval      types.Int64 `tfsdk:"retry_interval"`
+	RetryCompileErrors types.Bool  `tfsdk:"retry_compile_errors"`
+
+	SourceHash types.String `tfsdk:"source_hash"`
+
+	OutputSHA256 types.String `tfsdk:"output_sha256"`
+	Exclude      types.List   `tfsdk:"exclude"`
+
+	SensitiveOutput      types.Bool   `tfsdk:"sensitive_output"`
+	SensitiveEnvironment types.Set    `tfsdk:"sensitive_environment"`
+	SensitiveArguments   types.Set    `tfsdk:"sensitive_arguments"`
+	OutputSensitive      types.String `tfsdk:"output_sensitive"`
+	StdoutSensitive      types.String `tfsdk:"stdout_sensitive"`
+
+	OutputFile                types.String `tfsdk:"output_file"`
+	OutputFilePermission      types.String `tfsdk:"output_file_permission"`
+	DeleteOutputFileOnDestroy types.Bool   `tfsdk:"delete_output_file_on_destroy"`
+	OutputDir                 types.String `tfsdk:"output_dir"`
+	FilenameTemplate          types.String `tfsdk:"filename_template"`
+	OutputDirFiles            types.List   `tfsdk:"output_dir_files"`
+
+	Documents     types.List  `tfsdk:"documents"`
+	DocumentCount types.Int64 `tfsdk:"document_count"`
+
+	DocumentsByKindName types.Map `tfsdk:"documents_by_kind_name"`
+
+	StdoutLines types.List `tfsdk:"stdout_lines"`
+	StderrLines types.List `tfsdk:"stderr_lines"`
+
+	Format types.String  `tfsdk:"format"`
+	Result types.Dynamic `tfsdk:"result"`
+
+	OutputToml types.String `tfsdk:"output_toml"`
+
+	ResultSchema     types.String `tfsdk:"result_schema"`
+	ResultSchemaFile types.String `tfsdk:"result_schema_file"`
 }
 
 func (r *KclExecResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -50,50 +226,596 @@ func (r *KclExecResource) Metadata(_ context.Context, req resource.MetadataReque
 
 func (r *KclExecResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
-		MarkdownDescription: "Executes KCL (Kusion Configuration Language) scripts in a specified directory",
+		// Bump with a new upgrader in kcl_exec_upgrade.go whenever stored state
+		// needs converting
+		Version: kclExecSchemaVersion,
+
+		MarkdownDescription: "Executes KCL (Kusion Configuration Language) scripts in a specified directory. " +
+			"Import with the `source_dir` as ID, optionally followed by `@` and the expected `source_hash`; the import runs KCL to fill in the outputs",
 
 		Attributes: map[string]schema.Attribute{
 			"id": schema.StringAttribute{
 				Computed:            true,
-				MarkdownDescription: "Unique identifier for the execution",
+				MarkdownDescription: "Unique identifier for the execution, recomputed whenever the inputs change",
+			},
+			"source_dir": schema.StringAttribute{
+				Optional: true,
+				MarkdownDescription: "Path to directory containing KCL scripts, or to a single `.k` file, which is then run from its directory. " +
+					"Exactly one of `source_dir`, `code`, `http_source`, `oci_ref` or `git` must be set",
 				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.UseStateForUnknown(),
+					stringplanmodifier.RequiresReplace(),
 				},
 			},
-			"source_dir": schema.StringAttribute{
-				Required:            true,
-				MarkdownDescription: "Path to directory containing KCL scripts",
+			"working_dir": schema.StringAttribute{
+				Optional: true,
+				MarkdownDescription: "Directory KCL runs in, e.g. the package root holding `kcl.mod`, when it differs from `source_dir`. " +
+					"Relative `entry_files`, `settings_files` and `external_packages` paths resolve against it. " +
+					"Without `entry_files`, `source_dir` is passed to KCL relative to it. Defaults to `source_dir`; only valid with `source_dir`",
+			},
+			"code": schema.StringAttribute{
+				Optional: true,
+				MarkdownDescription: "Inline KCL source to run instead of `source_dir`. It is written to `" + inlineCodeFileName + "` " +
+					"in a temporary directory that is removed after execution",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"http_source": schema.StringAttribute{
+				Optional: true,
+				MarkdownDescription: "HTTP(S) URL of a `.k` file or a `.tar.gz` archive of KCL sources to evaluate instead of `source_dir`. " +
+					"The download is extracted into a temporary directory that is removed after execution",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"oci_ref": schema.StringAttribute{
+				Optional: true,
+				MarkdownDescription: "KCL package in an OCI registry to run instead of `source_dir`, as `oci://<registry>/<repository>:<tag>`. " +
+					"KCL pulls it with the provider's `registry` credentials; other registries are accessed anonymously",
 				PlanModifiers: []planmodifier.String{
 					stringplanmodifier.RequiresReplace(),
 				},
 			},
+			"oci_digest": schema.StringAttribute{
+				Computed: true,
+				MarkdownDescription: "Manifest digest the `oci_ref` tag resolved to. It is resolved again on every plan and goes into `id`, " +
+					"so a tag that moved re-runs the resource. Null unless `oci_ref` is set",
+			},
+			"http_sha256": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Expected hex SHA-256 of the `http_source` download",
+			},
+			"http_headers": schema.MapAttribute{
+				ElementType:         types.StringType,
+				Optional:            true,
+				Sensitive:           true,
+				MarkdownDescription: "HTTP headers sent when downloading `http_source`, e.g. for authentication",
+			},
 			"output": schema.StringAttribute{
 				Computed:            true,
 				MarkdownDescription: "Combined standard output and error from KCL execution",
 			},
+			"stdout": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Standard output from KCL execution, without anything written to standard error",
+			},
+			"stderr": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Standard error from KCL execution",
+			},
+			"stdout_lines": schema.ListAttribute{
+				ElementType:         types.StringType,
+				Computed:            true,
+				MarkdownDescription: "Lines of `stdout`, without line endings (LF or CRLF) and trailing empty lines. Null when the output is sensitive",
+			},
+			"stderr_lines": schema.ListAttribute{
+				ElementType:         types.StringType,
+				Computed:            true,
+				MarkdownDescription: "Lines of `stderr`, without line endings (LF or CRLF) and trailing empty lines",
+			},
+			"sensitive_output": schema.BoolAttribute{
+				Optional: true,
+				MarkdownDescription: "Treat the output as a secret (default: false). The output is then stored only in the sensitive " +
+					"`output_sensitive` and `stdout_sensitive` attributes, while `output`, `stdout`, `documents`, `result` and " +
+					"`result_compact_json` are null. Output lines are masked in logs and failure diagnostics show only its size and SHA-256",
+			},
+			"sensitive_environment": schema.SetAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				MarkdownDescription: "Names of `environment` entries whose values are masked wherever they would appear in logs " +
+					"and replaced with `" + redactedValue + "` in `command_line`",
+			},
+			"sensitive_arguments": schema.SetAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				MarkdownDescription: "Keys of `arguments` whose values are masked in logs and replaced with `" + redactedValue + "` " +
+					"in `command_line` and error messages",
+			},
+			"output_sensitive": schema.StringAttribute{
+				Computed:            true,
+				Sensitive:           true,
+				MarkdownDescription: "`output` when `sensitive_output` is true, otherwise null",
+			},
+			"stdout_sensitive": schema.StringAttribute{
+				Computed:            true,
+				Sensitive:           true,
+				MarkdownDescription: "`stdout` when `sensitive_output` is true, otherwise null",
+			},
+			"exit_code": schema.Int64Attribute{
+				Computed:            true,
+				MarkdownDescription: "Exit code of the KCL process",
+			},
+			"allowed_exit_codes": schema.ListAttribute{
+				ElementType: types.Int64Type,
+				Optional:    true,
+				MarkdownDescription: "Non-zero exit codes that are accepted instead of failing the run, e.g. `[1]` to inspect " +
+					"`kcl vet` validation failures. `exit_code`, `stdout` and `stderr` are still recorded. " +
+					"`success_marker` is only enforced for exit code 0",
+			},
+			"subcommand": schema.StringAttribute{
+				Optional: true,
+				MarkdownDescription: "KCL subcommand placed before all other arguments: `run`, `vet`, `fmt`, `test` or `doc`. " +
+					"Defaults to `run`, unless `args` or the provider's `default_args` already start with a subcommand",
+			},
+			"entry_files": schema.ListAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				MarkdownDescription: "KCL files to run, merged by KCL in the given order. Relative paths are resolved against the " +
+					"directory KCL runs in. They are passed as positional arguments right after `subcommand`, ahead of all flags",
+			},
+			"independent_entries": schema.BoolAttribute{
+				Optional: true,
+				MarkdownDescription: "Run each of `entry_files` as its own KCL program instead of merging them, and expose the results " +
+					"in `entry_results`. Every entry runs even when another fails, and each failure is reported on its own. " +
+					"`output` and `stdout` hold the outputs of all entries in order, separated by `---` lines, and `result` " +
+					"is an object of the results keyed by entry. Results are not cached (default: false)",
+			},
+			"incremental": schema.BoolAttribute{
+				Optional: true,
+				MarkdownDescription: "With `independent_entries`, run only the entries whose content changed since the last apply and " +
+					"reuse the recorded output of the others. The entry hashes and outputs are kept in private state. An entry is " +
+					"only compared by its own content, so while `full_on_dependency_change` is disabled, a change to a file it " +
+					"imports goes unnoticed until the entry itself changes (default: false)",
+			},
+			"full_on_dependency_change": schema.BoolAttribute{
+				Optional: true,
+				MarkdownDescription: "With `incremental`, run every entry again when anything besides the entry files changed: " +
+					"the other sources, the arguments or the environment (default: true)",
+			},
 			"args": schema.ListAttribute{
 				ElementType:         types.StringType,
 				Optional:            true,
-				MarkdownDescription: "Additional arguments to pass to KCL command",
+				MarkdownDescription: "Additional arguments to pass to KCL command, placed after the provider's `default_args`",
 				PlanModifiers:       []planmodifier.List{},
 			},
+			"arguments": schema.MapAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				MarkdownDescription: "Top-level arguments readable with `option(\"key\")`, passed as `-D key=value` in sorted key order " +
+					"after `args`. Values are passed verbatim without shell quoting",
+			},
+			"settings_files": schema.ListAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				MarkdownDescription: "KCL settings files such as `kcl.yaml`, each passed as `-Y <path>` in order. Relative paths are " +
+					"resolved against `source_dir`, or the working directory when `code`, `http_source` or `oci_ref` is used",
+			},
+			"external_packages": schema.MapAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				MarkdownDescription: "Packages resolved from local directories instead of a registry, keyed by package name and " +
+					"passed as `-E name=path` in sorted order. Relative paths are resolved against `source_dir`, or the working " +
+					"directory when `code`, `http_source` or `oci_ref` is used",
+			},
 			"triggers": schema.MapAttribute{
 				ElementType:         types.StringType,
 				Optional:            true,
-				MarkdownDescription: "Map of values that should trigger re-execution when changed",
-				PlanModifiers:       []planmodifier.Map{},
+				MarkdownDescription: "Map of values that should trigger re-execution when changed. Like `null_resource`, any change replaces the resource",
+				PlanModifiers: []planmodifier.Map{
+					mapplanmodifier.RequiresReplace(),
+				},
 			},
 			"timeout": schema.Int64Attribute{
 				Optional:            true,
 				MarkdownDescription: "Execution timeout in seconds (default: 300)",
 				PlanModifiers:       []planmodifier.Int64{},
 			},
+			"kill_timeout": schema.Int64Attribute{
+				Optional: true,
+				MarkdownDescription: "Seconds KCL gets to exit after SIGTERM when the run is interrupted or times out, before it is killed. " +
+					"Output written until then is reported. `0` kills right away (default: 10)",
+			},
+			"pre_command": schema.ListAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				MarkdownDescription: "Command and arguments run before KCL, e.g. to generate inputs. It runs in the directory KCL " +
+					"runs in, with the same environment, and counts against `timeout`. It runs before the result cache is " +
+					"consulted, so generated files are part of the cache key. A failure stops the execution",
+			},
+			"post_command": schema.ListAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				MarkdownDescription: "Command and arguments run after a successful KCL run, e.g. to copy artifacts out. It runs in " +
+					"the directory KCL runs in, with the same environment, and counts against `timeout`. A failure fails the execution",
+			},
 			"environment": schema.MapAttribute{
 				ElementType:         types.StringType,
 				Optional:            true,
-				MarkdownDescription: "Environment variables to set during execution",
+				MarkdownDescription: "Environment variables to set during execution. Merged over the provider's `default_environment`, with these values winning on conflicts",
 				PlanModifiers:       []planmodifier.Map{},
 			},
+			"environment_files": schema.ListAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				MarkdownDescription: "Dotenv files whose `KEY=VALUE` lines are added to the environment, with `#` comments, an optional " +
+					"`export` prefix, and single- or double-quoted values. Later files override earlier ones and the provider's " +
+					"`default_environment`; `environment` overrides them all. Relative paths resolve against the directory KCL runs in",
+			},
+			"inherit_environment": schema.BoolAttribute{
+				Optional: true,
+				MarkdownDescription: "Start from the environment of the Terraform process (default: true). When false, KCL sees only " +
+					"the variables declared through `environment`, `environment_from_files`, `threads` and the provider's " +
+					"`default_environment`, so variables such as `PATH` and `HOME` must be declared explicitly when needed",
+			},
+			"retries": schema.Int64Attribute{
+				Optional: true,
+				MarkdownDescription: "Number of times a failed run is retried, e.g. to ride out a flaky package registry (default: 0). " +
+					"Failures that look like errors in the KCL program are not retried unless `retry_compile_errors` is set. " +
+					"Retries share the overall `timeout`",
+			},
+			"retry_interval": schema.Int64Attribute{
+				Optional:            true,
+				MarkdownDescription: "Seconds to wait between retries (default: 5)",
+			},
+			"retry_compile_errors": schema.BoolAttribute{
+				Optional:            true,
+				MarkdownDescription: "Retry on any failure, including compilation and evaluation errors (default: false)",
+			},
+			"force": schema.BoolAttribute{
+				Optional: true,
+				MarkdownDescription: "Always execute KCL, ignoring any result cached in the provider's `cache_dir`. " +
+					"A successful run still refreshes the cache (default: false)",
+			},
+			"threads": schema.Int64Attribute{
+				Optional: true,
+				MarkdownDescription: "Upper bound on the number of OS threads the KCL process runs in parallel. " +
+					"KCL has no thread-count flag, so this is applied through the `GOMAXPROCS` environment variable read by the KCL CLI runtime",
+			},
+			"environment_from_files": schema.MapAttribute{
+				ElementType:         types.StringType,
+				Optional:            true,
+				MarkdownDescription: "Map of environment variable names to files whose trimmed contents become the variable values. File contents are never stored in state, only hashed into `id`",
+			},
+			"input_from": schema.StringAttribute{
+				Optional: true,
+				MarkdownDescription: "Input for the KCL program, typically the `output` of another `kcl_exec`. " +
+					"The content is written to a temporary file whose path is passed as the top-level argument `" + inputFromOption + "`, " +
+					"so the program can read it with `file.read(option(\"" + inputFromOption + "\"))`",
+			},
+			"kcl_path": schema.StringAttribute{
+				Optional: true,
+				MarkdownDescription: "Path to the KCL executable for this resource, overriding the provider's `kcl_path`, e.g. to try a " +
+					"prerelease. A bare name is looked up on `PATH`. The provider's version constraints do not apply to it",
+			},
+			"stdin": schema.StringAttribute{
+				Optional: true,
+				MarkdownDescription: "Content written to the standard input of KCL, which is closed afterwards so KCL sees end of input. " +
+					"KCL only reads it for a `-` file, so list `\"-\"` in `entry_files` to compile it, on its own or merged with the other files. " +
+					"Only its hash goes into `id`",
+			},
+			"keep_temp_on_failure": schema.BoolAttribute{
+				Optional: true,
+				MarkdownDescription: "Keep the temporary files of a failed run, i.e. the `code` directory, the `git` checkout, the " +
+					"`http_source` download and the `input_from` file, and name them in a warning so what KCL saw can be inspected " +
+					"(default: false). Kept files are never removed by the provider, so remove them after use; leaving this on " +
+					"for a resource that fails repeatedly fills the temporary directory",
+			},
+			"max_state_output_bytes": schema.Int64Attribute{
+				Optional: true,
+				MarkdownDescription: "Fail the run when `output` would exceed this many bytes instead of storing it in state. " +
+					"Unset means no limit",
+			},
+			"output_file": schema.StringAttribute{
+				Optional: true,
+				MarkdownDescription: "Path `stdout` is written to after a successful run. Parent directories are created as needed " +
+					"and the file is replaced atomically",
+			},
+			"output_file_permission": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Octal permission of `output_file` and the files in `output_dir` (default: `\"" + defaultOutputFilePermission + "\"`)",
+			},
+			"delete_output_file_on_destroy": schema.BoolAttribute{
+				Optional:            true,
+				MarkdownDescription: "Remove `output_file` and the files written to `output_dir` when the resource is destroyed (default: false)",
+			},
+			"output_dir": schema.StringAttribute{
+				Optional: true,
+				MarkdownDescription: "Directory each of `documents` is written to as a file of its own after a successful run, named " +
+					"by `filename_template`. Files of documents no longer rendered are left in place",
+			},
+			"filename_template": schema.StringAttribute{
+				Optional: true,
+				MarkdownDescription: "Go template naming the file of each document below `output_dir`. It is executed with `.Index`, " +
+					"the position in `documents`, `.Kind` and `.Name`, the document's `kind` and `metadata.name`, and `.Document`, " +
+					"the decoded document, e.g. `{{ .Document.metadata.namespace }}/{{ .Kind }}-{{ .Name }}.yaml`. A missing key is an error, " +
+					"and so is a name two documents share. Defaults to the `documents_by_kind_name` key followed by `.yaml`",
+			},
+			"output_dir_files": schema.ListAttribute{
+				ElementType: types.StringType,
+				Computed:    true,
+				MarkdownDescription: "Files written to `output_dir`, relative to it and in the order of `documents`. " +
+					"Null unless `output_dir` is set",
+			},
+			"output_encoding": schema.StringAttribute{
+				Optional: true,
+				MarkdownDescription: "Encoding of the KCL process output: `utf8` (default), `latin1` or `utf16`. " +
+					"Output is transcoded to UTF-8 before it is stored; with `utf8`, invalid byte sequences are replaced and reported as a warning",
+			},
+			"output_transforms": schema.ListAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				MarkdownDescription: "Transforms applied, in order, to the output after execution: `sort_keys`, `strip_nulls`, `trim`. " +
+					"When set, replaces the provider's `default_output_transforms`; an empty list disables them. " +
+					"`sort_keys` and `strip_nulls` leave output that is not valid JSON unchanged",
+			},
+			"metadata": schema.MapAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				MarkdownDescription: "Free-form labels attached as `metadata.<key>` fields to every log entry emitted for this resource. " +
+					"Changing them never alters `id`, and unless `inject_tf_metadata` is set it only updates the labels, " +
+					"keeping the outputs of the previous execution",
+			},
+			"inject_tf_metadata": schema.BoolAttribute{
+				Optional: true,
+				MarkdownDescription: "Also pass each `metadata` entry to KCL as an environment variable named `" + metadataEnvPrefix +
+					"<KEY>`, the key upper-cased with every character other than letters, digits and `_` replaced by `_`, " +
+					"e.g. `team-name` becomes `" + metadataEnvPrefix + "TEAM_NAME` (default: false). Variables set through " +
+					"`environment` take precedence. As KCL then sees the metadata, changing it runs KCL again",
+			},
+			"verify_deterministic": schema.BoolAttribute{
+				Optional: true,
+				MarkdownDescription: "Run the program twice and fail if the canonicalized outputs differ, catching timestamps, " +
+					"randomness or unstable ordering. Doubles execution time (default: false)",
+			},
+			"json_diagnostics": schema.BoolAttribute{
+				Optional: true,
+				MarkdownDescription: "Expose the errors and warnings KCL reports in `diagnostics`. KCL has no JSON diagnostics output, " +
+					"so they are parsed from its text reports",
+			},
+			"success_marker": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Literal string or regular expression that must appear in the output for a successful run to be accepted",
+			},
+			"fail_on_warnings": schema.BoolAttribute{
+				Optional: true,
+				MarkdownDescription: "Fail a successful run that reported warnings, instead of showing them as Terraform warnings " +
+					"(default: false)",
+			},
+			"path_selectors": schema.ListAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				MarkdownDescription: "Paths of the parts of the result to output, e.g. `app.spec`, passed as `-S` flags in order. " +
+					"Combine with `format` to decode just the selected value into `result`",
+			},
+			"overrides": schema.ListAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				MarkdownDescription: "Overrides applied to the program before it is evaluated, passed as `-O` flags in order. " +
+					"Each entry is `pkg:path.field=value` to set a field or `pkg:path.field-` to delete it; the package may be empty",
+			},
+			"sort_keys": schema.BoolAttribute{
+				Optional:            true,
+				MarkdownDescription: "Sort the keys of the output (`--sort_keys`, default: false)",
+			},
+			"disable_none": schema.BoolAttribute{
+				Optional:            true,
+				MarkdownDescription: "Omit attributes whose value is `None` from the output (`--disable_none`, default: false)",
+			},
+			"strict_range_check": schema.BoolAttribute{
+				Optional:            true,
+				MarkdownDescription: "Fail on integer and float values out of their 32-bit range (`--strict_range_check`, default: false)",
+			},
+			"quiet": schema.BoolAttribute{
+				Optional: true,
+				MarkdownDescription: "Run KCL in quiet mode (`-q`) and log the executed command at debug instead of info level, " +
+					"to keep applies with many resources readable (default: false)",
+			},
+			"debug": schema.BoolAttribute{
+				Optional:            true,
+				MarkdownDescription: "Run KCL in debug mode (`--debug`) and stream its output lines to the info instead of the debug log (default: false)",
+			},
+			"dry_run": schema.BoolAttribute{
+				Optional: true,
+				MarkdownDescription: "Assemble the command without running it, or any hook (default: false). `command_line` and `id` are " +
+					"set and a warning shows the command, directory and declared environment, with secrets redacted; every output " +
+					"attribute is null",
+			},
+			"no_style": schema.BoolAttribute{
+				Optional: true,
+				MarkdownDescription: "Ask KCL not to color its output by setting `" + noColorEnvVar + "=1` (default: true). " +
+					"ANSI escape sequences are stripped from the captured output either way",
+			},
+			"vendor": schema.BoolAttribute{
+				Optional:            true,
+				MarkdownDescription: "Resolve dependencies from vendored packages instead of the network (`--vendor`, default: false)",
+			},
+			"vendor_dir": schema.StringAttribute{
+				Optional: true,
+				MarkdownDescription: "Directory holding the packages to resolve dependencies from, exported to KCL as `" + pkgPathEnvVar + "`. " +
+					"Relative paths resolve against the directory KCL runs in. It must exist when `vendor` is true",
+			},
+			"format": schema.StringAttribute{
+				Optional: true,
+				MarkdownDescription: "Output format, `json`, `yaml` or `toml`, passed to KCL as `--format` unless `args` already set one. " +
+					"When set, `stdout` is parsed into `result`. When unset no flag is passed and KCL prints YAML",
+			},
+			"result": schema.DynamicAttribute{
+				Computed: true,
+				MarkdownDescription: "`stdout` parsed according to `format` into a Terraform value, e.g. `kcl_exec.x.result.metadata.name`. " +
+					"Null when `format` is not set",
+			},
+			"output_toml": schema.StringAttribute{
+				Computed: true,
+				MarkdownDescription: "The result as a TOML document when `format` is `toml`. KCL releases before " + minKclTOMLVersion +
+					" cannot print TOML, so they are run with `--format json`, `stdout` holds their JSON and the provider converts it. " +
+					"Output TOML cannot represent, a top-level list or scalar or any null value, fails the run. Null for other formats, " +
+					"`independent_entries` and `sensitive_output`",
+			},
+			"result_schema": schema.StringAttribute{
+				Optional: true,
+				MarkdownDescription: "Name of a KCL schema `result` must conform to. `stdout` is checked with `kcl vet` after every " +
+					"run, and a mismatch fails the execution, so downstream configuration can rely on the shape of `result`. " +
+					"Requires `format` `json` or `yaml`",
+			},
+			"result_schema_file": schema.StringAttribute{
+				Optional: true,
+				MarkdownDescription: "KCL file defining `result_schema`, relative to the directory KCL runs in. Defaults to the " +
+					"program itself when it is a single file, i.e. `code` or a `source_dir` naming a file",
+			},
+			"documents": schema.ListAttribute{
+				ElementType: types.StringType,
+				Computed:    true,
+				MarkdownDescription: "`stdout` split into YAML documents on `---` and `...` markers at the start of a line, " +
+					"each trimmed, with empty documents dropped. Suited to `kubernetes_manifest` via `yamldecode()`",
+			},
+			"document_count": schema.Int64Attribute{
+				Computed:            true,
+				MarkdownDescription: "Number of entries in `documents`",
+			},
+			"documents_by_kind_name": schema.MapAttribute{
+				ElementType: types.StringType,
+				Computed:    true,
+				MarkdownDescription: "`documents` keyed by `<kind>/<metadata.name>`, e.g. `kcl_exec.x.documents_by_kind_name[\"Deployment/web\"]`. " +
+					"Documents lacking either field, or repeating an earlier key, are keyed by their index in `documents`",
+			},
+			"source_hash": schema.StringAttribute{
+				Computed: true,
+				MarkdownDescription: "Hex SHA-256 over the `.k` files and `kcl.mod` below `source_dir`, excluding `.git` and paths matched by " +
+					"`exclude` or a `" + kclIgnoreFileName + "`. " +
+					"It is recomputed on every plan, so editing a KCL file re-runs the resource even when the configuration is unchanged. " +
+					"Null unless `source_dir` is set",
+			},
+			"output_sha256": schema.StringAttribute{
+				Computed: true,
+				MarkdownDescription: "Hex SHA-256 of `stdout` with surrounding whitespace trimmed, also set when the output is sensitive. " +
+					"Key downstream changes on it instead of diffing the output itself",
+			},
+			"git_commit": schema.StringAttribute{
+				Computed: true,
+				MarkdownDescription: "Commit SHA the `git` ref resolved to. It is resolved again on every plan, so a branch that moved " +
+					"re-runs the resource. Null unless `git` is set",
+			},
+			"exclude": schema.ListAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				MarkdownDescription: "Patterns, in `.gitignore` syntax, of paths below `source_dir` left out of `source_hash` and the " +
+					"cache key, e.g. generated files. They apply in addition to any `" + kclIgnoreFileName + "` files in the tree",
+			},
+			"entry_results": schema.MapAttribute{
+				ElementType: types.StringType,
+				Computed:    true,
+				MarkdownDescription: "Result of each entry, keyed by its path in `entry_files`, decoded in `format` (default: `yaml`) and " +
+					"re-encoded as JSON. Null unless `independent_entries` is set, or when the output is sensitive",
+			},
+			"result_compact_json": schema.StringAttribute{
+				Computed: true,
+				MarkdownDescription: "`stdout`, after the output transforms, re-encoded as minified JSON with sorted keys, " +
+					"independent of KCL's formatting. Warnings KCL prints on stderr do not affect it. " +
+					"Null when stdout is not a JSON document",
+			},
+			"command_line": schema.ListAttribute{
+				ElementType: types.StringType,
+				Computed:    true,
+				MarkdownDescription: "The KCL executable followed by the fully assembled argument list that was run, with the values " +
+					"named in `sensitive_arguments` and `sensitive_environment` redacted",
+			},
+			"duration_ms": schema.Int64Attribute{
+				Computed: true,
+				MarkdownDescription: "Wall-clock time spent running KCL in milliseconds, including retries. " +
+					"0 when a cached result was reused",
+			},
+			"dependency_closure_hash": schema.StringAttribute{
+				Computed: true,
+				MarkdownDescription: "Fingerprint of all resolved dependencies in `kcl.mod.lock` after the run, null when there is no lock file. " +
+					"Computed as the hex SHA-256 of one `<name>@<version>:<sum>\\n` line per locked dependency, sorted by name then version",
+			},
+			"lockfile": schema.StringAttribute{
+				Computed: true,
+				MarkdownDescription: "Content of `kcl.mod.lock` in the directory KCL runs in after a successful run, showing the exact " +
+					"resolved dependency versions. Null when there is no lock file",
+			},
+			"diagnostics": schema.ListNestedAttribute{
+				Computed:            true,
+				MarkdownDescription: "Structured errors and warnings reported by KCL when `json_diagnostics` is enabled",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"severity": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "Diagnostic severity, e.g. `error` or `warning`",
+						},
+						"file": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "File the diagnostic refers to",
+						},
+						"line": schema.Int64Attribute{
+							Computed:            true,
+							MarkdownDescription: "Line number within `file`",
+						},
+						"column": schema.Int64Attribute{
+							Computed:            true,
+							MarkdownDescription: "Column number within `line`",
+						},
+						"message": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "Diagnostic message",
+						},
+					},
+				},
+			},
+		},
+
+		Blocks: map[string]schema.Block{
+			"git": schema.SingleNestedBlock{
+				MarkdownDescription: "Git repository to take the KCL sources from instead of `source_dir`. The ref is checked out " +
+					"shallowly into a temporary directory that is removed after execution. HTTP(S) repositories hosted on the provider's " +
+					"`registry` are accessed with the registry credentials",
+				PlanModifiers: []planmodifier.Object{
+					objectplanmodifier.RequiresReplace(),
+				},
+				Attributes: map[string]schema.Attribute{
+					"url": schema.StringAttribute{
+						Optional:            true,
+						MarkdownDescription: "Repository URL",
+					},
+					"ref": schema.StringAttribute{
+						Optional:            true,
+						MarkdownDescription: "Branch, tag or commit SHA to check out (default: the remote `HEAD`)",
+					},
+					"subdirectory": schema.StringAttribute{
+						Optional:            true,
+						MarkdownDescription: "Directory within the repository to run KCL in, relative to its root",
+					},
+				},
+			},
+			"verify": schema.SingleNestedBlock{
+				MarkdownDescription: "Command run after a successful execution with the output on its standard input, " +
+					"e.g. `kubeconform` or `conftest`. A non-zero exit fails the resource with the verifier's output",
+				Attributes: map[string]schema.Attribute{
+					"command": schema.StringAttribute{
+						Optional:            true,
+						MarkdownDescription: "Verifier executable",
+					},
+					"args": schema.ListAttribute{
+						ElementType:         types.StringType,
+						Optional:            true,
+						MarkdownDescription: "Arguments passed to the verifier",
+					},
+					"timeout_seconds": schema.Int64Attribute{
+						Optional:            true,
+						MarkdownDescription: "Verifier timeout in seconds (default: 300)",
+					},
+				},
+			},
 		},
 	}
 }
@@ -115,111 +837,2451 @@ func (r *KclExecResource) Configure(_ context.Context, req resource.ConfigureReq
 	r.provider = provider
 }
 
-func (r *KclExecResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
-	var plan KclExecResourceModel
-	diags := req.Plan.Get(ctx, &plan)
+func (r *KclExecResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config KclExecResourceModel
+	diags := req.Config.Get(ctx, &config)
 	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	// Validate and resolve source directory
-	sourceDir := plan.SourceDir.ValueString()
-	absPath, err := filepath.Abs(sourceDir)
-	if err != nil {
-		resp.Diagnostics.AddError("Path Resolution Error", "Invalid source directory path: "+err.Error())
-		return
+	// Exactly one source must be configured. Unknown values are resolved at
+	// apply time, so the check is skipped until then.
+	sources := []struct {
+		name  string
+		value types.String
+	}{
+		{"source_dir", config.SourceDir},
+		{"code", config.Code},
+		{"http_source", config.HTTPSource},
+		{"oci_ref", config.OCIRef},
+	}
+	if config.Git != nil {
+		sources = append(sources, struct {
+			name  string
+			value types.String
+		}{"git", config.Git.URL})
+	}
+	var setSources []string
+	unknownSource := false
+	for _, src := range sources {
+		switch {
+		case src.value.IsUnknown():
+			unknownSource = true
+		case !src.value.IsNull():
+			setSources = append(setSources, src.name)
+		}
+	}
+	if !unknownSource {
+		switch {
+		case len(setSources) == 0:
+			resp.Diagnostics.AddAttributeError(path.Root("source_dir"), "Missing Source",
+				"Exactly one of source_dir, code, http_source, oci_ref or git must be set.")
+		case len(setSources) > 1:
+			resp.Diagnostics.AddAttributeError(path.Root(setSources[1]), "Conflicting Sources",
+				fmt.Sprintf("Exactly one of source_dir, code, http_source, oci_ref or git must be set, got %s.", strings.Join(setSources, " and ")))
+		}
 	}
 
-	// Check directory existence
-	if _, err := os.Stat(absPath); os.IsNotExist(err) {
-		resp.Diagnostics.AddError("Directory Not Found", "Source directory does not exist: "+absPath)
-		return
+	if !config.OCIRef.IsNull() && !config.OCIRef.IsUnknown() {
+		if _, err := parseOCIReference(config.OCIRef.ValueString()); err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("oci_ref"), "Invalid OCI Reference", err.Error())
+		}
 	}
 
-	// Determine KCL command path
-	kclCommand := "kcl"
-	if r.provider != nil && r.provider.KclPath != "" {
-		kclCommand = r.provider.KclPath
+	if !config.WorkingDir.IsNull() && len(setSources) > 0 && setSources[0] != "source_dir" {
+		resp.Diagnostics.AddAttributeError(path.Root("working_dir"), "Invalid Working Directory",
+			fmt.Sprintf("working_dir can only be combined with source_dir, not %s.", setSources[0]))
 	}
 
-	// Prepare arguments
-	args := []string{}
-	if !plan.Args.IsNull() {
-		diags := plan.Args.ElementsAs(ctx, &args, false)
-		resp.Diagnostics.Append(diags...)
-		if resp.Diagnostics.HasError() {
-			return
+	if !config.Timeout.IsNull() && !config.Timeout.IsUnknown() && config.Timeout.ValueInt64() < 1 {
+		resp.Diagnostics.AddAttributeError(path.Root("timeout"), "Invalid Timeout",
+			fmt.Sprintf("timeout must be at least 1 second, got %d.", config.Timeout.ValueInt64()))
+	}
+
+	if config.IndependentEntries.ValueBool() {
+		switch {
+		case config.EntryFiles.IsNull():
+			resp.Diagnostics.AddAttributeError(path.Root("independent_entries"), "Missing Entry Files",
+				"independent_entries runs each of entry_files on its own, so entry_files must be set.")
+		case !config.EntryFiles.IsUnknown() && len(config.EntryFiles.Elements()) == 0:
+			resp.Diagnostics.AddAttributeError(path.Root("entry_files"), "Missing Entry Files",
+				"independent_entries runs each of entry_files on its own, so entry_files must not be empty.")
+		}
+		if !config.ResultSchema.IsNull() {
+			resp.Diagnostics.AddAttributeError(path.Root("independent_entries"), "Conflicting Attributes",
+				"result_schema checks a single result, so it cannot be combined with independent_entries.")
 		}
 	}
 
-	// Prepare environment variables
-	envVars := os.Environ()
-	if !plan.Environment.IsNull() {
-		envMap := make(map[string]string)
-		diags := plan.Environment.ElementsAs(ctx, &envMap, false)
-		resp.Diagnostics.Append(diags...)
-		if resp.Diagnostics.HasError() {
-			return
+	if !config.FilenameTemplate.IsNull() && !config.FilenameTemplate.IsUnknown() {
+		if _, err := parseFilenameTemplate(config.FilenameTemplate.ValueString()); err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("filename_template"), "Invalid Filename Template", err.Error())
+		}
+		if config.OutputDir.IsNull() {
+			resp.Diagnostics.AddAttributeError(path.Root("filename_template"), "Unused Attribute",
+				"filename_template only applies when output_dir is set.")
 		}
+	}
+
+	if config.Incremental.ValueBool() && !config.IndependentEntries.IsUnknown() && !config.IndependentEntries.ValueBool() {
+		resp.Diagnostics.AddAttributeError(path.Root("incremental"), "Invalid Incremental Evaluation",
+			"incremental reuses the outputs of unchanged entries, so it requires independent_entries.")
+	}
+	if !config.FullOnDependencyChange.IsNull() && !config.Incremental.IsUnknown() && !config.Incremental.ValueBool() {
+		resp.Diagnostics.AddAttributeError(path.Root("full_on_dependency_change"), "Unused Attribute",
+			"full_on_dependency_change only applies when incremental is set.")
+	}
 
-		for k, v := range envMap {
-			envVars = append(envVars, fmt.Sprintf("%s=%s", k, v))
+	if !config.KillTimeout.IsNull() && !config.KillTimeout.IsUnknown() && config.KillTimeout.ValueInt64() < 0 {
+		resp.Diagnostics.AddAttributeError(path.Root("kill_timeout"), "Invalid Kill Timeout",
+			"kill_timeout must not be negative.")
+	}
+
+	hooks := []struct {
+		name  string
+		value types.List
+	}{
+		{"pre_command", config.PreCommand},
+		{"post_command", config.PostCommand},
+	}
+	for _, hook := range hooks {
+		if !hook.value.IsNull() && !hook.value.IsUnknown() && len(hook.value.Elements()) == 0 {
+			resp.Diagnostics.AddAttributeError(path.Root(hook.name), "Invalid Hook Command",
+				hook.name+" must name a command to run.")
 		}
 	}
 
-	// Create execution context with timeout
-	timeout := 300 * time.Second
-	if !plan.Timeout.IsNull() {
-		timeout = time.Duration(plan.Timeout.ValueInt64()) * time.Second
+	if !config.Threads.IsNull() && !config.Threads.IsUnknown() && config.Threads.ValueInt64() < 1 {
+		resp.Diagnostics.AddAttributeError(path.Root("threads"), "Invalid Thread Count",
+			fmt.Sprintf("threads must be at least 1, got %d.", config.Threads.ValueInt64()))
 	}
 
-	ctx, cancel := context.WithTimeout(ctx, timeout)
-	defer cancel()
+	if !config.Retries.IsNull() && !config.Retries.IsUnknown() && config.Retries.ValueInt64() < 0 {
+		resp.Diagnostics.AddAttributeError(path.Root("retries"), "Invalid Retry Count",
+			"retries must not be negative.")
+	}
 
-	// Execute command
-	cmd := exec.CommandContext(ctx, kclCommand, args...)
-	cmd.Dir = absPath
-	cmd.Env = envVars
+	if !config.RetryInterval.IsNull() && !config.RetryInterval.IsUnknown() && config.RetryInterval.ValueInt64() < 0 {
+		resp.Diagnostics.AddAttributeError(path.Root("retry_interval"), "Invalid Retry Interval",
+			"retry_interval must not be negative.")
+	}
 
-	tflog.Info(ctx, "Executing KCL command", map[string]interface{}{
-		"command":   kclCommand,
-		"arguments": args,
-		"directory": absPath,
-		"timeout":   timeout,
-	})
+	if !config.MaxStateOutputBytes.IsNull() && !config.MaxStateOutputBytes.IsUnknown() && config.MaxStateOutputBytes.ValueInt64() < 0 {
+		resp.Diagnostics.AddAttributeError(path.Root("max_state_output_bytes"), "Invalid Output Budget",
+			"max_state_output_bytes must not be negative.")
+	}
 
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"KCL Execution Failed",
-			fmt.Sprintf("Command: %s %s\nError: %v\nOutput: %s",
-				kclCommand, strings.Join(args, " "), err, string(output)),
-		)
-		return
+	if config.Git != nil && config.Git.URL.IsNull() {
+		resp.Diagnostics.AddAttributeError(path.Root("git").AtName("url"), "Missing Git URL",
+			"The git block requires a url.")
 	}
 
-	// Generate unique ID based on inputs
-	idInput := fmt.Sprintf("%s|%s|%v|%v", absPath, kclCommand, args, envVars)
-	hash := sha256.Sum256([]byte(idInput))
-	plan.ID = types.StringValue(hex.EncodeToString(hash[:16]))
-	plan.Output = types.StringValue(strings.TrimSpace(string(output)))
+	if config.Verify != nil && config.Verify.Command.IsNull() {
+		resp.Diagnostics.AddAttributeError(path.Root("verify").AtName("command"), "Missing Verify Command",
+			"The verify block requires a command.")
+	}
 
-	diags = resp.State.Set(ctx, plan)
-	resp.Diagnostics.Append(diags...)
-}
+	if !config.OutputFilePermission.IsNull() && !config.OutputFilePermission.IsUnknown() {
+		if _, err := parseFilePermission(config.OutputFilePermission.ValueString()); err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("output_file_permission"), "Invalid File Permission", err.Error())
+		}
+	}
 
-func (r *KclExecResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
-	// Output is ephemeral - nothing to read after creation
-}
+	if !config.Overrides.IsNull() && !config.Overrides.IsUnknown() {
+		var overrides []types.String
+		resp.Diagnostics.Append(config.Overrides.ElementsAs(ctx, &overrides, false)...)
+		for i, o := range overrides {
+			if o.IsNull() || o.IsUnknown() {
+				continue
+			}
+			if err := validateOverride(o.ValueString()); err != nil {
+				resp.Diagnostics.AddAttributeError(path.Root("overrides").AtListIndex(i), "Invalid Override", err.Error())
+			}
+		}
+	}
 
-func (r *KclExecResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
-	resp.Diagnostics.AddError(
-		"Update Not Supported",
-		"KCL execution resource does not support updates - changes require replacement",
-	)
-}
+	if !config.Arguments.IsNull() && !config.Arguments.IsUnknown() {
+		arguments := make(map[string]types.String)
+		resp.Diagnostics.Append(config.Arguments.ElementsAs(ctx, &arguments, false)...)
+		keys := make([]string, 0, len(arguments))
+		for key := range arguments {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			value := arguments[key]
+			if value.IsUnknown() {
+				continue
+			}
+			if err := validateArgument(key, value.ValueString()); err != nil {
+				resp.Diagnostics.AddAttributeError(path.Root("arguments").AtMapKey(key), "Invalid Argument", err.Error())
+			}
+		}
+	}
 
-func (r *KclExecResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
-	// No persistent state to clean up
+	if !config.Subcommand.IsNull() && !config.Subcommand.IsUnknown() {
+		if err := validateSubcommand(config.Subcommand.ValueString()); err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("subcommand"), "Invalid Subcommand", err.Error())
+		}
+	}
+
+	if !config.Format.IsNull() && !config.Format.IsUnknown() {
+		if err := validateResultFormat(config.Format.ValueString()); err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("format"), "Invalid Format", err.Error())
+		}
+
+		// args win over format, so a different --format there would
+		// leave result parsed with the wrong decoder
+		var args []types.String
+		if !config.Args.IsUnknown() {
+			resp.Diagnostics.Append(config.Args.ElementsAs(ctx, &args, false)...)
+		}
+		known := make([]string, 0, len(args))
+		for _, arg := range args {
+			if arg.IsUnknown() {
+				known = nil
+				break
+			}
+			known = append(known, arg.ValueString())
+		}
+		if value, ok := flagValue(known, "--format"); ok && value != config.Format.ValueString() {
+			resp.Diagnostics.AddAttributeError(path.Root("args"), "Conflicting Format",
+				fmt.Sprintf("args pass --format %s but format is %q; drop --format from args or make them agree.",
+					value, config.Format.ValueString()))
+		}
+	}
+
+	// Flags in args that an attribute controls are merged with, or fight,
+	// that attribute, so point at the attribute instead. A --format that
+	// disagrees with format is already an error above.
+	if !config.Args.IsUnknown() {
+		var args []types.String
+		resp.Diagnostics.Append(config.Args.ElementsAs(ctx, &args, false)...)
+		for i, arg := range args {
+			if arg.IsUnknown() {
+				continue
+			}
+			attribute, ok := attributeForFlag(arg.ValueString())
+			if !ok || (attribute == "format" && !config.Format.IsNull()) {
+				continue
+			}
+			resp.Diagnostics.AddAttributeWarning(path.Root("args").AtListIndex(i), "Flag Controlled By Attribute",
+				fmt.Sprintf("%s is controlled by the %s attribute. Set %s instead of passing the flag in args, "+
+					"so the provider knows about it and it is not repeated or contradicted.",
+					arg.ValueString(), attribute, attribute))
+		}
+	}
+
+	if !config.ResultSchema.IsNull() && !config.Format.IsUnknown() {
+		switch config.Format.ValueString() {
+		case resultFormatJSON, resultFormatYAML:
+		default:
+			resp.Diagnostics.AddAttributeError(path.Root("result_schema"), "Invalid Result Schema Format",
+				"result_schema requires format json or yaml, the formats kcl vet reads.")
+		}
+	}
+	if !config.ResultSchemaFile.IsNull() && config.ResultSchema.IsNull() {
+		resp.Diagnostics.AddAttributeError(path.Root("result_schema_file"), "Unused Attribute",
+			"result_schema_file only applies when result_schema is set.")
+	}
+
+	if !config.HTTPSHA256.IsNull() && config.HTTPSource.IsNull() {
+		resp.Diagnostics.AddAttributeError(path.Root("http_sha256"), "Unused Attribute",
+			"http_sha256 only applies when http_source is set.")
+	}
+}
+
+func (r *KclExecResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan KclExecResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var entries entryState
+	r.execute(ctx, &plan, &entries, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(storeEntryState(ctx, resp.Private, entries)...)
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *KclExecResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	// Output is ephemeral - only the sources are checked for changes. The
+	// recorded hash is kept so ModifyPlan can plan a re-run.
+	var state KclExecResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if state.SourceDir.IsNull() || state.SourceHash.IsNull() {
+		return
+	}
+
+	var exclude []string
+	resp.Diagnostics.Append(state.Exclude.ElementsAs(ctx, &exclude, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	current, err := sourceDirHash(state.SourceDir.ValueString(), exclude)
+	if err != nil {
+		tflog.Warn(ctx, "Unable to hash KCL sources", map[string]interface{}{
+			"source_dir": state.SourceDir.ValueString(),
+			"error":      err.Error(),
+		})
+		return
+	}
+	if current != state.SourceHash.ValueString() {
+		tflog.Info(ctx, "KCL sources changed since the last execution", map[string]interface{}{
+			"source_dir": state.SourceDir.ValueString(),
+		})
+	}
+}
+
+// ImportState adopts a source directory by running KCL on it with default
+// settings. The ID is "<source_dir>" or "<source_dir>@<source_hash>"; the
+// hash, when given, must match the current sources. Any other configured
+// attribute changes the ID and re-runs on the next apply.
+func (r *KclExecResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	sourceDir, wantHash := req.ID, ""
+	if i := strings.LastIndex(req.ID, "@"); i >= 0 && sourceHashPattern.MatchString(req.ID[i+1:]) {
+		sourceDir, wantHash = req.ID[:i], req.ID[i+1:]
+	}
+	if sourceDir == "" {
+		resp.Diagnostics.AddError("Invalid Import ID",
+			fmt.Sprintf("Expected \"<source_dir>\" or \"<source_dir>@<source_hash>\", got %q", req.ID))
+		return
+	}
+
+	if wantHash != "" {
+		current, err := sourceDirHash(sourceDir, nil)
+		if err != nil {
+			resp.Diagnostics.AddError("Source Hash Error", "Unable to hash "+sourceDir+": "+err.Error())
+			return
+		}
+		if current != wantHash {
+			resp.Diagnostics.AddError("Source Hash Mismatch",
+				fmt.Sprintf("The sources in %s hash to %s, not %s as given in the import ID", sourceDir, current, wantHash))
+			return
+		}
+	}
+
+	// Start from the all-null state the framework prepared, so every
+	// attribute has its schema type
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("source_dir"), sourceDir)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	var state KclExecResourceModel
+	resp.Diagnostics.Append(resp.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r.execute(ctx, &state, &entryState{}, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
+}
+
+func (r *KclExecResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to do on destroy
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	// A change to metadata alone only relabels the resource, so plan the
+	// prior outputs instead of the unknown ones the framework planned
+	if !req.State.Raw.IsNull() {
+		outputs := r.runOutputs(ctx)
+		metadataOnly, err := r.metadataOnlyChange(req.Plan.Raw, req.State.Raw, outputs)
+		if err != nil {
+			resp.Diagnostics.AddError("Plan Error", err.Error())
+			return
+		}
+		if metadataOnly {
+			for name := range outputs {
+				var value attr.Value
+				resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root(name), &value)...)
+				resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root(name), value)...)
+			}
+			if resp.Diagnostics.HasError() {
+				return
+			}
+		}
+	}
+
+	var git *KclGitModel
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("git"), &git)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if git != nil && !git.URL.IsNull() && !git.URL.IsUnknown() && !git.Ref.IsUnknown() {
+		// Plan the commit the ref points to now, so a moved branch shows up
+		// as an update. An unreachable remote is reported at apply time.
+		commit, err := resolveGitCommit(ctx, r.provider, git.URL.ValueString(), git.gitRef())
+		if err != nil {
+			tflog.Warn(ctx, "Unable to resolve git ref", map[string]interface{}{
+				"url":   git.URL.ValueString(),
+				"ref":   git.gitRef(),
+				"error": err.Error(),
+			})
+			return
+		}
+		resp.Diagnostics.Append(r.planSource(ctx, req, resp, "git_commit", commit)...)
+		return
+	}
+
+	var ociRef types.String
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("oci_ref"), &ociRef)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if !ociRef.IsNull() && !ociRef.IsUnknown() {
+		// Plan the digest the tag points to now, so a moved tag shows up as
+		// an update. An unreachable registry is reported at apply time.
+		ref, err := parseOCIReference(ociRef.ValueString())
+		if err != nil {
+			return
+		}
+		digest, err := resolveOCIDigest(ctx, r.provider, ref)
+		if err != nil {
+			tflog.Warn(ctx, "Unable to resolve OCI tag", map[string]interface{}{
+				"oci_ref": ociRef.ValueString(),
+				"error":   err.Error(),
+			})
+			return
+		}
+		resp.Diagnostics.Append(r.planSource(ctx, req, resp, "oci_digest", digest)...)
+		return
+	}
+
+	var sourceDir types.String
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("source_dir"), &sourceDir)...)
+	if resp.Diagnostics.HasError() || sourceDir.IsNull() || sourceDir.IsUnknown() {
+		return
+	}
+
+	var excludeList types.List
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("exclude"), &excludeList)...)
+	if resp.Diagnostics.HasError() || excludeList.IsUnknown() {
+		return
+	}
+	var exclude []string
+	resp.Diagnostics.Append(excludeList.ElementsAs(ctx, &exclude, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Plan the current hash so edited sources show up as an update. A
+	// missing directory is reported at apply time.
+	current, err := sourceDirHash(sourceDir.ValueString(), exclude)
+	if err != nil {
+		return
+	}
+	// The hash does not go into the ID, which stays as it is
+	resp.Diagnostics.Append(r.planSource(ctx, req, resp, "source_hash", current, "id")...)
+}
+
+// runOutputs returns the attributes set by a run, i.e. the computed
+// attributes that cannot be configured, with their types.
+func (r *KclExecResource) runOutputs(ctx context.Context) map[string]attr.Type {
+	var schemaResp resource.SchemaResponse
+	r.Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+
+	outputs := make(map[string]attr.Type)
+	for name, attribute := range schemaResp.Schema.Attributes {
+		if attribute.IsComputed() && !attribute.IsOptional() {
+			outputs[name] = attribute.GetType()
+		}
+	}
+	return outputs
+}
+
+// metadataOnlyChange reports whether plan differs from state in metadata
+// alone, ignoring the attributes in skip, while KCL does not see metadata.
+func (r *KclExecResource) metadataOnlyChange(plan, state tftypes.Value, skip map[string]attr.Type) (bool, error) {
+	var planned, prior map[string]tftypes.Value
+	if err := plan.As(&planned); err != nil {
+		return false, err
+	}
+	if err := state.As(&prior); err != nil {
+		return false, err
+	}
+
+	// Metadata KCL sees is an input like any other
+	inject := planned["inject_tf_metadata"]
+	if !inject.IsKnown() || planned["metadata"].Equal(prior["metadata"]) {
+		return false, nil
+	}
+	if !inject.IsNull() {
+		var injected bool
+		if err := inject.As(&injected); err != nil || injected {
+			return false, err
+		}
+	}
+	for name, value := range planned {
+		if _, skipped := skip[name]; !skipped && name != "metadata" && !value.Equal(prior[name]) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// planSource plans value for name, a computed attribute identifying the
+// sources. When it differs from state the sources changed without any
+// configuration change, so the framework planned the prior outputs; they
+// are marked unknown, except keep, as the run replaces them and Terraform
+// rejects an apply that differs from its plan.
+func (r *KclExecResource) planSource(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse,
+	name, value string, keep ...string) diag.Diagnostics {
+	diags := resp.Plan.SetAttribute(ctx, path.Root(name), value)
+	if diags.HasError() || req.State.Raw.IsNull() {
+		return diags
+	}
+
+	var prior types.String
+	diags.Append(req.State.GetAttribute(ctx, path.Root(name), &prior)...)
+	if diags.HasError() || prior.Equal(types.StringValue(value)) {
+		return diags
+	}
+
+	keep = append(keep, "source_hash", "git_commit", "oci_digest")
+	for attrName, attrType := range r.runOutputs(ctx) {
+		if containsString(keep, attrName) {
+			continue
+		}
+		unknown, err := attrType.ValueFromTerraform(ctx, tftypes.NewValue(attrType.TerraformType(ctx), tftypes.UnknownValue))
+		if err != nil {
+			diags.AddError("Plan Error", "Unable to plan "+attrName+": "+err.Error())
+			continue
+		}
+		diags.Append(resp.Plan.SetAttribute(ctx, path.Root(attrName), unknown)...)
+	}
+	return diags
+}
+
+func (r *KclExecResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan KclExecResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// ModifyPlan kept the prior outputs when only the labels changed
+	if metadataOnly, err := r.metadataOnlyChange(req.Plan.Raw, req.State.Raw, nil); err != nil {
+		resp.Diagnostics.AddError("Plan Error", err.Error())
+		return
+	} else if metadataOnly {
+		resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+		return
+	}
+
+	// Any change other than the source is applied by running KCL again
+	entries, diags := loadEntryState(ctx, req.Private)
+	resp.Diagnostics.Append(diags...)
+	r.execute(ctx, &plan, &entries, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(storeEntryState(ctx, resp.Private, entries)...)
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+// execRun carries one run of kcl_exec through the steps of execute. Each
+// step fills in the fields the later ones read.
+type execRun struct {
+	plan        *KclExecResourceModel
+	diagnostics *diag.Diagnostics
+	// prior is what the previous incremental run recorded
+	prior    entryState
+	metadata map[string]string
+	// tempPaths are removed once the run is over
+	tempPaths []string
+
+	// Set by resolveSource. exclude is only known for source_dir.
+	absPath, workDir, sourceHash string
+	exclude                      []string
+
+	// Set by buildRunArgs. idArgs leave out the input file, whose path
+	// changes on every run.
+	kclCommand, kclBinary string
+	argSpec               kclArgs
+	args, idArgs          []string
+	convertToTOML         bool
+	transforms            []string
+	outputEncoding        string
+	jsonDiagnostics       bool
+	inputHash, stdinHash  string
+	allowedExitCodes      []int64
+
+	// Set by buildRunEnvironment. Only userEnv and a digest of fileVars
+	// identify the run.
+	userEnv, envVars, fileVars, metadataEnv []string
+	fileEnvHash                             string
+	secrets                                 []string
+	shownArgs                               string
+	sensitive                               bool
+	timeout, killTimeout                    time.Duration
+
+	// Set by identifyRun
+	logCommand              logFunc
+	preCommand, postCommand []string
+	independentEntries      bool
+	incremental             bool
+	entryHashes             map[string]string
+	dependencyHash          string
+
+	// Set by lookupCachedResult and runWithRetries. With
+	// independent_entries, entryStdout holds what each entry printed and
+	// entryFailures the entries that failed.
+	cacheDir, cacheKey string
+	cached             bool
+	result             commandOutput
+	err                error
+	duration           time.Duration
+	entryStdout        map[string][]byte
+	entryFailures      []entryFailure
+
+	// Set by checkRunResult
+	decoded, stdout, stderr string
+	invalidUTF8             bool
+	kclDiags                []kclDiagnostic
+	exitCode                int64
+}
+
+// execute runs KCL for plan and fills in its computed attributes. entries
+// holds what the previous incremental run recorded and is replaced with the
+// record of this one. Problems are added to diagnostics; the plan must not
+// be saved when it has errors.
+func (r *KclExecResource) execute(ctx context.Context, plan *KclExecResourceModel, entries *entryState, diagnostics *diag.Diagnostics) {
+	run := &execRun{plan: plan, diagnostics: diagnostics, prior: *entries, metadata: make(map[string]string)}
+	*entries = entryState{}
+
+	// Attach resource metadata to every subsequent log entry
+	if !plan.Metadata.IsNull() {
+		diags := plan.Metadata.ElementsAs(ctx, &run.metadata, false)
+		diagnostics.Append(diags...)
+		if diagnostics.HasError() {
+			return
+		}
+
+		for k, v := range run.metadata {
+			ctx = tflog.SetField(ctx, "metadata."+k, v)
+		}
+	}
+
+	// Temporary files are removed once the run is over, unless it failed
+	// and keep_temp_on_failure asks to keep them for inspection
+	defer func() {
+		if len(run.tempPaths) > 0 && diagnostics.HasError() && plan.KeepTempOnFailure.ValueBool() {
+			diagnostics.AddWarning("Temporary Files Kept",
+				"keep_temp_on_failure is set, so the temporary files of the failed run were kept for inspection. "+
+					"Remove them when done:\n"+strings.Join(run.tempPaths, "\n"))
+			return
+		}
+		for _, tempPath := range run.tempPaths {
+			os.RemoveAll(tempPath)
+		}
+	}()
+
+	if !r.resolveSource(ctx, run) || !r.buildRunArgs(ctx, run) || !r.buildRunEnvironment(ctx, run) {
+		return
+	}
+
+	// Mask secret environment and argument values in every subsequent log
+	// entry, and secret output in the streamed output lines
+	for _, secret := range run.secrets {
+		ctx = tflog.MaskLogStrings(ctx, secret)
+	}
+	if run.sensitive {
+		ctx = tflog.MaskFieldValuesWithFieldKeys(ctx, "line")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, run.timeout)
+	defer cancel()
+
+	if !r.identifyRun(ctx, run) {
+		return
+	}
+
+	// A dry run stops here, reporting what would run instead of running it
+	if plan.DryRun.ValueBool() {
+		r.reportDryRun(ctx, plan, run.kclBinary, run.shownArgs, run.workDir, redactArgs(run.userEnv, run.secrets), run.fileVars, diagnostics)
+		return
+	}
+
+	if len(run.preCommand) > 0 && !r.runHook(ctx, run, "pre_command", "Pre-Command Hook", run.preCommand) {
+		return
+	}
+
+	if !r.lookupCachedResult(ctx, run) {
+		return
+	}
+	if !run.cached {
+		r.runWithRetries(ctx, run)
+	}
+
+	if !r.checkRunResult(ctx, run) || !r.recordOutputs(ctx, run) {
+		return
+	}
+
+	// Record the entries for the next incremental run
+	if run.incremental && !diagnostics.HasError() {
+		entries.DependencyHash = run.dependencyHash
+		entries.Entries = make(map[string]entryRecord, len(run.entryStdout))
+		for entry, stdout := range run.entryStdout {
+			entries.Entries[entry] = entryRecord{Hash: run.entryHashes[entry], Stdout: stdout}
+		}
+	}
+
+	// Only a run that passed every check, from success_marker to the result
+	// schema, is worth reusing
+	if run.cacheKey != "" && !run.cached && run.exitCode == 0 && !diagnostics.HasError() {
+		if err := storeCachedResult(run.cacheDir, run.cacheKey, run.result); err != nil {
+			tflog.Warn(ctx, "Unable to cache KCL result", map[string]interface{}{
+				"cache_key": run.cacheKey,
+				"error":     err.Error(),
+			})
+		}
+	}
+}
+
+// resolveSource checks out, downloads or locates the program of run and
+// the directory KCL runs in.
+func (r *KclExecResource) resolveSource(ctx context.Context, run *execRun) bool {
+	plan, diagnostics, argSpec := run.plan, run.diagnostics, &run.argSpec
+
+	plannedCommit, plannedDigest := plan.GitCommit, plan.OCIDigest
+	plan.GitCommit, plan.OCIDigest = types.StringNull(), types.StringNull()
+	if plan.Git != nil {
+		repoURL, ref := plan.Git.URL.ValueString(), plan.Git.gitRef()
+		dir, workDir, commit, err := fetchGitSource(ctx, r.provider, repoURL, ref, plan.Git.Subdirectory.ValueString())
+		if err != nil {
+			diagnostics.AddAttributeError(path.Root("git"), "Git Source Error",
+				"Unable to check out "+ref+" of "+repoURL+": "+err.Error())
+			return false
+		}
+		run.tempPaths = append(run.tempPaths, dir)
+
+		// The ref may have moved since the plan resolved it
+		if !plannedCommit.IsUnknown() && !plannedCommit.IsNull() && plannedCommit.ValueString() != commit {
+			diagnostics.AddAttributeError(path.Root("git"), "Git Ref Moved",
+				fmt.Sprintf("%s of %s resolved to %s during plan but to %s now. Plan again to apply the new commit.",
+					ref, repoURL, plannedCommit.ValueString(), commit))
+			return false
+		}
+
+		run.absPath, run.sourceHash = workDir, commit
+		plan.GitCommit = types.StringValue(commit)
+	} else if !plan.HTTPSource.IsNull() {
+		headers := make(map[string]string)
+		if !plan.HTTPHeaders.IsNull() {
+			diags := plan.HTTPHeaders.ElementsAs(ctx, &headers, false)
+			diagnostics.Append(diags...)
+			if diagnostics.HasError() {
+				return false
+			}
+		}
+
+		dir, contentHash, err := fetchHTTPSource(ctx, plan.HTTPSource.ValueString(), headers, plan.HTTPSHA256.ValueString())
+		if err != nil {
+			diagnostics.AddAttributeError(path.Root("http_source"), "HTTP Source Error",
+				"Unable to fetch "+plan.HTTPSource.ValueString()+": "+err.Error())
+			return false
+		}
+		run.tempPaths = append(run.tempPaths, dir)
+
+		run.absPath, run.sourceHash = dir, contentHash
+	} else if !plan.OCIRef.IsNull() {
+		ref, err := parseOCIReference(plan.OCIRef.ValueString())
+		if err != nil {
+			diagnostics.AddAttributeError(path.Root("oci_ref"), "Invalid OCI Reference", err.Error())
+			return false
+		}
+		digest, err := resolveOCIDigest(ctx, r.provider, ref)
+		if err != nil {
+			diagnostics.AddAttributeError(path.Root("oci_ref"), "OCI Source Error",
+				"Unable to resolve "+plan.OCIRef.ValueString()+": "+err.Error())
+			return false
+		}
+
+		// The tag may have moved since the plan resolved it
+		if !plannedDigest.IsUnknown() && !plannedDigest.IsNull() && plannedDigest.ValueString() != digest {
+			diagnostics.AddAttributeError(path.Root("oci_ref"), "OCI Tag Moved",
+				fmt.Sprintf("%s resolved to %s during plan but to %s now. Plan again to apply the new digest.",
+					plan.OCIRef.ValueString(), plannedDigest.ValueString(), digest))
+			return false
+		}
+
+		// KCL fetches the package itself, so it only needs a scratch
+		// directory to run in
+		dir, err := os.MkdirTemp("", "kcl-oci-")
+		if err != nil {
+			diagnostics.AddError("OCI Source Error", "Unable to create a temporary directory: "+err.Error())
+			return false
+		}
+		run.tempPaths = append(run.tempPaths, dir)
+
+		run.absPath, run.sourceHash = dir, digest
+		argSpec.CodeFile, argSpec.Tag = ref.URL(), ref.Tag
+		plan.OCIDigest = types.StringValue(digest)
+	} else if !plan.Code.IsNull() {
+		code := plan.Code.ValueString()
+		dir, err := writeInlineCode(code)
+		if err != nil {
+			diagnostics.AddError("Inline Code Error", "Unable to write code to a temporary directory: "+err.Error())
+			return false
+		}
+		run.tempPaths = append(run.tempPaths, dir)
+
+		sum := sha256.Sum256([]byte(code))
+		run.absPath, run.sourceHash = dir, hex.EncodeToString(sum[:])
+		argSpec.CodeFile = inlineCodeFileName
+	} else {
+		absPath, err := filepath.Abs(plan.SourceDir.ValueString())
+		if err != nil {
+			diagnostics.AddError("Path Resolution Error", "Invalid source directory path: "+err.Error())
+			return false
+		}
+
+		// Check directory existence
+		info, err := os.Stat(absPath)
+		if os.IsNotExist(err) {
+			diagnostics.AddError("Directory Not Found", "Source directory does not exist: "+absPath)
+			return false
+		}
+
+		// A single file runs from its directory, named as the entry point
+		if err == nil && !info.IsDir() {
+			if !info.Mode().IsRegular() || filepath.Ext(absPath) != kclSourceExt {
+				diagnostics.AddAttributeError(path.Root("source_dir"), "Invalid Source",
+					"source_dir must be a directory or a "+kclSourceExt+" file, got: "+absPath)
+				return false
+			}
+			argSpec.CodeFile = filepath.Base(absPath)
+			absPath = filepath.Dir(absPath)
+		}
+		run.absPath = absPath
+
+		// Hash before running, matching what ModifyPlan saw
+		diags := plan.Exclude.ElementsAs(ctx, &run.exclude, false)
+		diagnostics.Append(diags...)
+		if diagnostics.HasError() {
+			return false
+		}
+		run.sourceHash, err = hashSourceDir(absPath, run.exclude)
+		if err != nil {
+			diagnostics.AddError("Source Hash Error", "Unable to hash "+absPath+": "+err.Error())
+			return false
+		}
+	}
+
+	// KCL runs in working_dir when set, so name the sources relative to it
+	run.workDir = run.absPath
+	if !plan.WorkingDir.IsNull() {
+		workDir, err := filepath.Abs(plan.WorkingDir.ValueString())
+		if err != nil {
+			diagnostics.AddAttributeError(path.Root("working_dir"), "Path Resolution Error", "Invalid working directory path: "+err.Error())
+			return false
+		}
+		if info, err := os.Stat(workDir); err != nil || !info.IsDir() {
+			diagnostics.AddAttributeError(path.Root("working_dir"), "Directory Not Found", "Working directory does not exist: "+workDir)
+			return false
+		}
+		run.workDir = workDir
+
+		source := filepath.Join(run.absPath, argSpec.CodeFile)
+		if argSpec.CodeFile != "" || plan.EntryFiles.IsNull() {
+			rel, err := filepath.Rel(workDir, source)
+			if err != nil {
+				rel = source
+			}
+			argSpec.CodeFile = ""
+			if rel != "." {
+				argSpec.CodeFile = rel
+			}
+		}
+	}
+	return true
+}
+
+// buildRunArgs resolves the KCL executable of run and assembles its
+// command line from the resource's attributes and the provider's defaults.
+func (r *KclExecResource) buildRunArgs(ctx context.Context, run *execRun) bool {
+	plan, diagnostics, argSpec, workDir := run.plan, run.diagnostics, &run.argSpec, run.workDir
+
+	// Determine KCL command path. The configured command identifies the run,
+	// the resolved path is what gets executed and logged.
+	run.kclCommand = r.provider.kclCommand()
+	var err error
+	if !plan.KclPath.IsNull() {
+		run.kclCommand = plan.KclPath.ValueString()
+		run.kclBinary, err = lookupKclExecutable(run.kclCommand)
+	} else {
+		run.kclBinary, err = r.provider.resolveKclCommand()
+	}
+	if err != nil && r.provider.nativeBackend() {
+		// Only the features running other subcommands need the executable
+		run.kclBinary, err = nativeKclCommand, nil
+	}
+	if err != nil {
+		diagnostics.AddError("KCL Executable Not Found", err.Error())
+		return false
+	}
+
+	// Prepare arguments, provider defaults first
+	if r.provider != nil {
+		argSpec.DefaultArgs = r.provider.DefaultArgs
+	}
+	if !plan.Args.IsNull() {
+		diags := plan.Args.ElementsAs(ctx, &argSpec.Args, false)
+		diagnostics.Append(diags...)
+		if diagnostics.HasError() {
+			return false
+		}
+	}
+
+	if !plan.EntryFiles.IsNull() {
+		var entryFiles []string
+		diags := plan.EntryFiles.ElementsAs(ctx, &entryFiles, false)
+		diagnostics.Append(diags...)
+		if diagnostics.HasError() {
+			return false
+		}
+
+		for i, f := range entryFiles {
+			if err := checkEntryFile(f, workDir); err != nil {
+				diagnostics.AddAttributeError(path.Root("entry_files").AtListIndex(i), "Entry File Error", err.Error())
+			}
+		}
+		if diagnostics.HasError() {
+			return false
+		}
+		argSpec.EntryFiles = entryFiles
+	}
+
+	argSpec.Subcommand = effectiveSubcommand(plan.Subcommand.ValueString(),
+		append(append([]string{}, argSpec.DefaultArgs...), argSpec.Args...))
+
+	if !plan.Arguments.IsNull() {
+		diags := plan.Arguments.ElementsAs(ctx, &argSpec.Arguments, false)
+		diagnostics.Append(diags...)
+		if diagnostics.HasError() {
+			return false
+		}
+	}
+
+	if !plan.SettingsFiles.IsNull() {
+		var settingsFiles []string
+		diags := plan.SettingsFiles.ElementsAs(ctx, &settingsFiles, false)
+		diagnostics.Append(diags...)
+		if diagnostics.HasError() {
+			return false
+		}
+
+		sourceDir := ""
+		if !plan.SourceDir.IsNull() {
+			sourceDir = workDir
+		}
+		for i, f := range settingsFiles {
+			resolved, err := resolveSettingsFile(f, sourceDir)
+			if err != nil {
+				diagnostics.AddAttributeError(path.Root("settings_files").AtListIndex(i), "Settings File Error", err.Error())
+				continue
+			}
+			argSpec.SettingsFiles = append(argSpec.SettingsFiles, resolved)
+		}
+		if diagnostics.HasError() {
+			return false
+		}
+	}
+
+	if !plan.ExternalPackages.IsNull() {
+		packages := make(map[string]string)
+		diags := plan.ExternalPackages.ElementsAs(ctx, &packages, false)
+		diagnostics.Append(diags...)
+		if diagnostics.HasError() {
+			return false
+		}
+
+		base := ""
+		if !plan.SourceDir.IsNull() {
+			base = workDir
+		}
+		argSpec.ExternalPackages = make(map[string]string, len(packages))
+		for _, name := range sortedKeys(packages) {
+			dir, err := resolveExternalPackage(packages[name], base)
+			if err != nil {
+				diagnostics.AddAttributeError(path.Root("external_packages").AtMapKey(name), "External Package Error", err.Error())
+				continue
+			}
+			argSpec.ExternalPackages[name] = dir
+		}
+		if diagnostics.HasError() {
+			return false
+		}
+	}
+
+	if !plan.PathSelectors.IsNull() {
+		diags := plan.PathSelectors.ElementsAs(ctx, &argSpec.PathSelectors, false)
+		diagnostics.Append(diags...)
+		if diagnostics.HasError() {
+			return false
+		}
+	}
+
+	if !plan.Overrides.IsNull() {
+		diags := plan.Overrides.ElementsAs(ctx, &argSpec.Overrides, false)
+		diagnostics.Append(diags...)
+		if diagnostics.HasError() {
+			return false
+		}
+	}
+
+	toggles := []struct {
+		value types.Bool
+		flag  string
+	}{
+		{plan.SortKeys, "--sort_keys"},
+		{plan.DisableNone, "--disable_none"},
+		{plan.StrictRangeCheck, "--strict_range_check"},
+		{plan.Vendor, "--vendor"},
+		{plan.Debug, "--debug"},
+		{plan.Quiet, "-q"},
+	}
+	for _, t := range toggles {
+		if t.value.ValueBool() {
+			argSpec.Flags = append(argSpec.Flags, t.flag)
+		}
+	}
+	argSpec.Format = plan.Format.ValueString()
+
+	// Convert JSON to TOML for a KCL that cannot print it
+	if argSpec.Format == resultFormatTOML {
+		kclVersion, err := r.kclVersionOf(ctx, plan, run.kclBinary)
+		if err != nil {
+			diagnostics.AddError("KCL Version Detection Failed", err.Error())
+			return false
+		}
+		if detected, err := version.NewVersion(kclVersion); err == nil && detected.LessThan(version.Must(version.NewVersion(minKclTOMLVersion))) {
+			tflog.Debug(ctx, "KCL cannot print TOML, converting its JSON output", map[string]interface{}{
+				"kcl_version": kclVersion,
+			})
+			argSpec.Format, run.convertToTOML = resultFormatJSON, true
+		}
+	}
+
+	// Resolve output transforms, resource-level settings replacing provider defaults
+	if r.provider != nil {
+		run.transforms = r.provider.DefaultOutputTransforms
+	}
+	if !plan.OutputTransforms.IsNull() {
+		run.transforms = []string{}
+		diags := plan.OutputTransforms.ElementsAs(ctx, &run.transforms, false)
+		diagnostics.Append(diags...)
+		if diagnostics.HasError() {
+			return false
+		}
+	}
+	if err := validateOutputTransforms(run.transforms); err != nil {
+		diagnostics.AddAttributeError(path.Root("output_transforms"), "Invalid Output Transform", err.Error())
+		return false
+	}
+
+	run.outputEncoding = plan.OutputEncoding.ValueString()
+	if err := validateOutputEncoding(run.outputEncoding); err != nil {
+		diagnostics.AddAttributeError(path.Root("output_encoding"), "Invalid Output Encoding", err.Error())
+		return false
+	}
+
+	run.jsonDiagnostics = plan.JSONDiagnostics.ValueBool()
+
+	// Hand the chained input to the program through a temporary file. Its
+	// path changes on every run, so only the content hash goes into the ID.
+	run.idArgs = buildArgs(*argSpec)
+	if !plan.InputFrom.IsNull() {
+		input := plan.InputFrom.ValueString()
+		inputFile, err := writeInputFile(input)
+		if err != nil {
+			diagnostics.AddError("Input File Error", "Unable to write input_from to a temporary file: "+err.Error())
+			return false
+		}
+		run.tempPaths = append(run.tempPaths, inputFile)
+
+		argSpec.InputFile = inputFile
+		sum := sha256.Sum256([]byte(input))
+		run.inputHash = hex.EncodeToString(sum[:])
+	}
+
+	run.args = buildArgs(*argSpec)
+
+	if !plan.Stdin.IsNull() {
+		sum := sha256.Sum256([]byte(plan.Stdin.ValueString()))
+		run.stdinHash = hex.EncodeToString(sum[:])
+	}
+
+	run.allowedExitCodes = []int64{}
+	if !plan.AllowedExitCodes.IsNull() {
+		diags := plan.AllowedExitCodes.ElementsAs(ctx, &run.allowedExitCodes, false)
+		diagnostics.Append(diags...)
+		if diagnostics.HasError() {
+			return false
+		}
+	}
+	return true
+}
+
+// buildRunEnvironment assembles the environment and the time limits of run,
+// and collects the secrets to keep out of logs and diagnostics.
+func (r *KclExecResource) buildRunEnvironment(ctx context.Context, run *execRun) bool {
+	plan, diagnostics, workDir := run.plan, run.diagnostics, run.workDir
+
+	// Prepare environment variables. Only the user-controlled variables, in
+	// sorted order, contribute to the ID; the inherited OS environment is
+	// machine specific. Resource values override environment files, which
+	// override provider defaults.
+	envMap := make(map[string]string)
+	if r.provider != nil {
+		for k, v := range r.provider.DefaultEnvironment {
+			envMap[k] = v
+		}
+	}
+	if !plan.EnvironmentFiles.IsNull() {
+		var files []string
+		diags := plan.EnvironmentFiles.ElementsAs(ctx, &files, false)
+		diagnostics.Append(diags...)
+		if diagnostics.HasError() {
+			return false
+		}
+		fileEnv, err := readDotenvFiles(files, workDir)
+		if err != nil {
+			diagnostics.AddAttributeError(path.Root("environment_files"), "Environment File Error", err.Error())
+			return false
+		}
+		for k, v := range fileEnv {
+			envMap[k] = v
+		}
+	}
+	if !plan.Environment.IsNull() {
+		resourceEnv := make(map[string]string)
+		diags := plan.Environment.ElementsAs(ctx, &resourceEnv, false)
+		diagnostics.Append(diags...)
+		if diagnostics.HasError() {
+			return false
+		}
+		for k, v := range resourceEnv {
+			envMap[k] = v
+		}
+	}
+
+	if !plan.Threads.IsNull() {
+		envMap[threadsEnvVar] = fmt.Sprintf("%d", plan.Threads.ValueInt64())
+	}
+
+	if !plan.VendorDir.IsNull() {
+		vendorDir := plan.VendorDir.ValueString()
+		if !filepath.IsAbs(vendorDir) {
+			vendorDir = filepath.Join(workDir, vendorDir)
+		}
+		if plan.Vendor.ValueBool() {
+			if info, err := os.Stat(vendorDir); err != nil || !info.IsDir() {
+				diagnostics.AddAttributeError(path.Root("vendor_dir"), "Vendor Directory Not Found",
+					"vendor is enabled but the vendor directory does not exist: "+vendorDir)
+				return false
+			}
+		}
+		envMap[pkgPathEnvVar] = vendorDir
+	}
+
+	// Secret environment and argument values are kept out of the recorded
+	// command line and, by execute, out of the logs
+	if !plan.SensitiveEnvironment.IsNull() {
+		var sensitiveNames []string
+		diags := plan.SensitiveEnvironment.ElementsAs(ctx, &sensitiveNames, false)
+		diagnostics.Append(diags...)
+		if diagnostics.HasError() {
+			return false
+		}
+		for _, name := range sensitiveNames {
+			if value, ok := envMap[name]; ok && value != "" {
+				run.secrets = append(run.secrets, value)
+			}
+		}
+	}
+	if !plan.SensitiveArguments.IsNull() {
+		var sensitiveKeys []string
+		diags := plan.SensitiveArguments.ElementsAs(ctx, &sensitiveKeys, false)
+		diagnostics.Append(diags...)
+		if diagnostics.HasError() {
+			return false
+		}
+		for _, key := range sensitiveKeys {
+			if value, ok := run.argSpec.Arguments[key]; ok && value != "" {
+				run.secrets = append(run.secrets, value)
+			}
+		}
+	}
+	run.shownArgs = strings.Join(redactArgs(run.args, run.secrets), " ")
+
+	// Keep secret output out of the streamed output lines and diagnostics
+	run.sensitive = plan.SensitiveOutput.ValueBool()
+
+	run.userEnv = sortedEnv(envMap)
+	// Never leave envVars nil, which would make exec inherit the host environment
+	run.envVars = []string{}
+	if plan.InheritEnvironment.IsNull() || plan.InheritEnvironment.ValueBool() {
+		run.envVars = os.Environ()
+	}
+	// The provider's package storage is shared by every resource and, like
+	// the inherited environment, machine specific
+	run.envVars = append(run.envVars, r.provider.pkgPathEnv()...)
+	// Disabling colors is not a user-controlled variable, so it is added
+	// before userEnv, letting an explicit NO_COLOR win, and stays out of the ID
+	if plan.NoStyle.IsNull() || plan.NoStyle.ValueBool() {
+		run.envVars = append(run.envVars, noColorEnvVar+"=1")
+	}
+	// Metadata must not alter the ID either, and is overridden by userEnv
+	if plan.InjectTFMetadata.ValueBool() {
+		run.metadataEnv = metadataEnvironment(run.metadata)
+		run.envVars = append(run.envVars, run.metadataEnv...)
+	}
+	run.envVars = append(run.envVars, run.userEnv...)
+
+	// Load environment variables backed by files. Their values are kept out
+	// of envVars so only a digest of them contributes to the ID.
+	if !plan.EnvironmentFromFiles.IsNull() {
+		fileMap := make(map[string]string)
+		diags := plan.EnvironmentFromFiles.ElementsAs(ctx, &fileMap, false)
+		diagnostics.Append(diags...)
+		if diagnostics.HasError() {
+			return false
+		}
+
+		vars, err := readEnvironmentFiles(fileMap)
+		if err != nil {
+			diagnostics.AddError("Environment File Error", err.Error())
+			return false
+		}
+		run.fileVars = vars
+
+		h := sha256.New()
+		for _, kv := range run.fileVars {
+			h.Write([]byte(kv))
+			h.Write([]byte{0})
+		}
+		run.fileEnvHash = hex.EncodeToString(h.Sum(nil))
+	}
+
+	timeout := 300 * time.Second
+	if !plan.Timeout.IsNull() {
+		timeout = time.Duration(plan.Timeout.ValueInt64()) * time.Second
+	}
+	run.timeout = effectiveTimeout(ctx, timeout)
+
+	run.killTimeout = killGracePeriod
+	if !plan.KillTimeout.IsNull() {
+		run.killTimeout = time.Duration(plan.KillTimeout.ValueInt64()) * time.Second
+	}
+	return true
+}
+
+// identifyRun hashes the entries of an incremental run, logs the command
+// and sets the ID, command line and source hash of the plan.
+func (r *KclExecResource) identifyRun(ctx context.Context, run *execRun) bool {
+	plan, diagnostics, argSpec := run.plan, run.diagnostics, run.argSpec
+
+	// With incremental, an entry whose content is unchanged reuses its
+	// recorded output, provided nothing else changed either or
+	// full_on_dependency_change is disabled
+	run.independentEntries = plan.IndependentEntries.ValueBool()
+	run.incremental = run.independentEntries && plan.Incremental.ValueBool()
+	if run.incremental {
+		var err error
+		run.entryHashes, err = hashEntryFiles(run.workDir, argSpec.EntryFiles, run.stdinHash)
+		if err != nil {
+			diagnostics.AddError("Source Hash Error", "Unable to hash the entry files: "+err.Error())
+			return false
+		}
+		otherSources, err := hashSourceDirWithout(run.absPath, run.exclude, entryFilesBelow(run.absPath, run.workDir, argSpec.EntryFiles))
+		if err != nil {
+			diagnostics.AddError("Source Hash Error", "Unable to hash "+run.absPath+": "+err.Error())
+			return false
+		}
+		spec := argSpec
+		spec.EntryFiles, spec.InputFile = nil, ""
+		run.dependencyHash = execCacheKey(run.kclBinary, otherSources, cacheWorkDir(run.absPath, run.workDir),
+			fmt.Sprintf("%q", buildArgs(spec)), fmt.Sprintf("%q", run.userEnv), run.fileEnvHash, run.inputHash)
+	}
+
+	// The provider's version describes a different executable than kcl_path
+	loggedVersion := r.provider.kclVersion()
+	if !plan.KclPath.IsNull() {
+		loggedVersion = "unknown"
+	}
+	run.logCommand = tflog.Info
+	if plan.Quiet.ValueBool() {
+		run.logCommand = tflog.Debug
+	}
+	run.logCommand(ctx, "Executing KCL command", map[string]interface{}{
+		"command":     run.kclBinary,
+		"arguments":   run.args,
+		"directory":   run.workDir,
+		"timeout":     run.timeout,
+		"kcl_version": loggedVersion,
+	})
+
+	// Hooks run where KCL runs, with its environment and timeout
+	if !plan.PreCommand.IsNull() {
+		diagnostics.Append(plan.PreCommand.ElementsAs(ctx, &run.preCommand, false)...)
+	}
+	if !plan.PostCommand.IsNull() {
+		diagnostics.Append(plan.PostCommand.ElementsAs(ctx, &run.postCommand, false)...)
+	}
+	if diagnostics.HasError() {
+		return false
+	}
+
+	// Generate a deterministic ID from the user-controlled inputs
+	idSource := plan.SourceDir.ValueString()
+	// Checkouts, downloads and inline code land in a fresh temporary
+	// directory, so identify them by commit or content
+	switch {
+	case plan.Git != nil:
+		idSource = "git:" + plan.Git.URL.ValueString() + "@" + run.sourceHash + "/" + plan.Git.Subdirectory.ValueString()
+	case !plan.HTTPSource.IsNull():
+		idSource = plan.HTTPSource.ValueString() + "@" + run.sourceHash
+	case !plan.OCIRef.IsNull():
+		idSource = plan.OCIRef.ValueString() + "@" + run.sourceHash
+	case !plan.Code.IsNull():
+		idSource = "code@" + run.sourceHash
+	}
+	triggers := make(map[string]string)
+	if !plan.Triggers.IsNull() {
+		diags := plan.Triggers.ElementsAs(ctx, &triggers, false)
+		diagnostics.Append(diags...)
+		if diagnostics.HasError() {
+			return false
+		}
+	}
+	idInput := fmt.Sprintf("%s|%s|%q|%q|%s|%s|%v", idSource, run.kclCommand, run.idArgs, run.userEnv, run.fileEnvHash, run.inputHash, triggers)
+	if run.stdinHash != "" {
+		idInput += "|stdin:" + run.stdinHash
+	}
+	if run.workDir != run.absPath {
+		idInput += "|working_dir:" + run.workDir
+	}
+	if len(run.preCommand) > 0 {
+		idInput += fmt.Sprintf("|pre_command:%q", run.preCommand)
+	}
+	if len(run.postCommand) > 0 {
+		idInput += fmt.Sprintf("|post_command:%q", run.postCommand)
+	}
+	hash := sha256.Sum256([]byte(idInput))
+	plan.ID = types.StringValue(hex.EncodeToString(hash[:16]))
+
+	commandLine, diags := types.ListValueFrom(ctx, types.StringType, append([]string{run.kclBinary}, redactArgs(run.args, run.secrets)...))
+	plan.CommandLine = commandLine
+	diagnostics.Append(diags...)
+	if diagnostics.HasError() {
+		return false
+	}
+
+	plan.SourceHash = types.StringNull()
+	if !plan.SourceDir.IsNull() {
+		plan.SourceHash = types.StringValue(run.sourceHash)
+	}
+	return true
+}
+
+// lookupCachedResult loads the cached result of run when the provider has
+// a cache and none of the inputs changed. The inherited host environment is
+// not part of the key.
+func (r *KclExecResource) lookupCachedResult(ctx context.Context, run *execRun) bool {
+	plan, diagnostics, workDir := run.plan, run.diagnostics, run.workDir
+	if r.provider == nil || r.provider.CacheDir == "" || run.independentEntries {
+		return true
+	}
+
+	run.cacheDir = r.provider.CacheDir
+	contentHash, err := hashSourceDir(run.absPath, run.exclude)
+	if err != nil {
+		diagnostics.AddError("Cache Key Error", "Unable to hash "+run.absPath+": "+err.Error())
+		return false
+	}
+	kclVersion, err := r.kclVersionOf(ctx, plan, run.kclBinary)
+	if err != nil {
+		diagnostics.AddError("KCL Version Detection Failed", err.Error())
+		return false
+	}
+	filesHash, err := hashFiles(workDir, append(append([]string{}, run.argSpec.SettingsFiles...), run.argSpec.EntryFiles...))
+	if err != nil {
+		diagnostics.AddError("Cache Key Error", "Unable to hash settings and entry files: "+err.Error())
+		return false
+	}
+	run.cacheKey = execCacheKey(kclVersion, run.kclBinary, contentHash, cacheWorkDir(run.absPath, workDir), filesHash,
+		fmt.Sprintf("%q", run.idArgs), fmt.Sprintf("%q", run.userEnv), run.fileEnvHash, run.inputHash, run.stdinHash,
+		fmt.Sprintf("%q", run.metadataEnv))
+
+	if plan.Force.ValueBool() {
+		return true
+	}
+	run.result, run.cached, err = loadCachedResult(run.cacheDir, run.cacheKey)
+	if err != nil {
+		diagnostics.AddError("Cache Read Error", err.Error())
+		return false
+	}
+	if run.cached {
+		run.logCommand(ctx, "Using cached KCL result", map[string]interface{}{
+			"cache_key": run.cacheKey,
+		})
+	}
+	return true
+}
+
+// runWithRetries runs KCL, retrying failures that may be transient within
+// the overall timeout, and records the last attempt in run.
+func (r *KclExecResource) runWithRetries(ctx context.Context, run *execRun) {
+	plan := run.plan
+	start := time.Now()
+
+	retryInterval := 5 * time.Second
+	if !plan.RetryInterval.IsNull() {
+		retryInterval = time.Duration(plan.RetryInterval.ValueInt64()) * time.Second
+	}
+
+	for attempt := int64(1); ; attempt++ {
+		run.result, run.err = r.runOnce(ctx, run)
+
+		code, runErr := exitCodeOf(run.err)
+		failed := runErr != nil || (code != 0 && !containsInt64(run.allowedExitCodes, code))
+		if !failed || attempt > plan.Retries.ValueInt64() || ctx.Err() != nil {
+			break
+		}
+		if !plan.RetryCompileErrors.ValueBool() && isKclCompileError(string(run.result.Combined)) {
+			break
+		}
+
+		tflog.Warn(ctx, "KCL execution failed, retrying", map[string]interface{}{
+			"attempt":     attempt,
+			"max_retries": plan.Retries.ValueInt64(),
+			"error":       run.err.Error(),
+			"retry_in":    retryInterval,
+		})
+
+		timer := time.NewTimer(retryInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+		case <-timer.C:
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	run.duration = time.Since(start)
+}
+
+// runOnce runs the command line of run. With independent_entries, every
+// entry runs on its own, or reuses its recorded output when incremental
+// allows, and the outputs are joined as YAML documents.
+func (r *KclExecResource) runOnce(ctx context.Context, run *execRun) (commandOutput, error) {
+	if !run.independentEntries {
+		return r.runArgs(ctx, run, run.args)
+	}
+
+	run.entryStdout, run.entryFailures = make(map[string][]byte, len(run.argSpec.EntryFiles)), nil
+	var (
+		all      commandOutput
+		firstErr error
+	)
+	for i, entry := range run.argSpec.EntryFiles {
+		if ctx.Err() != nil {
+			break
+		}
+		var (
+			result commandOutput
+			err    error
+		)
+		if stdout, ok := run.reusableStdout(entry); ok {
+			tflog.Debug(ctx, "Reusing the output of an unchanged entry", map[string]interface{}{
+				"entry": entry,
+			})
+			result = commandOutput{Combined: stdout, Stdout: stdout}
+		} else {
+			spec := run.argSpec
+			spec.EntryFiles = []string{entry}
+			result, err = r.runArgs(ctx, run, buildArgs(spec))
+		}
+
+		if i > 0 {
+			all.Combined = appendDocumentSeparator(all.Combined)
+			all.Stdout = appendDocumentSeparator(all.Stdout)
+		}
+		all.Combined = append(all.Combined, result.Combined...)
+		all.Stdout = append(all.Stdout, result.Stdout...)
+		all.Stderr = append(all.Stderr, result.Stderr...)
+
+		code, runErr := exitCodeOf(err)
+		if runErr != nil || (code != 0 && !containsInt64(run.allowedExitCodes, code)) {
+			run.entryFailures = append(run.entryFailures, entryFailure{Entry: entry, Err: err, Output: result.Combined})
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		run.entryStdout[entry] = result.Stdout
+	}
+	return all, firstErr
+}
+
+// runArgs runs KCL once with args, in-process for the native backend.
+func (r *KclExecResource) runArgs(ctx context.Context, run *execRun, args []string) (commandOutput, error) {
+	if r.provider.nativeBackend() {
+		return r.provider.runNative(ctx, args, run.workDir)
+	}
+	cmd := exec.CommandContext(ctx, run.kclBinary, args...)
+	cmd.Dir = run.workDir
+	cmd.Env = append(run.envVars, run.fileVars...)
+	// exec copies the reader to KCL in chunks and closes the pipe at EOF
+	if !run.plan.Stdin.IsNull() {
+		cmd.Stdin = strings.NewReader(run.plan.Stdin.ValueString())
+	}
+	configureGracefulStopWithin(cmd, run.killTimeout)
+	if run.plan.Debug.ValueBool() {
+		return r.provider.runKclLogging(ctx, cmd, tflog.Info)
+	}
+	return r.provider.runKcl(ctx, cmd)
+}
+
+// runHook runs the pre_command or post_command hook name where KCL runs,
+// adding an error titled after title when it fails.
+func (r *KclExecResource) runHook(ctx context.Context, run *execRun, name, title string, command []string) bool {
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Dir = run.workDir
+	cmd.Env = append(run.envVars, run.fileVars...)
+	configureGracefulStopWithin(cmd, run.killTimeout)
+
+	run.logCommand(ctx, "Executing "+name, map[string]interface{}{
+		"command":   command[0],
+		"arguments": redactArgs(command[1:], run.secrets),
+		"directory": run.workDir,
+	})
+
+	result, err := runCapturingOutput(ctx, cmd)
+	if err != nil {
+		run.diagnostics.AddAttributeError(path.Root(name), title+" Failed",
+			fmt.Sprintf("Command: %s\nError: %v\nOutput: %s",
+				strings.Join(redactArgs(command, run.secrets), " "), err, run.shown(strings.TrimSpace(string(result.Combined)))))
+		return false
+	}
+	return true
+}
+
+// reusableStdout returns the recorded output of entry when incremental
+// allows reusing it.
+func (run *execRun) reusableStdout(entry string) ([]byte, bool) {
+	plan := run.plan
+	dependenciesChanged := run.prior.DependencyHash != run.dependencyHash
+	if !run.incremental || (dependenciesChanged && (plan.FullOnDependencyChange.IsNull() || plan.FullOnDependencyChange.ValueBool())) {
+		return nil, false
+	}
+	record, ok := run.prior.Entries[entry]
+	if !ok || record.Hash != run.entryHashes[entry] {
+		return nil, false
+	}
+	return record.Stdout, true
+}
+
+// shown returns output as it may appear in diagnostics.
+func (run *execRun) shown(output string) string {
+	if run.sensitive {
+		return redactOutput(output)
+	}
+	return output
+}
+
+// decode decodes a stream of KCL output from output_encoding and strips its
+// colors, noting when invalid UTF-8 had to be replaced.
+func (run *execRun) decode(raw []byte) (string, error) {
+	decoded, replaced, err := decodeOutput(raw, run.outputEncoding)
+	if err != nil {
+		return "", err
+	}
+	run.invalidUTF8 = run.invalidUTF8 || replaced
+	return stripANSI(decoded), nil
+}
+
+// checkRunResult decodes the output of run and reports a run that failed,
+// was cut short or did not meet the success criteria of the plan. It also
+// runs post_command and the determinism check.
+func (r *KclExecResource) checkRunResult(ctx context.Context, run *execRun) bool {
+	plan, diagnostics := run.plan, run.diagnostics
+	kclBinary, shownArgs, err := run.kclBinary, run.shownArgs, run.err
+
+	// Decode each stream
+	decoded, decodeErr := run.decode(run.result.Combined)
+	if decodeErr == nil {
+		run.stdout, decodeErr = run.decode(run.result.Stdout)
+	}
+	if decodeErr == nil {
+		run.stderr, decodeErr = run.decode(run.result.Stderr)
+	}
+	if decodeErr != nil {
+		diagnostics.AddError("Output Decoding Failed", decodeErr.Error())
+		return false
+	}
+	if run.invalidUTF8 {
+		diagnostics.AddWarning(
+			"Invalid UTF-8 In KCL Output",
+			"The KCL output contained invalid UTF-8 byte sequences, which were replaced with U+FFFD. "+
+				"Set output_encoding if KCL writes output in another encoding.",
+		)
+	}
+	run.decoded = decoded
+
+	// KCL has no machine-readable diagnostics output, so json_diagnostics
+	// collects the errors and warnings of its text reports: errors from the
+	// combined output, warnings from stderr, as reported below
+	if run.jsonDiagnostics {
+		run.kclDiags = append(parseKclTextErrors(decoded), parseKclTextWarnings(run.stderr)...)
+	}
+
+	if errors.Is(ctx.Err(), context.Canceled) {
+		diagnostics.AddError(
+			"KCL Execution Cancelled",
+			fmt.Sprintf("Command %s %s was interrupted before it finished.\nOutput: %s",
+				kclBinary, shownArgs, run.shown(decoded)),
+		)
+		return false
+	}
+
+	// A run killed at the deadline ends with "signal: killed", which reads
+	// like a crash, so say plainly that it ran out of time
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		diagnostics.AddAttributeError(
+			path.Root("timeout"),
+			fmt.Sprintf("KCL Execution Timed Out after %ds", int64(run.timeout.Round(time.Second)/time.Second)),
+			fmt.Sprintf("Command %s %s did not finish within %s and was stopped. "+
+				"Raise timeout if the program needs more time.\nOutput so far: %s",
+				kclBinary, shownArgs, run.timeout.Round(time.Second), run.shown(decoded)),
+		)
+		return false
+	}
+
+	// Tolerate non-zero exits the configuration expects
+	exitCode, exitErr := exitCodeOf(err)
+	if exitErr == nil && exitCode != 0 && !containsInt64(run.allowedExitCodes, exitCode) {
+		exitErr = err
+	}
+	run.exitCode = exitCode
+
+	if exitErr != nil && len(run.entryFailures) > 0 {
+		for _, failure := range run.entryFailures {
+			diagnostics.AddAttributeError(path.Root("entry_files"), "KCL Entry Failed",
+				fmt.Sprintf("Entry: %s\nError: %v\nOutput: %s", failure.Entry, failure.Err, run.shown(stripANSI(string(failure.Output)))))
+		}
+		return false
+	}
+	if exitErr != nil {
+		// Locate errors in the text report, and fall back to the raw output
+		// when it has an unknown format
+		errorDiags := run.kclDiags
+		if !run.jsonDiagnostics && !run.sensitive {
+			errorDiags = parseKclTextErrors(decoded)
+		}
+		if reportKclDiagnostics(diagnostics, errorDiags) {
+			return false
+		}
+		diagnostics.AddError(
+			"KCL Execution Failed",
+			fmt.Sprintf("Command: %s %s\nError: %v\nOutput: %s",
+				kclBinary, shownArgs, err, run.shown(decoded)),
+		)
+		return false
+	}
+
+	// Require the success marker when one is configured
+	if !plan.SuccessMarker.IsNull() && exitCode == 0 {
+		marker := plan.SuccessMarker.ValueString()
+		if !outputHasMarker(decoded, marker) {
+			diagnostics.AddError(
+				"KCL Success Marker Not Found",
+				fmt.Sprintf("Command exited successfully but its output does not contain %q\nOutput: %s",
+					marker, run.shown(decoded)),
+			)
+			return false
+		}
+	}
+
+	// Surface warnings from a successful run, which would otherwise only be
+	// visible in stderr. Sensitive output is not scanned for text warnings,
+	// like it is not scanned for text errors.
+	warningDiags := run.kclDiags
+	if !run.jsonDiagnostics && !run.sensitive {
+		warningDiags = parseKclTextWarnings(run.stderr)
+	}
+	if reportKclWarnings(diagnostics, warningDiags, plan.FailOnWarnings.ValueBool()) {
+		return false
+	}
+
+	if len(run.postCommand) > 0 && !r.runHook(ctx, run, "post_command", "Post-Command Hook", run.postCommand) {
+		return false
+	}
+
+	// Run a second time and compare when determinism is required
+	if plan.VerifyDeterministic.ValueBool() {
+		tflog.Debug(ctx, "Re-running KCL command to verify deterministic output")
+
+		secondResult, err := r.runOnce(ctx, run)
+		if secondCode, exitErr := exitCodeOf(err); exitErr != nil || secondCode != exitCode {
+			diagnostics.AddError(
+				"KCL Execution Failed",
+				fmt.Sprintf("Verification run of %s %s failed\nError: %v\nOutput: %s",
+					kclBinary, shownArgs, err, run.shown(string(secondResult.Combined))),
+			)
+			return false
+		}
+
+		second, err := run.decode(secondResult.Combined)
+		if err != nil {
+			diagnostics.AddError("Output Decoding Failed", err.Error())
+			return false
+		}
+
+		if diff := compareCanonicalOutputs(decoded, second); diff != "" {
+			diagnostics.AddError(
+				"Non-Deterministic KCL Output",
+				"Two runs of the same configuration produced different output:\n"+diff,
+			)
+			return false
+		}
+	}
+	return true
+}
+
+// recordOutputs fills in the output attributes of the plan from a run that
+// passed checkRunResult, writes the output files and checks the result
+// against result_schema.
+func (r *KclExecResource) recordOutputs(ctx context.Context, run *execRun) bool {
+	plan, diagnostics, stdout, stderr := run.plan, run.diagnostics, run.stdout, run.stderr
+
+	plan.DurationMs = types.Int64Value(run.duration.Milliseconds())
+
+	transformed, err := applyOutputTransforms(run.decoded, run.transforms)
+	if err != nil {
+		diagnostics.AddError("Output Transform Failed", err.Error())
+		return false
+	}
+	transformed = strings.TrimSpace(transformed)
+
+	if !plan.MaxStateOutputBytes.IsNull() && int64(len(transformed)) > plan.MaxStateOutputBytes.ValueInt64() {
+		diagnostics.AddAttributeError(
+			path.Root("max_state_output_bytes"),
+			"KCL Output Exceeds State Budget",
+			fmt.Sprintf("The output is %d bytes, above the limit of %d bytes. "+
+				"Write large results to a file with output_file instead, and keep only a summary in state, "+
+				"for example with output_transforms.",
+				len(transformed), plan.MaxStateOutputBytes.ValueInt64()),
+		)
+		return false
+	}
+	plan.Output = types.StringValue(transformed)
+	plan.ExitCode = types.Int64Value(run.exitCode)
+	plan.Stdout = types.StringValue(strings.TrimSpace(stdout))
+	plan.Stderr = types.StringValue(strings.TrimSpace(stderr))
+	stdoutSum := sha256.Sum256([]byte(plan.Stdout.ValueString()))
+	plan.OutputSHA256 = types.StringValue(hex.EncodeToString(stdoutSum[:]))
+
+	// Let the verifier accept or reject the rendered output
+	if plan.Verify != nil && !plan.Verify.Command.IsNull() {
+		verifyArgs := []string{}
+		if !plan.Verify.Args.IsNull() {
+			diags := plan.Verify.Args.ElementsAs(ctx, &verifyArgs, false)
+			diagnostics.Append(diags...)
+			if diagnostics.HasError() {
+				return false
+			}
+		}
+
+		verifyTimeout := 300 * time.Second
+		if !plan.Verify.TimeoutSeconds.IsNull() {
+			verifyTimeout = time.Duration(plan.Verify.TimeoutSeconds.ValueInt64()) * time.Second
+		}
+
+		verifyOutput, err := runVerifier(ctx, plan.Verify.Command.ValueString(), verifyArgs, verifyTimeout,
+			run.workDir, append(run.envVars, run.fileVars...), transformed)
+		if err != nil {
+			diagnostics.AddError(
+				"KCL Output Verification Failed",
+				fmt.Sprintf("Verifier: %v\nOutput: %s", err, run.shown(verifyOutput)),
+			)
+			return false
+		}
+	}
+
+	// Hand the output to other tools without keeping a second copy in state
+	outputFilePermission := defaultOutputFilePermission
+	if !plan.OutputFilePermission.IsNull() {
+		outputFilePermission = plan.OutputFilePermission.ValueString()
+	}
+	if !plan.OutputFile.IsNull() {
+		perm, err := parseFilePermission(outputFilePermission)
+		if err != nil {
+			diagnostics.AddAttributeError(path.Root("output_file_permission"), "Invalid File Permission", err.Error())
+			return false
+		}
+
+		outputFile := plan.OutputFile.ValueString()
+		if err := writeFileAtomic(outputFile, stdout, perm); err != nil {
+			diagnostics.AddAttributeError(path.Root("output_file"), "Output File Write Error",
+				"Unable to write "+outputFile+": "+err.Error())
+			return false
+		}
+	}
+
+	documents := splitYAMLDocuments(stdout)
+	var diags diag.Diagnostics
+	plan.Documents, diags = types.ListValueFrom(ctx, types.StringType, documents)
+	diagnostics.Append(diags...)
+	if diagnostics.HasError() {
+		return false
+	}
+	plan.DocumentCount = types.Int64Value(int64(len(documents)))
+	plan.DocumentsByKindName, diags = types.MapValueFrom(ctx, types.StringType, documentsByKindName(documents))
+	diagnostics.Append(diags...)
+	if diagnostics.HasError() {
+		return false
+	}
+
+	// Write every document to a file of its own
+	plan.OutputDirFiles = types.ListNull(types.StringType)
+	if !plan.OutputDir.IsNull() {
+		var tmpl *template.Template
+		if !plan.FilenameTemplate.IsNull() {
+			if tmpl, err = parseFilenameTemplate(plan.FilenameTemplate.ValueString()); err != nil {
+				diagnostics.AddAttributeError(path.Root("filename_template"), "Invalid Filename Template", err.Error())
+				return false
+			}
+		}
+		names, err := documentFileNames(documents, tmpl)
+		if err != nil {
+			diagnostics.AddAttributeError(path.Root("filename_template"), "Invalid Document File Name", err.Error())
+			return false
+		}
+		perm, err := parseFilePermission(outputFilePermission)
+		if err != nil {
+			diagnostics.AddAttributeError(path.Root("output_file_permission"), "Invalid File Permission", err.Error())
+			return false
+		}
+		outputDir := plan.OutputDir.ValueString()
+		for i, name := range names {
+			file := filepath.Join(outputDir, filepath.FromSlash(name))
+			if err := writeFileAtomic(file, documents[i]+"\n", perm); err != nil {
+				diagnostics.AddAttributeError(path.Root("output_dir"), "Output File Write Error",
+					"Unable to write "+file+": "+err.Error())
+				return false
+			}
+		}
+		plan.OutputDirFiles, diags = types.ListValueFrom(ctx, types.StringType, names)
+		diagnostics.Append(diags...)
+		if diagnostics.HasError() {
+			return false
+		}
+	}
+
+	plan.StdoutLines, diags = types.ListValueFrom(ctx, types.StringType, outputLines(stdout))
+	diagnostics.Append(diags...)
+	plan.StderrLines, diags = types.ListValueFrom(ctx, types.StringType, outputLines(stderr))
+	diagnostics.Append(diags...)
+	if diagnostics.HasError() {
+		return false
+	}
+
+	// Parse stdout into a Terraform value when its format is declared. Each
+	// independent entry has a result of its own.
+	plan.Result = types.DynamicNull()
+	plan.EntryResults = types.MapNull(types.StringType)
+	plan.OutputToml = types.StringNull()
+	if run.independentEntries && !run.sensitive {
+		format := resultFormatYAML
+		if !plan.Format.IsNull() {
+			format = run.argSpec.Format
+		}
+		outputs := make(map[string]string, len(run.entryStdout))
+		for entry, raw := range run.entryStdout {
+			if outputs[entry], err = run.decode(raw); err != nil {
+				diagnostics.AddError("Output Decoding Failed", err.Error())
+				return false
+			}
+		}
+		encoded, results, err := parseEntryResults(outputs, format)
+		if err != nil {
+			diagnostics.AddAttributeError(path.Root("entry_results"), "Invalid KCL Result", err.Error())
+			return false
+		}
+		plan.EntryResults, diags = types.MapValueFrom(ctx, types.StringType, encoded)
+		diagnostics.Append(diags...)
+		if diagnostics.HasError() {
+			return false
+		}
+		if !plan.Format.IsNull() {
+			plan.Result = types.DynamicValue(results)
+		}
+	} else if !plan.Format.IsNull() && !run.sensitive {
+		output := strings.TrimSpace(stdout)
+		if run.convertToTOML {
+			if output, err = jsonToTOML(output); err != nil {
+				diagnostics.AddAttributeError(path.Root("format"), "Incompatible TOML Output",
+					"Unable to convert the JSON output of KCL to TOML: "+err.Error())
+				return false
+			}
+			output = strings.TrimSpace(output)
+		}
+		result, err := parseResult(output, plan.Format.ValueString())
+		if err != nil {
+			diagnostics.AddAttributeError(
+				path.Root("format"),
+				"Invalid KCL Result",
+				fmt.Sprintf("Unable to parse stdout as %s: %v", plan.Format.ValueString(), err),
+			)
+			return false
+		}
+		plan.Result = types.DynamicValue(result)
+		if plan.Format.ValueString() == resultFormatTOML {
+			plan.OutputToml = types.StringValue(output)
+		}
+	}
+
+	// Check the result against the schema it is declared to conform to. An
+	// oci_ref package has no local file to take the schema from.
+	programFile := run.argSpec.CodeFile
+	if run.argSpec.Tag != "" {
+		programFile = ""
+	}
+	if !plan.ResultSchema.IsNull() && !r.vetResult(ctx, plan, run.kclBinary, run.workDir, programFile,
+		append(run.envVars, run.fileVars...), stdout, &run.tempPaths, run.shown, diagnostics) {
+		return false
+	}
+
+	// Only stdout holds the result; anything KCL prints on stderr, e.g. a
+	// warning, would make the combined output invalid JSON
+	stdoutTransformed, err := applyOutputTransforms(stdout, run.transforms)
+	if err != nil {
+		diagnostics.AddError("Output Transform Failed", err.Error())
+		return false
+	}
+	plan.ResultCompactJSON = types.StringNull()
+	if compact, ok := compactJSON(stdoutTransformed); ok {
+		plan.ResultCompactJSON = types.StringValue(compact)
+	}
+
+	// Move secret output into the sensitive attributes only
+	plan.OutputSensitive = types.StringNull()
+	plan.StdoutSensitive = types.StringNull()
+	if run.sensitive {
+		plan.OutputSensitive = plan.Output
+		plan.StdoutSensitive = plan.Stdout
+		plan.Output = types.StringNull()
+		plan.Stdout = types.StringNull()
+		plan.Documents = types.ListNull(types.StringType)
+		plan.DocumentsByKindName = types.MapNull(types.StringType)
+		plan.StdoutLines = types.ListNull(types.StringType)
+		plan.ResultCompactJSON = types.StringNull()
+	}
+
+	// Fingerprint the resolved dependencies
+	lockedDeps, err := readKclModLock(run.workDir)
+	if err != nil {
+		diagnostics.AddError("Lock File Read Error", "Unable to read "+kclModLockFileName+": "+err.Error())
+		return false
+	}
+	plan.DependencyClosureHash = types.StringNull()
+	if lockedDeps != nil {
+		plan.DependencyClosureHash = types.StringValue(dependencyClosureHash(lockedDeps))
+	}
+	plan.Lockfile, err = readKclModLockContent(run.workDir)
+	if err != nil {
+		diagnostics.AddError("Lock File Read Error", "Unable to read "+kclModLockFileName+": "+err.Error())
+		return false
+	}
+
+	diagObjType := types.ObjectType{AttrTypes: kclDiagnosticAttrTypes}
+	plan.Diagnostics = types.ListNull(diagObjType)
+	if run.jsonDiagnostics {
+		kclDiags := run.kclDiags
+		if kclDiags == nil {
+			kclDiags = []kclDiagnostic{}
+		}
+		plan.Diagnostics, diags = types.ListValueFrom(ctx, diagObjType, kclDiags)
+		diagnostics.Append(diags...)
+	}
+	return true
+}
+
+func (r *KclExecResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state KclExecResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Only the output files, when asked for, outlive the execution
+	if !state.DeleteOutputFileOnDestroy.ValueBool() {
+		return
+	}
+
+	var outputFiles []string
+	if !state.OutputFile.IsNull() {
+		outputFiles = append(outputFiles, state.OutputFile.ValueString())
+	}
+	if !state.OutputDir.IsNull() && !state.OutputDirFiles.IsNull() {
+		var names []string
+		resp.Diagnostics.Append(state.OutputDirFiles.ElementsAs(ctx, &names, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		for _, name := range names {
+			outputFiles = append(outputFiles, filepath.Join(state.OutputDir.ValueString(), filepath.FromSlash(name)))
+		}
+	}
+
+	for _, outputFile := range outputFiles {
+		tflog.Info(ctx, "Removing output file", map[string]interface{}{
+			"path": outputFile,
+		})
+		if err := os.Remove(outputFile); err != nil && !os.IsNotExist(err) {
+			resp.Diagnostics.AddError("Output File Removal Error", "Unable to remove "+outputFile+": "+err.Error())
+		}
+	}
+}
+
+// kclVersionOf returns the version of the KCL executable plan runs. The
+// provider only knows the version of its own executable.
+func (r *KclExecResource) kclVersionOf(ctx context.Context, plan *KclExecResourceModel, kclBinary string) (string, error) {
+	if r.provider.nativeBackend() {
+		if r.provider.KclVersion != "" {
+			return r.provider.KclVersion, nil
+		}
+		return nativeKclVersion()
+	}
+	if plan.KclPath.IsNull() && r.provider != nil {
+		return r.provider.detectedKclVersion(ctx, kclBinary)
+	}
+	detected, err := detectKclVersion(ctx, kclBinary)
+	if err != nil {
+		return "", err
+	}
+	return detected.String(), nil
+}
+
+// outputHasMarker reports whether output contains marker, either literally or
+// as a match of marker interpreted as a regular expression.
+func outputHasMarker(output, marker string) bool {
+	if strings.Contains(output, marker) {
+		return true
+	}
+
+	re, err := regexp.Compile(marker)
+	if err != nil {
+		return false
+	}
+	return re.MatchString(output)
+}
+
+// entryFailure is an entry of independent_entries whose run failed.
+type entryFailure struct {
+	Entry  string
+	Err    error
+	Output []byte
+}
+
+// appendDocumentSeparator ends output with a "---" line, so the output of
+// the next entry starts a new YAML document.
+func appendDocumentSeparator(output []byte) []byte {
+	if len(output) > 0 && output[len(output)-1] != '\n' {
+		output = append(output, '\n')
+	}
+	return append(output, "---\n"...)
+}
+
+// readEnvironmentFiles reads each file in files and returns NAME=value pairs
+// sorted by variable name, with the file contents trimmed of surrounding
+// whitespace.
+func readEnvironmentFiles(files map[string]string) ([]string, error) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	vars := make([]string, 0, len(names))
+	for _, name := range names {
+		filePath, err := filepath.Abs(files[name])
+		if err != nil {
+			return nil, fmt.Errorf("invalid path for %s: %w", name, err)
+		}
+
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, fmt.Errorf("file for %s does not exist: %s", name, filePath)
+			}
+			return nil, fmt.Errorf("reading file for %s: %w", name, err)
+		}
+
+		vars = append(vars, fmt.Sprintf("%s=%s", name, strings.TrimSpace(string(content))))
+	}
+	return vars, nil
+}
+
+// reportDryRun nulls the output attributes of plan and reports the command
+// a dry run would have executed. Values of environment_from_files are
+// never shown.
+func (r *KclExecResource) reportDryRun(ctx context.Context, plan *KclExecResourceModel, kclBinary, shownArgs, workDir string,
+	shownEnv, fileVars []string, diagnostics *diag.Diagnostics) {
+	plan.Output = types.StringNull()
+	plan.Stdout = types.StringNull()
+	plan.Stderr = types.StringNull()
+	plan.ExitCode = types.Int64Null()
+	plan.DurationMs = types.Int64Null()
+	plan.OutputSHA256 = types.StringNull()
+	plan.OutputSensitive = types.StringNull()
+	plan.StdoutSensitive = types.StringNull()
+	plan.StdoutLines = types.ListNull(types.StringType)
+	plan.StderrLines = types.ListNull(types.StringType)
+	plan.Documents = types.ListNull(types.StringType)
+	plan.DocumentCount = types.Int64Null()
+	plan.DocumentsByKindName = types.MapNull(types.StringType)
+	plan.OutputDirFiles = types.ListNull(types.StringType)
+	plan.Result = types.DynamicNull()
+	plan.EntryResults = types.MapNull(types.StringType)
+	plan.OutputToml = types.StringNull()
+	plan.ResultCompactJSON = types.StringNull()
+	plan.DependencyClosureHash = types.StringNull()
+	plan.Lockfile = types.StringNull()
+	plan.Diagnostics = types.ListNull(types.ObjectType{AttrTypes: kclDiagnosticAttrTypes})
+
+	env := append([]string{}, shownEnv...)
+	for _, kv := range fileVars {
+		name, _, _ := strings.Cut(kv, "=")
+		env = append(env, name+"="+redactedValue)
+	}
+	shownEnvLines := "(none)"
+	if len(env) > 0 {
+		shownEnvLines = strings.Join(env, "\n")
+	}
+
+	tflog.Info(ctx, "Skipping KCL execution for dry run", map[string]interface{}{
+		"command":   kclBinary,
+		"directory": workDir,
+	})
+	diagnostics.AddWarning("KCL Dry Run",
+		fmt.Sprintf("dry_run is set, so KCL was not run.\nCommand: %s %s\nDirectory: %s\n"+
+			"Environment, besides the inherited one:\n%s", kclBinary, shownArgs, workDir, shownEnvLines))
+}
+
+// vetResult validates stdout against result_schema with `kcl vet`, adding
+// an error per violation. The data file is added to tempPaths. codeFile is
+// the program file, used when result_schema_file is not set.
+func (r *KclExecResource) vetResult(ctx context.Context, plan *KclExecResourceModel, kclBinary, workDir, codeFile string,
+	env []string, stdout string, tempPaths *[]string, shown func(string) string, diagnostics *diag.Diagnostics) bool {
+	schemaFile := plan.ResultSchemaFile.ValueString()
+	if schemaFile == "" {
+		schemaFile = codeFile
+	}
+	if schemaFile == "" {
+		diagnostics.AddAttributeError(path.Root("result_schema_file"), "Missing Result Schema File",
+			"The program is not a single local file, so result_schema_file must name the file defining "+plan.ResultSchema.ValueString()+".")
+		return false
+	}
+	if !filepath.IsAbs(schemaFile) {
+		schemaFile = filepath.Join(workDir, schemaFile)
+	}
+
+	format := plan.Format.ValueString()
+	dataFile, err := os.CreateTemp("", "kclx-result-*."+format)
+	if err != nil {
+		diagnostics.AddError("Result Schema Error", "Unable to write the result to a temporary file: "+err.Error())
+		return false
+	}
+	*tempPaths = append(*tempPaths, dataFile.Name())
+	_, err = dataFile.WriteString(strings.TrimSpace(stdout))
+	if closeErr := dataFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		diagnostics.AddError("Result Schema Error", "Unable to write the result to a temporary file: "+err.Error())
+		return false
+	}
+
+	args := []string{"vet", dataFile.Name(), schemaFile, "--schema", plan.ResultSchema.ValueString(), "--format", format}
+	cmd := exec.CommandContext(ctx, kclBinary, args...)
+	cmd.Dir = workDir
+	cmd.Env = env
+	configureGracefulStop(cmd)
+
+	tflog.Debug(ctx, "Validating KCL result", map[string]interface{}{
+		"command":   kclBinary,
+		"arguments": args,
+	})
+
+	result, err := r.provider.runKcl(ctx, cmd)
+	output := strings.TrimSpace(string(result.Combined))
+	exitCode, runErr := exitCodeOf(err)
+	if runErr != nil || ctx.Err() != nil {
+		diagnostics.AddAttributeError(path.Root("result_schema"), "KCL Vet Failed",
+			fmt.Sprintf("Command: %s %s\nError: %v\nOutput: %s", kclBinary, strings.Join(args, " "), err, shown(output)))
+		return false
+	}
+	if exitCode != 0 {
+		for _, e := range parseKclVetErrors(output) {
+			diagnostics.AddAttributeError(path.Root("result_schema"), "KCL Result Does Not Match Schema",
+				fmt.Sprintf("The result does not conform to %s: %s", plan.ResultSchema.ValueString(), shown(e)))
+		}
+		return false
+	}
+	return true
+}
+
+// outputLines splits output into lines, dropping the line endings, LF or
+// CRLF, and any trailing empty lines. The scanner accepts lines as long as
+// output itself.
+func outputLines(output string) []string {
+	lines := []string{}
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(output)+1)
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimSuffix(scanner.Text(), "\r"))
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// effectiveTimeout bounds the configured timeout by the time remaining on the
+// incoming context's deadline, so a run never outlives the Terraform operation.
+func effectiveTimeout(ctx context.Context, configured time.Duration) time.Duration {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		tflog.Debug(ctx, "Using configured KCL timeout, operation has no deadline", map[string]interface{}{
+			"timeout": configured,
+		})
+		return configured
+	}
+
+	remaining := time.Until(deadline)
+	if remaining < configured {
+		tflog.Debug(ctx, "Operation deadline is shorter than configured KCL timeout", map[string]interface{}{
+			"configured_timeout": configured,
+			"remaining":          remaining,
+		})
+		return remaining
+	}
+
+	tflog.Debug(ctx, "Configured KCL timeout is within operation deadline", map[string]interface{}{
+		"configured_timeout": configured,
+		"remaining":          remaining,
+	})
+	return configured
+}
+
+// writeInputFile stores content in a new temporary file and returns its path.
+func writeInputFile(content string) (string, error) {
+	f, err := os.CreateTemp("", "kclx-input-*")
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// exitCodeOf returns the exit code carried by a command error. err is
+// passed back when the command did not run to completion with an exit code,
+// e.g. because it could not be started or was killed by a signal.
+func exitCodeOf(err error) (int64, error) {
+	if err == nil {
+		return 0, nil
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() >= 0 {
+		return int64(exitErr.ExitCode()), nil
+	}
+	var nativeErr *nativeKclError
+	if errors.As(err, &nativeErr) {
+		return 1, nil
+	}
+	return -1, err
+}
+
+func containsInt64(values []int64, v int64) bool {
+	for _, candidate := range values {
+		if candidate == v {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedEnv renders env as NAME=value pairs sorted by name.
+func sortedEnv(env map[string]string) []string {
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	vars := make([]string, 0, len(names))
+	for _, name := range names {
+		vars = append(vars, name+"="+env[name])
+	}
+	return vars
+}
+
+// metadataEnvironment renders metadata as the variables inject_tf_metadata
+// passes to KCL, sorted by name. Keys are upper-cased and every character
+// other than A-Z, 0-9 and _ becomes _.
+func metadataEnvironment(metadata map[string]string) []string {
+	env := make(map[string]string, len(metadata))
+	for key, value := range metadata {
+		name := strings.Map(func(c rune) rune {
+			if (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_' {
+				return c
+			}
+			return '_'
+		}, strings.ToUpper(key))
+		env[metadataEnvPrefix+name] = value
+	}
+	return sortedEnv(env)
+}
+
+// redactOutput describes output by its size and digest, for messages that
+// must not reveal it.
+func redactOutput(output string) string {
+	sum := sha256.Sum256([]byte(output))
+	return fmt.Sprintf("(sensitive output redacted: %d bytes, sha256 %s)", len(output), hex.EncodeToString(sum[:]))
+}
+
+// sourceDirHash hashes the KCL inputs of a configured source_dir. For a
+// single file that is its directory, where KCL resolves imports.
+func sourceDirHash(sourceDir string, exclude []string) (string, error) {
+	absPath, err := filepath.Abs(sourceDir)
+	if err != nil {
+		return "", err
+	}
+	if info, err := os.Stat(absPath); err == nil && !info.IsDir() {
+		absPath = filepath.Dir(absPath)
+	}
+	return hashSourceDir(absPath, exclude)
+}
+
+// resolveSettingsFile checks that a settings file exists and returns the
+// path to pass to KCL. Paths relative to sourceDir are passed unchanged,
+// since KCL runs there, keeping the command line independent of where the
+// configuration is checked out. With an empty sourceDir KCL runs in a
+// temporary directory, so relative paths are made absolute from the working
+// directory instead.
+func resolveSettingsFile(file, sourceDir string) (string, error) {
+	if sourceDir == "" {
+		absFile, err := filepath.Abs(file)
+		if err != nil {
+			return "", fmt.Errorf("invalid settings file path %s: %w", file, err)
+		}
+		file = absFile
+	}
+
+	full := file
+	if !filepath.IsAbs(file) {
+		full = filepath.Join(sourceDir, file)
+	}
+	if _, err := os.Stat(full); err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("settings file does not exist: %s", full)
+		}
+		return "", fmt.Errorf("reading settings file %s: %w", full, err)
+	}
+	return file, nil
+}
+
+// checkEntryFile verifies that an entry file exists, resolving a relative
+// path against dir. The path is passed to KCL as configured, so relative
+// paths keep the resource ID independent of where the sources are.
+func checkEntryFile(file, dir string) error {
+	full := file
+	if !filepath.IsAbs(file) {
+		full = filepath.Join(dir, file)
+	}
+	info, err := os.Stat(full)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("entry file does not exist: %s", full)
+		}
+		return fmt.Errorf("reading entry file %s: %w", full, err)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("entry file is a directory: %s", full)
+	}
+	return nil
+}
+
+// resolveExternalPackage returns the absolute path of an external package
+// directory, resolving relative paths against sourceDir or, when empty, the
+// working directory.
+func resolveExternalPackage(dir, sourceDir string) (string, error) {
+	if !filepath.IsAbs(dir) && sourceDir != "" {
+		dir = filepath.Join(sourceDir, dir)
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("invalid package path %s: %w", dir, err)
+	}
+
+	info, err := os.Stat(absDir)
+	if err != nil {
+		return "", fmt.Errorf("package directory does not exist: %s", absDir)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("package path is not a directory: %s", absDir)
+	}
+	return absDir, nil
+}
+
+// writeInlineCode stores code as inlineCodeFileName in a new temporary
+// directory and returns the directory, which the caller must remove.
+func writeInlineCode(code string) (string, error) {
+	dir, err := os.MkdirTemp("", "kclx-code-*")
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, inlineCodeFileName), []byte(code), 0o644); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	return dir, nil
 }
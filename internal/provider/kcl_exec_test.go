@@ -0,0 +1,789 @@
+// internal/provider/kcl_exec_test.go
+package provider
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// catMainKcl is a fake KCL that prints main.k of the directory it runs in.
+const catMainKcl = `case "$1" in
+version) echo "0.11.0" ;;
+*) cat main.k ;;
+esac`
+
+func TestKclExecResource_SourceChangeReruns(t *testing.T) {
+	h := newExecHarness(t, fakeKcl(t, catMainKcl))
+	dir := writeTestFiles(t, map[string]string{"main.k": "a = 1\n"})
+	config := map[string]attr.Value{"source_dir": types.StringValue(dir)}
+
+	first := h.mustApply(config)
+	if got := first.Stdout.ValueString(); got != "a = 1" {
+		t.Fatalf("stdout = %q, want the first version of main.k", got)
+	}
+
+	// Only the file changes, the configuration stays the same
+	if err := os.WriteFile(filepath.Join(dir, "main.k"), []byte("a = 2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	second := h.mustApply(config)
+	if got := second.Stdout.ValueString(); got != "a = 2" {
+		t.Errorf("stdout = %q, want the edited main.k", got)
+	}
+	if second.SourceHash.Equal(first.SourceHash) {
+		t.Errorf("source_hash did not change after editing main.k")
+	}
+	if !second.ID.Equal(first.ID) {
+		t.Errorf("id changed from %s to %s, but source_dir did not", first.ID, second.ID)
+	}
+
+	// Applying again without a change keeps everything
+	third := h.mustApply(config)
+	if !third.Stdout.Equal(second.Stdout) || !third.SourceHash.Equal(second.SourceHash) {
+		t.Errorf("unchanged sources planned a different result")
+	}
+}
+
+func TestKclExecResource_GitRefMoveReruns(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	repo := writeTestFiles(t, map[string]string{"main.k": "a = 1\n"})
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = repo
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q", "-b", "main")
+	git("add", "main.k")
+	git("commit", "-q", "-m", "first")
+
+	h := newExecHarness(t, fakeKcl(t, catMainKcl))
+	gitBlock := types.ObjectValueMust(
+		map[string]attr.Type{"url": types.StringType, "ref": types.StringType, "subdirectory": types.StringType},
+		map[string]attr.Value{
+			"url":          types.StringValue("file://" + filepath.ToSlash(repo)),
+			"ref":          types.StringValue("main"),
+			"subdirectory": types.StringNull(),
+		},
+	)
+	config := map[string]attr.Value{"git": gitBlock}
+
+	first := h.mustApply(config)
+	if got := first.Stdout.ValueString(); got != "a = 1" {
+		t.Fatalf("stdout = %q, want the first commit of main.k", got)
+	}
+
+	// The branch moves on, the configuration stays the same
+	if err := os.WriteFile(filepath.Join(repo, "main.k"), []byte("a = 2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	git("commit", "-q", "-a", "-m", "second")
+
+	second := h.mustApply(config)
+	if got := second.Stdout.ValueString(); got != "a = 2" {
+		t.Errorf("stdout = %q, want the second commit of main.k", got)
+	}
+	if second.GitCommit.Equal(first.GitCommit) {
+		t.Errorf("git_commit did not change after the branch moved")
+	}
+	if second.ID.Equal(first.ID) {
+		t.Errorf("id did not change after the branch moved")
+	}
+}
+
+func TestKclExecResource_MetadataOnlyChangeKeepsOutputs(t *testing.T) {
+	runs := filepath.Join(t.TempDir(), "runs")
+	h := newExecHarness(t, fakeKcl(t, `case "$1" in
+version) echo "0.11.0" ;;
+*) echo run >> "`+runs+`"; wc -l < "`+runs+`" ;;
+esac`))
+	dir := writeTestFiles(t, map[string]string{"main.k": "a = 1\n"})
+	labels := func(team string) attr.Value {
+		return types.MapValueMust(types.StringType, map[string]attr.Value{"team": types.StringValue(team)})
+	}
+
+	first := h.mustApply(map[string]attr.Value{"source_dir": types.StringValue(dir), "metadata": labels("a")})
+	second := h.mustApply(map[string]attr.Value{"source_dir": types.StringValue(dir), "metadata": labels("b")})
+	if !second.Stdout.Equal(first.Stdout) || !second.DurationMs.Equal(first.DurationMs) {
+		t.Errorf("changing metadata ran KCL again: stdout %s, then %s", first.Stdout, second.Stdout)
+	}
+	if !second.ID.Equal(first.ID) {
+		t.Errorf("changing metadata changed id from %s to %s", first.ID, second.ID)
+	}
+	if got := second.Metadata.Elements()["team"]; !got.Equal(types.StringValue("b")) {
+		t.Errorf("metadata.team = %s, want the new label", got)
+	}
+}
+
+func TestKclExecResource_InjectTFMetadata(t *testing.T) {
+	h := newExecHarness(t, fakeKcl(t, `case "$1" in
+version) echo "0.11.0" ;;
+*) env | grep '^`+metadataEnvPrefix+`' | sort ;;
+esac`))
+	dir := writeTestFiles(t, map[string]string{"main.k": "a = 1\n"})
+	config := func(team string) map[string]attr.Value {
+		return map[string]attr.Value{
+			"source_dir":         types.StringValue(dir),
+			"inject_tf_metadata": types.BoolValue(true),
+			"metadata": types.MapValueMust(types.StringType, map[string]attr.Value{
+				"team-name": types.StringValue(team),
+				"env":       types.StringValue("prod"),
+			}),
+		}
+	}
+
+	first := h.mustApply(config("core"))
+	if got, want := first.Stdout.ValueString(), "KCLX_META_ENV=prod\nKCLX_META_TEAM_NAME=core"; got != want {
+		t.Fatalf("stdout = %q, want %q", got, want)
+	}
+
+	// KCL sees the metadata, so changing it runs KCL again
+	second := h.mustApply(config("platform"))
+	if got, want := second.Stdout.ValueString(), "KCLX_META_ENV=prod\nKCLX_META_TEAM_NAME=platform"; got != want {
+		t.Errorf("stdout = %q, want %q", got, want)
+	}
+	if !second.ID.Equal(first.ID) {
+		t.Errorf("changing metadata changed id from %s to %s", first.ID, second.ID)
+	}
+}
+
+func TestMetadataEnvironment(t *testing.T) {
+	got := metadataEnvironment(map[string]string{
+		"team":        "core",
+		"cost-center": "42",
+		"a.b/c d":     "x=y",
+		"Already_Up9": "v",
+	})
+	want := []string{
+		"KCLX_META_ALREADY_UP9=v",
+		"KCLX_META_A_B_C_D=x=y",
+		"KCLX_META_COST_CENTER=42",
+		"KCLX_META_TEAM=core",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("metadataEnvironment() = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("metadataEnvironment()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestKclExecResource_ResultCompactJSONIgnoresStderr(t *testing.T) {
+	h := newExecHarness(t, fakeKcl(t, `case "$1" in
+version) echo "0.11.0" ;;
+*) echo 'WARNING: deprecated attribute' >&2; printf '{\n  "b": 1,\n  "a": 2\n}\n' ;;
+esac`))
+	dir := writeTestFiles(t, map[string]string{"main.k": "a = 1\n"})
+
+	model := h.mustApply(map[string]attr.Value{"source_dir": types.StringValue(dir)})
+	if got, want := model.ResultCompactJSON.ValueString(), `{"a":2,"b":1}`; got != want {
+		t.Errorf("result_compact_json = %q, want %q", got, want)
+	}
+}
+
+func TestAccKclExecResource_Basic(t *testing.T) {
+	h := newExecHarness(t, testAccKclBinary(t))
+
+	code := h.mustApply(map[string]attr.Value{
+		"code":   types.StringValue("a = 1\nb = \"x\"\n"),
+		"format": types.StringValue("json"),
+	})
+	if got, want := code.ResultCompactJSON.ValueString(), `{"a":1,"b":"x"}`; got != want {
+		t.Errorf("result_compact_json = %q, want %q", got, want)
+	}
+	if got := code.ExitCode.ValueInt64(); got != 0 {
+		t.Errorf("exit_code = %d, want 0", got)
+	}
+
+	h = newExecHarness(t, testAccKclBinary(t))
+	dir := writeTestFiles(t, map[string]string{"main.k": "name = \"app\"\nreplicas = 2\n"})
+	config := map[string]attr.Value{
+		"source_dir": types.StringValue(dir),
+		"arguments":  types.MapValueMust(types.StringType, map[string]attr.Value{"env": types.StringValue("prod")}),
+	}
+	first := h.mustApply(config)
+	if got, want := first.Stdout.ValueString(), "name: app\nreplicas: 2"; got != want {
+		t.Errorf("stdout = %q, want %q", got, want)
+	}
+	if first.ID.IsNull() || first.ID.IsUnknown() || first.SourceHash.ValueString() == "" {
+		t.Errorf("id = %s, source_hash = %s, want both set", first.ID, first.SourceHash)
+	}
+
+	// Applying again without a change keeps the state
+	second := h.mustApply(config)
+	if !second.ID.Equal(first.ID) || !second.Stdout.Equal(first.Stdout) {
+		t.Errorf("unchanged configuration planned a different result")
+	}
+}
+
+func TestKclExecResource_MaxStateOutputBytes(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{"main.k": "0123456789\n"})
+	config := func(limit int64) map[string]attr.Value {
+		return map[string]attr.Value{
+			"source_dir":             types.StringValue(dir),
+			"max_state_output_bytes": types.Int64Value(limit),
+		}
+	}
+
+	within := newExecHarness(t, fakeKcl(t, catMainKcl)).mustApply(config(10))
+	if got := within.Output.ValueString(); got != "0123456789" {
+		t.Errorf("output = %q, want main.k", got)
+	}
+
+	_, diags := newExecHarness(t, fakeKcl(t, catMainKcl)).apply(config(9))
+	if !diags.HasError() {
+		t.Fatal("output over max_state_output_bytes did not fail the run")
+	}
+	detail := diags.Errors()[0].Detail()
+	for _, want := range []string{"10 bytes", "limit of 9 bytes", "output_file"} {
+		if !strings.Contains(detail, want) {
+			t.Errorf("diagnostic %q does not mention %q", detail, want)
+		}
+	}
+}
+
+func TestKclExecResource_QuietAndDebugFlags(t *testing.T) {
+	h := newExecHarness(t, fakeKcl(t, catMainKcl))
+	dir := writeTestFiles(t, map[string]string{"main.k": "a = 1\n"})
+
+	model := h.mustApply(map[string]attr.Value{
+		"source_dir": types.StringValue(dir),
+		"quiet":      types.BoolValue(true),
+		"debug":      types.BoolValue(true),
+	})
+	var commandLine []string
+	if diags := model.CommandLine.ElementsAs(context.Background(), &commandLine, false); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if got := commandLine[len(commandLine)-2:]; got[0] != "--debug" || got[1] != "-q" {
+		t.Errorf("command_line = %q, want it to end with --debug -q", commandLine)
+	}
+}
+
+func TestKclExecResource_SuccessMarker(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{"main.k": "status = \"DONE in 3s\"\n"})
+	tests := []struct {
+		name    string
+		marker  attr.Value
+		wantErr bool
+	}{
+		{"unset", types.StringNull(), false},
+		{"literal present", types.StringValue("DONE"), false},
+		{"regex present", types.StringValue(`DONE in \d+s`), false},
+		{"absent", types.StringValue("SUCCESS"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newExecHarness(t, fakeKcl(t, catMainKcl))
+			_, diags := h.apply(map[string]attr.Value{
+				"source_dir":     types.StringValue(dir),
+				"success_marker": tt.marker,
+			})
+			if diags.HasError() != tt.wantErr {
+				t.Fatalf("apply diagnostics = %v, want error: %t", diags, tt.wantErr)
+			}
+			if tt.wantErr && diags.Errors()[0].Summary() != "KCL Success Marker Not Found" {
+				t.Errorf("error = %q, want the success marker error", diags.Errors()[0].Summary())
+			}
+		})
+	}
+}
+
+func TestOutputHasMarker(t *testing.T) {
+	tests := []struct {
+		output, marker string
+		want           bool
+	}{
+		{"all good: OK", "OK", true},
+		{"a+b", "a+b", true},
+		{"done 42", `done \d+`, true},
+		{"failed", "OK", false},
+		{"text", "[", false},
+	}
+	for _, tt := range tests {
+		if got := outputHasMarker(tt.output, tt.marker); got != tt.want {
+			t.Errorf("outputHasMarker(%q, %q) = %t, want %t", tt.output, tt.marker, got, tt.want)
+		}
+	}
+}
+
+func TestKclExecResource_InputFromChaining(t *testing.T) {
+	// Prints main.k, or the input_from file with a prefix when there is one
+	kcl := fakeKcl(t, `[ "$1" = version ] && { echo "0.11.0"; exit; }
+for arg; do
+	case "$arg" in
+	`+inputFromOption+`=*) printf 'input: '; cat "${arg#*=}"; exit ;;
+	esac
+done
+cat main.k`)
+	producerDir := writeTestFiles(t, map[string]string{"main.k": "a = 1\n"})
+	consumerDir := writeTestFiles(t, map[string]string{"main.k": "unused\n"})
+
+	producer := newExecHarness(t, kcl)
+	produced := producer.mustApply(map[string]attr.Value{"source_dir": types.StringValue(producerDir)})
+
+	consumer := newExecHarness(t, kcl)
+	consumerConfig := func(input attr.Value) map[string]attr.Value {
+		return map[string]attr.Value{"source_dir": types.StringValue(consumerDir), "input_from": input}
+	}
+	first := consumer.mustApply(consumerConfig(produced.Output))
+	if got, want := first.Output.ValueString(), "input: a = 1"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+
+	// A new upstream output is a new execution
+	if err := os.WriteFile(filepath.Join(producerDir, "main.k"), []byte("a = 2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	produced = producer.mustApply(map[string]attr.Value{"source_dir": types.StringValue(producerDir)})
+	second := consumer.mustApply(consumerConfig(produced.Output))
+	if got, want := second.Output.ValueString(), "input: a = 2"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+	if second.ID.Equal(first.ID) {
+		t.Errorf("id did not change with input_from")
+	}
+}
+
+func TestKclExecResource_TriggersRerun(t *testing.T) {
+	runs := filepath.Join(t.TempDir(), "runs")
+	h := newExecHarness(t, fakeKcl(t, `case "$1" in
+version) echo "0.11.0" ;;
+*) echo run >> "`+runs+`"; printf 'run %s\n' $(wc -l < "`+runs+`") ;;
+esac`))
+	dir := writeTestFiles(t, map[string]string{"main.k": "a = 1\n"})
+	config := func(release string) map[string]attr.Value {
+		return map[string]attr.Value{
+			"source_dir": types.StringValue(dir),
+			"triggers":   types.MapValueMust(types.StringType, map[string]attr.Value{"release": types.StringValue(release)}),
+		}
+	}
+
+	first := h.mustApply(config("v1"))
+	unchanged := h.mustApply(config("v1"))
+	if !unchanged.Output.Equal(first.Output) {
+		t.Errorf("an unchanged trigger ran KCL again: output %s, then %s", first.Output, unchanged.Output)
+	}
+
+	second := h.mustApply(config("v2"))
+	if got := second.Output.ValueString(); got != "run 2" {
+		t.Errorf("output = %q, want a second run after the trigger changed", got)
+	}
+	if second.ID.Equal(first.ID) {
+		t.Errorf("id did not change with the trigger")
+	}
+}
+
+func TestKclExecResource_InheritEnvironment(t *testing.T) {
+	t.Setenv("KCLX_TEST_HOST", "host")
+	kcl := fakeKcl(t, `case "$1" in
+version) echo "0.11.0" ;;
+*) echo "host=${KCLX_TEST_HOST-unset} declared=${KCLX_TEST_DECLARED-unset}" ;;
+esac`)
+	dir := writeTestFiles(t, map[string]string{"main.k": "a = 1\n"})
+	tests := []struct {
+		inherit attr.Value
+		want    string
+	}{
+		{types.BoolNull(), "host=host declared=set"},
+		{types.BoolValue(true), "host=host declared=set"},
+		{types.BoolValue(false), "host=unset declared=set"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.inherit.String(), func(t *testing.T) {
+			model := newExecHarness(t, kcl).mustApply(map[string]attr.Value{
+				"source_dir":          types.StringValue(dir),
+				"inherit_environment": tt.inherit,
+				"environment":         types.MapValueMust(types.StringType, map[string]attr.Value{"KCLX_TEST_DECLARED": types.StringValue("set")}),
+			})
+			if got := model.Stdout.ValueString(); got != tt.want {
+				t.Errorf("stdout = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKclExecResource_SourceDirFileOrDirectory(t *testing.T) {
+	kcl := fakeKcl(t, `case "$1" in
+version) echo "0.11.0" ;;
+*) pwd -P; for arg; do echo "$arg"; done ;;
+esac`)
+	dir := writeTestFiles(t, map[string]string{"main.k": "a = 1\n", "other.k": "b = 1\n", "notes.txt": "x\n"})
+	realDir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		source string
+		// wantLast is the last argument KCL gets, wantErr the error summary
+		wantLast string
+		wantErr  string
+	}{
+		{"directory", dir, "run", ""},
+		{"file", filepath.Join(dir, "other.k"), "other.k", ""},
+		{"file of another type", filepath.Join(dir, "notes.txt"), "", "Invalid Source"},
+		{"missing", filepath.Join(dir, "missing"), "", "Directory Not Found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model, diags := newExecHarness(t, kcl).apply(map[string]attr.Value{"source_dir": types.StringValue(tt.source)})
+			if tt.wantErr != "" {
+				if !diags.HasError() || diags.Errors()[0].Summary() != tt.wantErr {
+					t.Fatalf("apply diagnostics = %v, want %q", diags, tt.wantErr)
+				}
+				return
+			}
+			if diags.HasError() {
+				t.Fatalf("apply: %v", diags)
+			}
+			lines := strings.Split(model.Stdout.ValueString(), "\n")
+			if lines[0] != realDir {
+				t.Errorf("KCL ran in %s, want %s", lines[0], realDir)
+			}
+			if last := lines[len(lines)-1]; last != tt.wantLast {
+				t.Errorf("last argument = %q, want %q", last, tt.wantLast)
+			}
+		})
+	}
+}
+
+// entryKcl is a fake KCL that prints the single entry file it is given and
+// fails for entries named bad*.k.
+const entryKcl = `case "$1" in
+version) echo "0.11.0" ;;
+*) case "$2" in
+	bad*) echo "error[E2L23]: CompileError in $2" >&2; exit 1 ;;
+	esac
+	cat "$2" ;;
+esac`
+
+func TestKclExecResource_IndependentEntries(t *testing.T) {
+	h := newExecHarness(t, fakeKcl(t, entryKcl))
+	dir := writeTestFiles(t, map[string]string{"a.k": "a: 1\n", "b.k": "b:\n  - x\n"})
+
+	model := h.mustApply(map[string]attr.Value{
+		"source_dir":          types.StringValue(dir),
+		"entry_files":         types.ListValueMust(types.StringType, []attr.Value{types.StringValue("a.k"), types.StringValue("b.k")}),
+		"independent_entries": types.BoolValue(true),
+		"format":              types.StringValue("yaml"),
+	})
+	var results map[string]string
+	if diags := model.EntryResults.ElementsAs(context.Background(), &results, false); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if len(results) != 2 || results["a.k"] != `{"a":1}` || results["b.k"] != `{"b":["x"]}` {
+		t.Errorf("entry_results = %q, want each entry's result as JSON", results)
+	}
+	if got := model.Stdout.ValueString(); got != "a: 1\n---\nb:\n  - x" {
+		t.Errorf("stdout = %q, want both outputs as separate documents", got)
+	}
+	if got := model.DocumentCount.ValueInt64(); got != 2 {
+		t.Errorf("document_count = %d, want 2", got)
+	}
+	if got := model.Result.String(); !strings.Contains(got, `"a.k"`) || !strings.Contains(got, `"b.k"`) {
+		t.Errorf("result = %s, want an object keyed by entry", got)
+	}
+}
+
+func TestKclExecResource_IndependentEntriesFailures(t *testing.T) {
+	h := newExecHarness(t, fakeKcl(t, entryKcl))
+	dir := writeTestFiles(t, map[string]string{"bad1.k": "", "a.k": "a: 1\n", "bad2.k": ""})
+
+	_, diags := h.apply(map[string]attr.Value{
+		"source_dir":          types.StringValue(dir),
+		"entry_files":         types.ListValueMust(types.StringType, []attr.Value{types.StringValue("bad1.k"), types.StringValue("a.k"), types.StringValue("bad2.k")}),
+		"independent_entries": types.BoolValue(true),
+	})
+	errs := diags.Errors()
+	if len(errs) != 2 {
+		t.Fatalf("apply diagnostics = %v, want one error per failed entry", diags)
+	}
+	for i, entry := range []string{"bad1.k", "bad2.k"} {
+		if errs[i].Summary() != "KCL Entry Failed" || !strings.Contains(errs[i].Detail(), "Entry: "+entry+"\n") ||
+			!strings.Contains(errs[i].Detail(), "CompileError in "+entry) {
+			t.Errorf("error %d = %s: %s, want the failure of %s", i, errs[i].Summary(), errs[i].Detail(), entry)
+		}
+	}
+}
+
+func TestKclExecResource_IndependentEntriesValidation(t *testing.T) {
+	h := newExecHarness(t, fakeKcl(t, entryKcl))
+	_, diags := h.apply(map[string]attr.Value{
+		"code":                types.StringValue("a = 1\n"),
+		"independent_entries": types.BoolValue(true),
+	})
+	if !diags.HasError() || diags.Errors()[0].Summary() != "Missing Entry Files" {
+		t.Fatalf("apply diagnostics = %v, want a missing entry files error", diags)
+	}
+}
+
+func TestKclExecResource_MergedEntriesHaveNoEntryResults(t *testing.T) {
+	h := newExecHarness(t, fakeKcl(t, entryKcl))
+	dir := writeTestFiles(t, map[string]string{"a.k": "a: 1\n"})
+
+	model := h.mustApply(map[string]attr.Value{
+		"source_dir":  types.StringValue(dir),
+		"entry_files": types.ListValueMust(types.StringType, []attr.Value{types.StringValue("a.k")}),
+	})
+	if !model.EntryResults.IsNull() {
+		t.Errorf("entry_results = %s, want null without independent_entries", model.EntryResults)
+	}
+}
+
+// countingEntryKcl is entryKcl recording every entry it runs in the
+// returned file.
+func countingEntryKcl(t *testing.T) (string, string) {
+	t.Helper()
+	runs := filepath.Join(t.TempDir(), "runs")
+	return fakeKcl(t, `[ "$1" = version ] && { echo "0.11.0"; exit; }
+echo "$2" >> "`+runs+`"
+cat "$2"`), runs
+}
+
+// takeRuns returns the entries recorded in runs and empties it.
+func takeRuns(t *testing.T, runs string) []string {
+	t.Helper()
+	content, err := os.ReadFile(runs)
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	os.Remove(runs)
+	return strings.Fields(string(content))
+}
+
+func TestKclExecResource_Incremental(t *testing.T) {
+	kcl, runs := countingEntryKcl(t)
+	dir := writeTestFiles(t, map[string]string{"a.k": "a: 1\n", "b.k": "b: 1\n", "lib/lib.k": "x = 1\n"})
+	tests := []struct {
+		name string
+		// fullOnDependencyChange is full_on_dependency_change, null when unset
+		fullOnDependencyChange attr.Value
+		// wantAfterLib are the entries run again after lib.k changed
+		wantAfterLib []string
+	}{
+		{"full run on dependency change", types.BoolNull(), []string{"a.k", "b.k"}},
+		{"entries only", types.BoolValue(false), []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeFile := func(name, content string) {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			writeFile("b.k", "b: 1\n")
+			writeFile("lib/lib.k", "x = 1\n")
+			takeRuns(t, runs)
+
+			h := newExecHarness(t, kcl)
+			config := map[string]attr.Value{
+				"source_dir":                types.StringValue(dir),
+				"entry_files":               types.ListValueMust(types.StringType, []attr.Value{types.StringValue("a.k"), types.StringValue("b.k")}),
+				"independent_entries":       types.BoolValue(true),
+				"incremental":               types.BoolValue(true),
+				"full_on_dependency_change": tt.fullOnDependencyChange,
+			}
+			h.mustApply(config)
+			if got := takeRuns(t, runs); !reflect.DeepEqual(got, []string{"a.k", "b.k"}) {
+				t.Fatalf("first apply ran %q, want every entry", got)
+			}
+
+			writeFile("b.k", "b: 2\n")
+			model := h.mustApply(config)
+			if got := takeRuns(t, runs); !reflect.DeepEqual(got, []string{"b.k"}) {
+				t.Errorf("apply after editing b.k ran %q, want only b.k", got)
+			}
+			var results map[string]string
+			model.EntryResults.ElementsAs(context.Background(), &results, false)
+			if results["a.k"] != `{"a":1}` || results["b.k"] != `{"b":2}` {
+				t.Errorf("entry_results = %q, want the reused a.k and the new b.k", results)
+			}
+			if got := model.Stdout.ValueString(); got != "a: 1\n---\nb: 2" {
+				t.Errorf("stdout = %q, want both outputs", got)
+			}
+
+			writeFile("lib/lib.k", "x = 2\n")
+			h.mustApply(config)
+			if got := takeRuns(t, runs); !reflect.DeepEqual(got, tt.wantAfterLib) {
+				t.Errorf("apply after editing lib.k ran %q, want %q", got, tt.wantAfterLib)
+			}
+		})
+	}
+}
+
+func TestKclExecResource_IncrementalValidation(t *testing.T) {
+	h := newExecHarness(t, fakeKcl(t, entryKcl))
+	_, diags := h.apply(map[string]attr.Value{
+		"code":        types.StringValue("a = 1\n"),
+		"incremental": types.BoolValue(true),
+	})
+	if !diags.HasError() || diags.Errors()[0].Summary() != "Invalid Incremental Evaluation" {
+		t.Fatalf("apply diagnostics = %v, want an incremental without independent_entries error", diags)
+	}
+}
+
+// manifestsKcl is a fake KCL printing two Kubernetes manifests.
+const manifestsKcl = `[ "$1" = version ] && { echo "0.11.0"; exit; }
+cat <<'EOF'
+kind: Deployment
+metadata:
+  name: web
+---
+kind: Service
+metadata:
+  name: web
+EOF`
+
+func TestKclExecResource_OutputDir(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out")
+	kcl := fakeKcl(t, manifestsKcl)
+	h := newResourceHarness(t, &KclExecResource{provider: &kclProvider{KclPath: kcl, KclBinary: kcl}})
+	h.mustApply(map[string]attr.Value{
+		"source_dir":                    types.StringValue(writeTestFiles(t, map[string]string{"main.k": ""})),
+		"output_dir":                    types.StringValue(out),
+		"filename_template":             types.StringValue("{{ .Index }}-{{ .Kind }}.yaml"),
+		"delete_output_file_on_destroy": types.BoolValue(true),
+	})
+	var model KclExecResourceModel
+	h.model(&model)
+	var files []string
+	model.OutputDirFiles.ElementsAs(h.ctx, &files, false)
+	if !reflect.DeepEqual(files, []string{"0-Deployment.yaml", "1-Service.yaml"}) {
+		t.Errorf("output_dir_files = %q", files)
+	}
+	if got := readTestFile(t, filepath.Join(out, "1-Service.yaml")); got != "kind: Service\nmetadata:\n  name: web\n" {
+		t.Errorf("1-Service.yaml = %q", got)
+	}
+
+	if diags := h.destroy(); diags.HasError() {
+		t.Fatalf("destroy: %v", diags)
+	}
+	for _, file := range files {
+		if _, err := os.Stat(filepath.Join(out, file)); !os.IsNotExist(err) {
+			t.Errorf("%s was not removed: %v", file, err)
+		}
+	}
+}
+
+func TestKclExecResource_OutputDirFileNameErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		summary  string
+		detail   string
+	}{
+		{"collision", "{{ .Name }}.yaml", "Invalid Document File Name", `documents 0 and 1 are both named "web.yaml"`},
+		{"render failure", "{{ .Document.metadata.namespace }}.yaml", "Invalid Document File Name", "document 0: "},
+		{"outside the directory", "../{{ .Kind }}.yaml", "Invalid Document File Name", "not a relative path"},
+		{"parse failure", "{{ .Kind ", "Invalid Filename Template", "unclosed action"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := filepath.Join(t.TempDir(), "out")
+			h := newExecHarness(t, fakeKcl(t, manifestsKcl))
+			_, diags := h.apply(map[string]attr.Value{
+				"source_dir":        types.StringValue(writeTestFiles(t, map[string]string{"main.k": ""})),
+				"output_dir":        types.StringValue(out),
+				"filename_template": types.StringValue(tt.template),
+			})
+			if !diags.HasError() || diags.Errors()[0].Summary() != tt.summary || !strings.Contains(diags.Errors()[0].Detail(), tt.detail) {
+				t.Fatalf("apply diagnostics = %v, want %s: %s", diags, tt.summary, tt.detail)
+			}
+			if _, err := os.Stat(out); !os.IsNotExist(err) {
+				t.Errorf("files were written despite the error: %v", err)
+			}
+		})
+	}
+}
+
+func TestKclExecResource_FilenameTemplateWithoutOutputDir(t *testing.T) {
+	h := newExecHarness(t, fakeKcl(t, manifestsKcl))
+	_, diags := h.apply(map[string]attr.Value{
+		"source_dir":        types.StringValue(writeTestFiles(t, map[string]string{"main.k": ""})),
+		"filename_template": types.StringValue("{{ .Name }}.yaml"),
+	})
+	if !diags.HasError() || diags.Errors()[0].Summary() != "Unused Attribute" {
+		t.Fatalf("apply diagnostics = %v, want an unused attribute error", diags)
+	}
+}
+
+// tomlKcl returns a fake KCL reporting version, which records its arguments
+// in args and prints main.k as it is, whatever the format.
+func tomlKcl(t *testing.T, version, args string) string {
+	return fakeKcl(t, `[ "$1" = version ] && { echo "`+version+`"; exit; }
+echo "$*" > "`+args+`"
+cat main.k`)
+}
+
+func TestKclExecResource_OutputTOML(t *testing.T) {
+	tests := []struct {
+		name       string
+		version    string
+		main       string
+		wantFormat string
+	}{
+		{"native", "0.11.0", "name = \"app\"\nreplicas = 2\n", "--format toml"},
+		{"converted", "0.7.5", `{"name": "app", "replicas": 2}`, "--format json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := filepath.Join(t.TempDir(), "args")
+			h := newExecHarness(t, tomlKcl(t, tt.version, args))
+			model := h.mustApply(map[string]attr.Value{
+				"source_dir": types.StringValue(writeTestFiles(t, map[string]string{"main.k": tt.main})),
+				"format":     types.StringValue("toml"),
+			})
+			if got := model.OutputToml.ValueString(); got != "name = \"app\"\nreplicas = 2" {
+				t.Errorf("output_toml = %q", got)
+			}
+			if got := model.Stdout.ValueString(); got != strings.TrimSpace(tt.main) {
+				t.Errorf("stdout = %q, want what KCL printed", got)
+			}
+			if got := model.Result.String(); !strings.Contains(got, `"name":"app"`) {
+				t.Errorf("result = %s, want the parsed document", got)
+			}
+			if got := readTestFile(t, args); !strings.Contains(got, tt.wantFormat) {
+				t.Errorf("KCL was run as %q, want %s", got, tt.wantFormat)
+			}
+		})
+	}
+}
+
+func TestKclExecResource_OutputTOMLIncompatibleShape(t *testing.T) {
+	h := newExecHarness(t, tomlKcl(t, "0.7.5", filepath.Join(t.TempDir(), "args")))
+	_, diags := h.apply(map[string]attr.Value{
+		"source_dir": types.StringValue(writeTestFiles(t, map[string]string{"main.k": `[{"name": "app"}]`})),
+		"format":     types.StringValue("toml"),
+	})
+	if !diags.HasError() || diags.Errors()[0].Summary() != "Incompatible TOML Output" ||
+		!strings.Contains(diags.Errors()[0].Detail(), "the output is a list") {
+		t.Fatalf("apply diagnostics = %v, want an incompatible TOML output error", diags)
+	}
+}
+
+func TestKclExecResource_OutputTOMLOtherFormats(t *testing.T) {
+	h := newExecHarness(t, tomlKcl(t, "0.11.0", filepath.Join(t.TempDir(), "args")))
+	model := h.mustApply(map[string]attr.Value{
+		"source_dir": types.StringValue(writeTestFiles(t, map[string]string{"main.k": `{"a": 1}`})),
+		"format":     types.StringValue("json"),
+	})
+	if !model.OutputToml.IsNull() {
+		t.Errorf("output_toml = %s, want null for format json", model.OutputToml)
+	}
+}
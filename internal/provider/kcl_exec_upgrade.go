@@ -0,0 +1,71 @@
+// internal/provider/kcl_exec_upgrade.go
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// kclExecSchemaVersion is the version of the kcl_exec schema. Version 0 is
+// every state written before versioning, from v1.0.0 on.
+const kclExecSchemaVersion = 1
+
+func (r *KclExecResource) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return map[int64]resource.StateUpgrader{
+		0: {StateUpgrader: r.upgradeStateV0},
+	}
+}
+
+// upgradeStateV0 converts unversioned state. It was written by several
+// releases with differing attribute sets, so no single prior schema fits:
+// the raw state is decoded against the current schema instead, attributes
+// it lacks becoming null and attributes since removed being dropped. The
+// computed attributes later releases added are then derived from what the
+// old state recorded, so upgrading does not show them as changed.
+func (r *KclExecResource) upgradeStateV0(ctx context.Context, req resource.UpgradeStateRequest, resp *resource.UpgradeStateResponse) {
+	var schemaResp resource.SchemaResponse
+	r.Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+	current := schemaResp.Schema
+
+	raw, err := req.RawState.UnmarshalWithOpts(current.Type().TerraformType(ctx), tfprotov6.UnmarshalOpts{
+		ValueFromJSONOpts: tftypes.ValueFromJSONOpts{IgnoreUndefinedAttributes: true},
+	})
+	if err != nil {
+		resp.Diagnostics.AddError("State Upgrade Failed", "Unable to read the kcl_exec state: "+err.Error())
+		return
+	}
+
+	var state KclExecResourceModel
+	resp.Diagnostics.Append(tfsdk.State{Schema: current, Raw: raw}.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Old releases only recorded the combined output, and only of runs that
+	// exited with 0
+	if state.Stdout.IsNull() && !state.Output.IsNull() && !state.SensitiveOutput.ValueBool() {
+		state.Stdout = state.Output
+	}
+	if state.Stderr.IsNull() && !state.Stdout.IsNull() {
+		state.Stderr = types.StringValue("")
+	}
+	if state.ExitCode.IsNull() && !state.Output.IsNull() {
+		state.ExitCode = types.Int64Value(0)
+	}
+	if state.DurationMs.IsNull() && !state.Output.IsNull() {
+		state.DurationMs = types.Int64Value(0)
+	}
+	if state.OutputSHA256.IsNull() && !state.Stdout.IsNull() {
+		sum := sha256.Sum256([]byte(state.Stdout.ValueString()))
+		state.OutputSHA256 = types.StringValue(hex.EncodeToString(sum[:]))
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
+}
@@ -0,0 +1,320 @@
+// internal/provider/kcl_fmt.go
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// kclSourceExt is the extension of KCL source files.
+const kclSourceExt = ".k"
+
+// Ensure provider defined types fully satisfy framework interfaces
+var (
+	_ resource.Resource              = &KclFmtResource{}
+	_ resource.ResourceWithConfigure = &KclFmtResource{}
+)
+
+func NewKclFmtResource() resource.Resource {
+	return &KclFmtResource{}
+}
+
+type KclFmtResource struct {
+	provider *kclProvider
+}
+
+type KclFmtResourceModel struct {
+	ID           types.String `tfsdk:"id"`
+	SourceDir    types.String `tfsdk:"source_dir"`
+	Timeout      types.Int64  `tfsdk:"timeout"`
+	ChangedFiles types.List   `tfsdk:"changed_files"`
+}
+
+func (r *KclFmtResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_fmt"
+}
+
+func (r *KclFmtResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Formats the KCL files in a directory with `kcl fmt`. On refresh the directory is checked against " +
+			"a formatted copy; when any file is not formatted the resource is planned for creation again, so the next apply reformats it",
+
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Absolute path of the formatted directory",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"source_dir": schema.StringAttribute{
+				Required:            true,
+				MarkdownDescription: "Path to directory containing KCL files, formatted recursively",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"timeout": schema.Int64Attribute{
+				Optional:            true,
+				MarkdownDescription: "Formatting timeout in seconds (default: 300)",
+			},
+			"changed_files": schema.ListAttribute{
+				ElementType:         types.StringType,
+				Computed:            true,
+				MarkdownDescription: "Paths, relative to `source_dir`, of the files reformatted by the last apply",
+			},
+		},
+	}
+}
+
+func (r *KclFmtResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	provider, ok := req.ProviderData.(*kclProvider)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Provider Data Type",
+			fmt.Sprintf("Expected *kclProvider, got: %T", req.ProviderData),
+		)
+		return
+	}
+
+	r.provider = provider
+}
+
+func (r *KclFmtResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan KclFmtResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(r.format(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *KclFmtResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state KclFmtResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	absPath := state.ID.ValueString()
+	if _, err := os.Stat(absPath); os.IsNotExist(err) {
+		tflog.Warn(ctx, "Formatted directory no longer exists, removing from state", map[string]interface{}{
+			"path": absPath,
+		})
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	kclCommand, err := r.provider.resolveKclCommand()
+	if err != nil {
+		resp.Diagnostics.AddError("KCL Executable Not Found", err.Error())
+		return
+	}
+
+	// Format a scratch copy so checking never touches the real files
+	scratch, err := os.MkdirTemp("", "kclx-fmt-*")
+	if err != nil {
+		resp.Diagnostics.AddError("KCL Format Check Failed", "Unable to create a temporary directory: "+err.Error())
+		return
+	}
+	defer os.RemoveAll(scratch)
+
+	if err := copyKclSources(absPath, scratch); err != nil {
+		resp.Diagnostics.AddError("KCL Format Check Failed", "Unable to copy KCL files: "+err.Error())
+		return
+	}
+
+	unformatted, output, err := formatKclDir(ctx, r.provider, kclCommand, scratch, fmtTimeout(state))
+	if err != nil {
+		resp.Diagnostics.AddError("KCL Format Check Failed", fmt.Sprintf("Error: %v\nOutput: %s", err, output))
+		return
+	}
+
+	if len(unformatted) > 0 {
+		tflog.Info(ctx, "KCL files are not formatted, planning to format again", map[string]interface{}{
+			"path":  absPath,
+			"files": unformatted,
+		})
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	diags = resp.State.Set(ctx, state)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *KclFmtResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan KclFmtResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(r.format(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *KclFmtResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	// Formatting is not undone
+}
+
+// format runs `kcl fmt` on the planned directory and fills in the computed
+// attributes of plan.
+func (r *KclFmtResource) format(ctx context.Context, plan *KclFmtResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	absPath, err := filepath.Abs(plan.SourceDir.ValueString())
+	if err != nil {
+		diags.AddError("Path Resolution Error", "Invalid source directory path: "+err.Error())
+		return diags
+	}
+	if _, err := os.Stat(absPath); os.IsNotExist(err) {
+		diags.AddError("Directory Not Found", "Source directory does not exist: "+absPath)
+		return diags
+	}
+
+	kclCommand, err := r.provider.resolveKclCommand()
+	if err != nil {
+		diags.AddError("KCL Executable Not Found", err.Error())
+		return diags
+	}
+
+	changed, output, err := formatKclDir(ctx, r.provider, kclCommand, absPath, fmtTimeout(*plan))
+	if err != nil {
+		diags.AddError("KCL Format Failed", fmt.Sprintf("Error: %v\nOutput: %s", err, output))
+		return diags
+	}
+
+	plan.ID = types.StringValue(absPath)
+	changedFiles, listDiags := types.ListValueFrom(ctx, types.StringType, changed)
+	diags.Append(listDiags...)
+	plan.ChangedFiles = changedFiles
+	return diags
+}
+
+func fmtTimeout(model KclFmtResourceModel) time.Duration {
+	if model.Timeout.IsNull() {
+		return 300 * time.Second
+	}
+	return time.Duration(model.Timeout.ValueInt64()) * time.Second
+}
+
+// formatKclDir runs `kcl fmt` recursively in dir and returns the paths,
+// relative to dir, of the files whose content changed, sorted.
+func formatKclDir(ctx context.Context, p *kclProvider, kclCommand, dir string, timeout time.Duration) ([]string, string, error) {
+	before, err := hashKclSources(dir)
+	if err != nil {
+		return nil, "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, effectiveTimeout(ctx, timeout))
+	defer cancel()
+
+	args := []string{"fmt", "./..."}
+	cmd := exec.CommandContext(ctx, kclCommand, args...)
+	cmd.Dir = dir
+	configureGracefulStop(cmd)
+
+	tflog.Info(ctx, "Formatting KCL files", map[string]interface{}{
+		"command":   kclCommand,
+		"arguments": args,
+		"directory": dir,
+	})
+
+	result, err := p.runKcl(ctx, cmd)
+	output := strings.TrimSpace(string(result.Combined))
+	if err != nil {
+		return nil, output, fmt.Errorf("%s %s: %w", kclCommand, strings.Join(args, " "), err)
+	}
+
+	after, err := hashKclSources(dir)
+	if err != nil {
+		return nil, output, err
+	}
+
+	changed := []string{}
+	for file, sum := range after {
+		if before[file] != sum {
+			changed = append(changed, file)
+		}
+	}
+	sort.Strings(changed)
+	return changed, output, nil
+}
+
+// walkKclSources calls fn with the slash-separated path, relative to root,
+// of every KCL file below root. Hidden directories and paths matched by a
+// .kclignore are skipped.
+func walkKclSources(root string, fn func(rel, abs string) error) error {
+	return walkSourceTree(root, []string{".*/"}, func(rel, abs string) error {
+		if path.Ext(rel) != kclSourceExt {
+			return nil
+		}
+		return fn(rel, abs)
+	})
+}
+
+func hashKclSources(root string) (map[string][sha256.Size]byte, error) {
+	sums := make(map[string][sha256.Size]byte)
+	err := walkKclSources(root, func(rel, abs string) error {
+		content, err := os.ReadFile(abs)
+		if err != nil {
+			return err
+		}
+		sums[rel] = sha256.Sum256(content)
+		return nil
+	})
+	return sums, err
+}
+
+// copyKclSources copies every KCL file below src into dst, keeping their
+// relative paths.
+func copyKclSources(src, dst string) error {
+	return walkKclSources(src, func(rel, abs string) error {
+		content, err := os.ReadFile(abs)
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dst, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		return os.WriteFile(target, content, 0o644)
+	})
+}
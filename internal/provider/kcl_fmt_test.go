@@ -0,0 +1,102 @@
+// internal/provider/kcl_fmt_test.go
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// fmtKcl is a fake KCL whose fmt puts spaces around the = of every
+// assignment written without them.
+const fmtKcl = `[ "$1" = fmt ] || exit 1
+find . -name '*.k' | while read -r f; do
+	sed 's/\([^ ]\)=\([^ ]\)/\1 = \2/' "$f" > "$f.tmp" && mv "$f.tmp" "$f"
+done`
+
+func newKclFmtHarness(t *testing.T, kclBinary string) *resourceHarness {
+	t.Helper()
+	return newResourceHarness(t, &KclFmtResource{provider: &kclProvider{KclPath: kclBinary, KclBinary: kclBinary}})
+}
+
+func TestKclFmtResource_Create(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"main.k":        "a=1\n",
+		"formatted.k":   "b = 2\n",
+		"pkg/nested.k":  "c=3\n",
+		"notes.txt":     "d=4\n",
+		".hidden/ign.k": "e = 5\n",
+	})
+	h := newKclFmtHarness(t, fakeKcl(t, fmtKcl))
+	h.mustApply(map[string]attr.Value{"source_dir": types.StringValue(dir)})
+
+	var model KclFmtResourceModel
+	h.model(&model)
+	var changed []string
+	model.ChangedFiles.ElementsAs(h.ctx, &changed, false)
+	if want := []string{"main.k", "pkg/nested.k"}; !reflect.DeepEqual(changed, want) {
+		t.Errorf("changed_files = %q, want %q", changed, want)
+	}
+	if model.ID.ValueString() != dir {
+		t.Errorf("id = %s, want %s", model.ID, dir)
+	}
+	if got := readTestFile(t, filepath.Join(dir, "pkg", "nested.k")); got != "c = 3\n" {
+		t.Errorf("pkg/nested.k = %q, want it formatted", got)
+	}
+}
+
+func TestKclFmtResource_ReadDetectsDrift(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{"main.k": "a=1\n"})
+	h := newKclFmtHarness(t, fakeKcl(t, fmtKcl))
+	h.mustApply(map[string]attr.Value{"source_dir": types.StringValue(dir)})
+
+	if !h.mustRead() {
+		t.Fatal("a formatted directory was removed from state")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "main.k"), []byte("a=2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if h.mustRead() {
+		t.Error("an unformatted file did not plan the resource for creation again")
+	}
+	if got := readTestFile(t, filepath.Join(dir, "main.k")); got != "a=2\n" {
+		t.Errorf("main.k = %q, the check changed the real file", got)
+	}
+}
+
+func TestKclFmtResource_ReadRemovedDirectory(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "src")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	h := newKclFmtHarness(t, fakeKcl(t, fmtKcl))
+	h.mustApply(map[string]attr.Value{"source_dir": types.StringValue(dir)})
+
+	if err := os.Remove(dir); err != nil {
+		t.Fatal(err)
+	}
+	if h.mustRead() {
+		t.Error("the resource is still in state after its directory was removed")
+	}
+}
+
+func TestKclFmtResource_Errors(t *testing.T) {
+	h := newKclFmtHarness(t, fakeKcl(t, fmtKcl))
+	diags := h.apply(map[string]attr.Value{"source_dir": types.StringValue(filepath.Join(t.TempDir(), "missing"))})
+	if !diags.HasError() || diags.Errors()[0].Summary() != "Directory Not Found" {
+		t.Errorf("apply diagnostics = %v, want a missing directory error", diags)
+	}
+
+	h = newKclFmtHarness(t, fakeKcl(t, `echo "main.k:1:2: invalid syntax" >&2; exit 1`))
+	diags = h.apply(map[string]attr.Value{"source_dir": types.StringValue(writeTestFiles(t, map[string]string{"main.k": "a=\n"}))})
+	if !diags.HasError() || diags.Errors()[0].Summary() != "KCL Format Failed" ||
+		!strings.Contains(diags.Errors()[0].Detail(), "invalid syntax") {
+		t.Errorf("apply diagnostics = %v, want a format error with KCL's output", diags)
+	}
+}
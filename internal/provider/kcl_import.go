@@ -0,0 +1,328 @@
+// internal/provider/kcl_import.go
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// validImportModes are the input formats kcl_import accepts.
+var validImportModes = []string{"json", "yaml", "auto"}
+
+// Ensure provider defined types fully satisfy framework interfaces
+var (
+	_ resource.Resource                   = &KclImportResource{}
+	_ resource.ResourceWithConfigure      = &KclImportResource{}
+	_ resource.ResourceWithValidateConfig = &KclImportResource{}
+)
+
+func NewKclImportResource() resource.Resource {
+	return &KclImportResource{}
+}
+
+type KclImportResource struct {
+	provider *kclProvider
+}
+
+type KclImportResourceModel struct {
+	ID                        types.String `tfsdk:"id"`
+	InputFile                 types.String `tfsdk:"input_file"`
+	Mode                      types.String `tfsdk:"mode"`
+	OutputFile                types.String `tfsdk:"output_file"`
+	DeleteOutputFileOnDestroy types.Bool   `tfsdk:"delete_output_file_on_destroy"`
+	Timeout                   types.Int64  `tfsdk:"timeout"`
+	Content                   types.String `tfsdk:"content"`
+	InputSHA256               types.String `tfsdk:"input_sha256"`
+}
+
+func (r *KclImportResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_import"
+}
+
+func (r *KclImportResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Converts a JSON or YAML file to KCL with `kcl import` and writes the generated code to `output_file`. " +
+			"On refresh the input and the generated file are checked; when either changed the resource is planned for creation again",
+
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Absolute path of the generated file",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"input_file": schema.StringAttribute{
+				Required:            true,
+				MarkdownDescription: "Path to the JSON or YAML file to convert",
+			},
+			"mode": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Format of `input_file`: `json`, `yaml` or `auto` to detect it from the file extension (default: `auto`)",
+			},
+			"output_file": schema.StringAttribute{
+				Required:            true,
+				MarkdownDescription: "Path the generated KCL is written to. Missing parent directories are created",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"delete_output_file_on_destroy": schema.BoolAttribute{
+				Optional:            true,
+				MarkdownDescription: "Remove `output_file` when the resource is destroyed (default: false)",
+			},
+			"timeout": schema.Int64Attribute{
+				Optional:            true,
+				MarkdownDescription: "Import timeout in seconds (default: 300)",
+			},
+			"content": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "The generated KCL code",
+			},
+			"input_sha256": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Hex SHA-256 of `input_file` at the time of the import",
+			},
+		},
+	}
+}
+
+func (r *KclImportResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	provider, ok := req.ProviderData.(*kclProvider)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Provider Data Type",
+			fmt.Sprintf("Expected *kclProvider, got: %T", req.ProviderData),
+		)
+		return
+	}
+
+	r.provider = provider
+}
+
+func (r *KclImportResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config KclImportResourceModel
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if config.Mode.IsNull() || config.Mode.IsUnknown() {
+		return
+	}
+	for _, v := range validImportModes {
+		if config.Mode.ValueString() == v {
+			return
+		}
+	}
+	resp.Diagnostics.AddAttributeError(path.Root("mode"), "Invalid Import Mode",
+		fmt.Sprintf("unknown mode %q, expected one of: %s", config.Mode.ValueString(), strings.Join(validImportModes, ", ")))
+}
+
+func (r *KclImportResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan KclImportResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(r.importFile(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *KclImportResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state KclImportResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	outputPath := state.ID.ValueString()
+	content, err := os.ReadFile(outputPath)
+	if errors.Is(err, os.ErrNotExist) || (err == nil && string(content) != state.Content.ValueString()) {
+		tflog.Info(ctx, "Generated KCL file is missing or was modified, planning to import again", map[string]interface{}{
+			"path": outputPath,
+		})
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Output File Read Error", err.Error())
+		return
+	}
+
+	inputHash, err := fileSHA256(state.InputFile.ValueString())
+	if err != nil || inputHash != state.InputSHA256.ValueString() {
+		tflog.Info(ctx, "Import input is missing or changed, planning to import again", map[string]interface{}{
+			"input_file": state.InputFile.ValueString(),
+		})
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	diags = resp.State.Set(ctx, state)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *KclImportResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan KclImportResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(r.importFile(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *KclImportResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state KclImportResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !state.DeleteOutputFileOnDestroy.ValueBool() {
+		return
+	}
+
+	if err := os.Remove(state.ID.ValueString()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		resp.Diagnostics.AddError("Output File Delete Error", err.Error())
+	}
+}
+
+// importFile runs `kcl import` for plan in a scratch directory, writes the
+// generated code to output_file and fills in the computed attributes.
+func (r *KclImportResource) importFile(ctx context.Context, plan *KclImportResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	inputPath, err := filepath.Abs(plan.InputFile.ValueString())
+	if err != nil {
+		diags.AddAttributeError(path.Root("input_file"), "Path Resolution Error", "Invalid input file path: "+err.Error())
+		return diags
+	}
+	inputHash, err := fileSHA256(inputPath)
+	if err != nil {
+		diags.AddAttributeError(path.Root("input_file"), "Input File Read Error", err.Error())
+		return diags
+	}
+
+	outputPath, err := filepath.Abs(plan.OutputFile.ValueString())
+	if err != nil {
+		diags.AddAttributeError(path.Root("output_file"), "Path Resolution Error", "Invalid output file path: "+err.Error())
+		return diags
+	}
+
+	kclCommand, err := r.provider.resolveKclCommand()
+	if err != nil {
+		diags.AddError("KCL Executable Not Found", err.Error())
+		return diags
+	}
+
+	// Generate into a scratch directory so a failed import leaves
+	// output_file untouched
+	scratch, err := os.MkdirTemp("", "kclx-import-*")
+	if err != nil {
+		diags.AddError("KCL Import Failed", "Unable to create a temporary directory: "+err.Error())
+		return diags
+	}
+	defer os.RemoveAll(scratch)
+
+	mode := "auto"
+	if !plan.Mode.IsNull() {
+		mode = plan.Mode.ValueString()
+	}
+	generated := filepath.Join(scratch, "generated"+kclSourceExt)
+	args := []string{"import", "--mode", mode, "--output", generated, "--force", inputPath}
+
+	timeout := 300 * time.Second
+	if !plan.Timeout.IsNull() {
+		timeout = time.Duration(plan.Timeout.ValueInt64()) * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, effectiveTimeout(ctx, timeout))
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, kclCommand, args...)
+	cmd.Dir = scratch
+	configureGracefulStop(cmd)
+
+	tflog.Info(ctx, "Importing file into KCL", map[string]interface{}{
+		"command":   kclCommand,
+		"arguments": args,
+		"timeout":   timeout,
+	})
+
+	result, err := r.provider.runKcl(ctx, cmd)
+	if err != nil {
+		diags.AddError(
+			"KCL Import Failed",
+			fmt.Sprintf("Command: %s %s\nError: %v\nOutput: %s",
+				kclCommand, strings.Join(args, " "), err, strings.TrimSpace(string(result.Combined))),
+		)
+		return diags
+	}
+
+	content, err := os.ReadFile(generated)
+	if err != nil {
+		diags.AddError("KCL Import Failed", "kcl import did not produce "+generated+": "+err.Error())
+		return diags
+	}
+
+	perm, _ := parseFilePermission(defaultOutputFilePermission)
+	if err := writeFileAtomic(outputPath, string(content), perm); err != nil {
+		diags.AddAttributeError(path.Root("output_file"), "Output File Write Error", err.Error())
+		return diags
+	}
+
+	plan.ID = types.StringValue(outputPath)
+	plan.Content = types.StringValue(string(content))
+	plan.InputSHA256 = types.StringValue(inputHash)
+	return diags
+}
+
+// fileSHA256 returns the hex SHA-256 of the file at path.
+func fileSHA256(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,120 @@
+// internal/provider/kcl_import_test.go
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// importKcl is a fake KCL whose import writes the mode and the commented
+// out input to the --output file.
+const importKcl = `[ "$1" = import ] || exit 1
+[ -s "$7" ] || { echo "empty input" >&2; exit 1; }
+{ echo "# mode $3"; sed 's/^/# /' "$7"; } > "$5"`
+
+func newKclImportHarness(t *testing.T, kclBinary string) *resourceHarness {
+	t.Helper()
+	return newResourceHarness(t, &KclImportResource{provider: &kclProvider{KclPath: kclBinary, KclBinary: kclBinary}})
+}
+
+func TestKclImportResource_Lifecycle(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{"data.json": "{\"a\": 1}\n"})
+	output := filepath.Join(dir, "out", "data.k")
+	h := newKclImportHarness(t, fakeKcl(t, importKcl))
+
+	h.mustApply(map[string]attr.Value{
+		"input_file":                    types.StringValue(filepath.Join(dir, "data.json")),
+		"mode":                          types.StringValue("json"),
+		"output_file":                   types.StringValue(output),
+		"delete_output_file_on_destroy": types.BoolValue(true),
+	})
+	want := "# mode json\n# {\"a\": 1}\n"
+	if got := readTestFile(t, output); got != want {
+		t.Errorf("output_file = %q, want %q", got, want)
+	}
+	var model KclImportResourceModel
+	h.model(&model)
+	if model.Content.ValueString() != want || model.ID.ValueString() != output || len(model.InputSHA256.ValueString()) != 64 {
+		t.Errorf("id, content, input_sha256 = %s, %s, %s", model.ID, model.Content, model.InputSHA256)
+	}
+
+	if diags := h.destroy(); diags.HasError() {
+		t.Fatalf("destroy: %v", diags)
+	}
+	if _, err := os.Stat(output); !os.IsNotExist(err) {
+		t.Errorf("output_file was not removed: %v", err)
+	}
+}
+
+func TestKclImportResource_ReadDetectsDrift(t *testing.T) {
+	tests := []struct {
+		name  string
+		drift func(input, output string) error
+	}{
+		{"unchanged", func(string, string) error { return nil }},
+		{"output edited", func(_, output string) error { return os.WriteFile(output, []byte("a = 2\n"), 0o644) }},
+		{"output removed", func(_, output string) error { return os.Remove(output) }},
+		{"input changed", func(input, _ string) error { return os.WriteFile(input, []byte("a: 2\n"), 0o644) }},
+		{"input removed", func(input, _ string) error { return os.Remove(input) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeTestFiles(t, map[string]string{"data.yaml": "a: 1\n"})
+			input, output := filepath.Join(dir, "data.yaml"), filepath.Join(dir, "data.k")
+			h := newKclImportHarness(t, fakeKcl(t, importKcl))
+			h.mustApply(map[string]attr.Value{
+				"input_file":  types.StringValue(input),
+				"output_file": types.StringValue(output),
+			})
+			if got := readTestFile(t, output); !strings.HasPrefix(got, "# mode auto\n") {
+				t.Errorf("output_file = %q, want the import to default to auto", got)
+			}
+
+			if err := tt.drift(input, output); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := h.mustRead(), tt.name == "unchanged"; got != want {
+				t.Errorf("resource kept in state = %t, want %t", got, want)
+			}
+		})
+	}
+}
+
+func TestKclImportResource_Errors(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{"empty.json": "", "data.k": "kept = True\n"})
+	h := newKclImportHarness(t, fakeKcl(t, importKcl))
+
+	diags := h.apply(map[string]attr.Value{
+		"input_file":  types.StringValue(filepath.Join(dir, "empty.json")),
+		"output_file": types.StringValue(filepath.Join(dir, "data.k")),
+	})
+	if !diags.HasError() || diags.Errors()[0].Summary() != "KCL Import Failed" ||
+		!strings.Contains(diags.Errors()[0].Detail(), "empty input") {
+		t.Errorf("apply diagnostics = %v, want an import error with KCL's output", diags)
+	}
+	if got := readTestFile(t, filepath.Join(dir, "data.k")); got != "kept = True\n" {
+		t.Errorf("a failed import changed output_file to %q", got)
+	}
+
+	diags = h.apply(map[string]attr.Value{
+		"input_file":  types.StringValue(filepath.Join(dir, "missing.json")),
+		"output_file": types.StringValue(filepath.Join(dir, "data.k")),
+	})
+	if !diags.HasError() || diags.Errors()[0].Summary() != "Input File Read Error" {
+		t.Errorf("apply diagnostics = %v, want an input file error", diags)
+	}
+
+	diags = h.apply(map[string]attr.Value{
+		"input_file":  types.StringValue(filepath.Join(dir, "empty.json")),
+		"mode":        types.StringValue("xml"),
+		"output_file": types.StringValue(filepath.Join(dir, "data.k")),
+	})
+	if !diags.HasError() || diags.Errors()[0].Summary() != "Invalid Import Mode" {
+		t.Errorf("apply diagnostics = %v, want an invalid mode error", diags)
+	}
+}
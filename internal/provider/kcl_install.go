@@ -0,0 +1,239 @@
+// internal/provider/kcl_install.go
+package provider
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// kclReleaseBaseURL is where KCL CLI releases are published, one directory
+// per "v"-prefixed version holding the archives and a checksums.txt.
+const kclReleaseBaseURL = "https://github.com/kcl-lang/cli/releases/download"
+
+// kclInstallTimeout bounds downloading and unpacking a KCL release.
+const kclInstallTimeout = 300 * time.Second
+
+// kclReleaseAsset returns the archive name of the KCL release for goos and
+// goarch, e.g. "kcl-v0.10.0-linux-amd64.tar.gz".
+func kclReleaseAsset(ver, goos, goarch string) string {
+	ext := ".tar.gz"
+	if goos == "windows" {
+		ext = ".zip"
+	}
+	return fmt.Sprintf("kcl-v%s-%s-%s%s", ver, goos, goarch, ext)
+}
+
+// defaultKclInstallDir returns the directory KCL releases are installed to:
+// below cache_dir when set, otherwise in the user cache directory.
+func (p *kclProvider) defaultKclInstallDir() (string, error) {
+	if p.CacheDir != "" {
+		return filepath.Join(p.CacheDir, "kcl"), nil
+	}
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cache, "terraform-provider-kclx", "kcl"), nil
+}
+
+// installKcl makes sure the KCL release ver for this platform is unpacked
+// below installDir and returns the path of its executable. A copy installed
+// by an earlier run is reused without network access. Downloads are
+// verified against the checksums published with the release.
+func installKcl(ctx context.Context, ver, installDir string) (string, error) {
+	parsed, err := version.NewVersion(ver)
+	if err != nil {
+		return "", fmt.Errorf("invalid KCL version %q: %w", ver, err)
+	}
+	ver = strings.TrimPrefix(parsed.Original(), "v")
+
+	name := "kcl"
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	dir := filepath.Join(installDir, ver, runtime.GOOS+"-"+runtime.GOARCH)
+	binary := filepath.Join(dir, name)
+	if info, err := os.Stat(binary); err == nil && info.Mode().IsRegular() {
+		return binary, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, kclInstallTimeout)
+	defer cancel()
+
+	asset := kclReleaseAsset(ver, runtime.GOOS, runtime.GOARCH)
+	releaseURL := kclReleaseBaseURL + "/v" + ver + "/"
+
+	tflog.Info(ctx, "Installing KCL", map[string]interface{}{
+		"version": ver,
+		"asset":   asset,
+		"path":    binary,
+	})
+
+	checksums, err := httpGet(ctx, releaseURL+"checksums.txt")
+	if err != nil {
+		return "", fmt.Errorf("downloading checksums of KCL %s: %w", ver, err)
+	}
+	want := releaseChecksum(string(checksums), asset)
+	if want == "" {
+		return "", fmt.Errorf("KCL %s publishes no checksum for %s, it may not support %s/%s",
+			ver, asset, runtime.GOOS, runtime.GOARCH)
+	}
+
+	archive, err := httpGet(ctx, releaseURL+asset)
+	if err != nil {
+		return "", fmt.Errorf("downloading %s: %w", asset, err)
+	}
+	sum := sha256.Sum256(archive)
+	if got := hex.EncodeToString(sum[:]); !strings.EqualFold(got, want) {
+		return "", fmt.Errorf("checksum mismatch for %s: expected %s, got %s", asset, want, got)
+	}
+
+	// Unpack next to the destination so the final rename cannot cross file
+	// systems, and concurrent installs never see a partial executable
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	scratch, err := os.MkdirTemp(dir, ".install-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(scratch)
+
+	if strings.HasSuffix(asset, ".zip") {
+		err = extractZip(archive, scratch)
+	} else {
+		err = extractTarGz(archive, scratch)
+	}
+	if err != nil {
+		return "", fmt.Errorf("unpacking %s: %w", asset, err)
+	}
+
+	unpacked, err := findFile(scratch, name)
+	if err != nil {
+		return "", fmt.Errorf("%s does not contain %s: %w", asset, name, err)
+	}
+	if err := os.Chmod(unpacked, 0o755); err != nil {
+		return "", err
+	}
+	if err := os.Rename(unpacked, binary); err != nil {
+		return "", err
+	}
+	return binary, nil
+}
+
+// httpGet returns the body of a successful GET of rawURL.
+func httpGet(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected HTTP status %s from %s", res.Status, rawURL)
+	}
+	return io.ReadAll(res.Body)
+}
+
+// releaseChecksum returns the hex SHA-256 listed for asset in a
+// sha256sum-style checksums file, or "" when it is not listed.
+func releaseChecksum(checksums, asset string) string {
+	for _, line := range strings.Split(checksums, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == asset {
+			return fields[0]
+		}
+	}
+	return ""
+}
+
+// extractZip unpacks a zip archive into dir, rejecting entries that would
+// escape it.
+func extractZip(data []byte, dir string) error {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return fmt.Errorf("opening archive: %w", err)
+	}
+
+	for _, f := range zr.File {
+		target := filepath.Join(dir, filepath.FromSlash(f.Name))
+		if target != dir && !strings.HasPrefix(target, dir+string(os.PathSeparator)) {
+			return fmt.Errorf("archive entry %q escapes the extraction directory", f.Name)
+		}
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		if err := extractZipFile(f, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractZipFile(f *zip.File, target string) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, rc); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// findFile returns the path of the first regular file called name below
+// root.
+func findFile(root, name string) (string, error) {
+	var found string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() && d.Name() == name {
+			found = p
+			return fs.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if found == "" {
+		return "", errors.New("file not found")
+	}
+	return found, nil
+}
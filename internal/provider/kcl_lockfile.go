@@ -0,0 +1,85 @@
+// internal/provider/kcl_lockfile.go
+package provider
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const kclModLockFileName = "kcl.mod.lock"
+
+// kclLockedDependency is a resolved dependency recorded in kcl.mod.lock.
+type kclLockedDependency struct {
+	Name    string
+	Version string
+	Sum     string
+}
+
+// readKclModLock parses the [dependencies.<name>] tables of the kcl.mod.lock
+// in dir. It returns nil without error when the package has no lock file.
+func readKclModLock(dir string) ([]kclLockedDependency, error) {
+	content, err := os.ReadFile(filepath.Join(dir, kclModLockFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	lock := parseKclMod(string(content))
+	deps := []kclLockedDependency{}
+	for _, table := range lock.tables("dependencies.") {
+		entries := lock.entries(table)
+
+		dep := kclLockedDependency{
+			Name:    strings.TrimPrefix(table, "dependencies."),
+			Version: tomlStringValue(entries["version"]).ValueString(),
+			Sum:     tomlStringValue(entries["sum"]).ValueString(),
+		}
+		if name := tomlStringValue(entries["name"]); !name.IsNull() {
+			dep.Name = name.ValueString()
+		}
+		deps = append(deps, dep)
+	}
+	return deps, nil
+}
+
+// readKclModLockContent returns the content of the kcl.mod.lock in dir,
+// null when the package has no lock file.
+func readKclModLockContent(dir string) (types.String, error) {
+	content, err := os.ReadFile(filepath.Join(dir, kclModLockFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return types.StringNull(), nil
+	}
+	if err != nil {
+		return types.StringNull(), err
+	}
+	return types.StringValue(string(content)), nil
+}
+
+// dependencyClosureHash fingerprints a set of resolved dependencies. Entries
+// are sorted by name, then version, and each contributes the line
+// "<name>@<version>:<sum>\n"; the result is the hex SHA-256 of those lines.
+func dependencyClosureHash(deps []kclLockedDependency) string {
+	sorted := append([]kclLockedDependency(nil), deps...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Name != sorted[j].Name {
+			return sorted[i].Name < sorted[j].Name
+		}
+		return sorted[i].Version < sorted[j].Version
+	})
+
+	h := sha256.New()
+	for _, dep := range sorted {
+		fmt.Fprintf(h, "%s@%s:%s\n", dep.Name, dep.Version, dep.Sum)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
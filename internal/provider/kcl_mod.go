@@ -0,0 +1,486 @@
+// internal/provider/kcl_mod.go
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const (
+	kclModFileName = "kcl.mod"
+
+	// kclModOriginalKey is the private state key holding the kcl.mod content
+	// found before the resource took ownership, restored on delete.
+	kclModOriginalKey = "original_kcl_mod"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var (
+	_ resource.Resource              = &KclModResource{}
+	_ resource.ResourceWithConfigure = &KclModResource{}
+)
+
+func NewKclModResource() resource.Resource {
+	return &KclModResource{}
+}
+
+type KclModResource struct {
+	provider *kclProvider
+}
+
+type KclModResourceModel struct {
+	ID           types.String `tfsdk:"id"`
+	SourceDir    types.String `tfsdk:"source_dir"`
+	Name         types.String `tfsdk:"name"`
+	Version      types.String `tfsdk:"version"`
+	Edition      types.String `tfsdk:"edition"`
+	Dependencies types.Map    `tfsdk:"dependencies"`
+	Timeout      types.Int64  `tfsdk:"timeout"`
+	LockFileHash types.String `tfsdk:"lock_file_hash"`
+}
+
+// kclModOriginal records what kcl.mod and kcl.mod.lock looked like before
+// Create. LockRecorded is false in private state written before the lock
+// file was tracked, in which case the lock file is left alone on delete.
+type kclModOriginal struct {
+	Exists       bool   `json:"exists"`
+	Content      string `json:"content"`
+	LockRecorded bool   `json:"lock_recorded"`
+	LockExists   bool   `json:"lock_exists"`
+	LockContent  string `json:"lock_content"`
+}
+
+func (r *KclModResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_mod"
+}
+
+func (r *KclModResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Manages the package declaration in a `kcl.mod` file. Only the attributes below are rewritten; " +
+			"comments and unmanaged fields are preserved. When `dependencies` is set, `kcl mod update` resolves them into " +
+			"`kcl.mod.lock`. The original `kcl.mod` and `kcl.mod.lock` are restored on destroy",
+
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Absolute path of the managed `kcl.mod` file",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"source_dir": schema.StringAttribute{
+				Required:            true,
+				MarkdownDescription: "Path to the KCL package directory containing `kcl.mod`",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"name": schema.StringAttribute{
+				Required:            true,
+				MarkdownDescription: "Package name (`package.name`)",
+			},
+			"version": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Package version (`package.version`)",
+			},
+			"edition": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "KCL edition the package targets (`package.edition`)",
+			},
+			"dependencies": schema.MapAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				MarkdownDescription: "Dependencies keyed by package name. Values are either a version string or a TOML inline table " +
+					"such as `{ git = \"https://github.com/org/repo\", tag = \"v0.1.0\" }`. When set, the `[dependencies]` table is made to match exactly " +
+					"and the dependencies are downloaded and locked with `kcl mod update`",
+			},
+			"timeout": schema.Int64Attribute{
+				Optional:            true,
+				MarkdownDescription: "Dependency resolution timeout in seconds (default: 300)",
+			},
+			"lock_file_hash": schema.StringAttribute{
+				Computed: true,
+				MarkdownDescription: "Fingerprint of the dependencies locked in `kcl.mod.lock`, computed like `dependency_closure_hash` " +
+					"of `kcl_exec`. Null when there is no lock file",
+			},
+		},
+	}
+}
+
+func (r *KclModResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	provider, ok := req.ProviderData.(*kclProvider)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Provider Data Type",
+			fmt.Sprintf("Expected *kclProvider, got: %T", req.ProviderData),
+		)
+		return
+	}
+
+	r.provider = provider
+}
+
+func (r *KclModResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan KclModResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	modPath, err := kclModPath(plan.SourceDir.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Path Resolution Error", err.Error())
+		return
+	}
+
+	// Remember the original files so Delete can put them back
+	original := kclModOriginal{LockRecorded: true}
+	content, err := os.ReadFile(modPath)
+	switch {
+	case err == nil:
+		original.Exists, original.Content = true, string(content)
+	case !errors.Is(err, os.ErrNotExist):
+		resp.Diagnostics.AddError("kcl.mod Read Error", err.Error())
+		return
+	}
+	lockContent, err := os.ReadFile(kclModLockPath(modPath))
+	switch {
+	case err == nil:
+		original.LockExists, original.LockContent = true, string(lockContent)
+	case !errors.Is(err, os.ErrNotExist):
+		resp.Diagnostics.AddError("Lock File Read Error", err.Error())
+		return
+	}
+
+	originalJSON, err := json.Marshal(original)
+	if err != nil {
+		resp.Diagnostics.AddError("kcl.mod Read Error", err.Error())
+		return
+	}
+	resp.Diagnostics.Append(resp.Private.SetKey(ctx, kclModOriginalKey, originalJSON)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(r.write(ctx, modPath, string(content), plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(r.resolve(ctx, modPath, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	plan.ID = types.StringValue(modPath)
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *KclModResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state KclModResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	modPath := state.ID.ValueString()
+	content, err := os.ReadFile(modPath)
+	if errors.Is(err, os.ErrNotExist) {
+		tflog.Warn(ctx, "kcl.mod no longer exists, removing from state", map[string]interface{}{
+			"path": modPath,
+		})
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("kcl.mod Read Error", err.Error())
+		return
+	}
+
+	mod := parseKclMod(string(content))
+	pkg := mod.entries("package")
+
+	state.Name = tomlStringValue(pkg["name"])
+	if !state.Version.IsNull() {
+		state.Version = tomlStringValue(pkg["version"])
+	}
+	if !state.Edition.IsNull() {
+		state.Edition = tomlStringValue(pkg["edition"])
+	}
+
+	if !state.Dependencies.IsNull() {
+		deps := make(map[string]string)
+		for name, raw := range mod.entries("dependencies") {
+			deps[name] = dependencySpecFromToml(raw)
+		}
+
+		state.Dependencies, diags = types.MapValueFrom(ctx, types.StringType, deps)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	lockHash, err := kclModLockHash(filepath.Dir(modPath))
+	if err != nil {
+		resp.Diagnostics.AddError("Lock File Read Error", err.Error())
+		return
+	}
+	state.LockFileHash = lockHash
+
+	diags = resp.State.Set(ctx, state)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *KclModResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state KclModResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	modPath := state.ID.ValueString()
+	content, err := os.ReadFile(modPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		resp.Diagnostics.AddError("kcl.mod Read Error", err.Error())
+		return
+	}
+
+	mod := parseKclMod(string(content))
+
+	// Drop managed keys that are no longer configured
+	if plan.Version.IsNull() && !state.Version.IsNull() {
+		mod.remove("package", "version")
+	}
+	if plan.Edition.IsNull() && !state.Edition.IsNull() {
+		mod.remove("package", "edition")
+	}
+	if plan.Dependencies.IsNull() && !state.Dependencies.IsNull() {
+		mod.setTable("dependencies", map[string]string{})
+	}
+
+	resp.Diagnostics.Append(r.write(ctx, modPath, mod.String(), plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(r.resolve(ctx, modPath, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	plan.ID = state.ID
+
+	diags := resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *KclModResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state KclModResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	originalJSON, diags := req.Private.GetKey(ctx, kclModOriginalKey)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	original := kclModOriginal{}
+	if len(originalJSON) > 0 {
+		if err := json.Unmarshal(originalJSON, &original); err != nil {
+			resp.Diagnostics.AddError("kcl.mod Restore Error", "Unable to decode original kcl.mod: "+err.Error())
+			return
+		}
+	}
+
+	modPath := state.ID.ValueString()
+	if err := restoreFile(modPath, original.Exists, original.Content); err != nil {
+		resp.Diagnostics.AddError("kcl.mod Restore Error", err.Error())
+	}
+	if !original.LockRecorded {
+		return
+	}
+	if err := restoreFile(kclModLockPath(modPath), original.LockExists, original.LockContent); err != nil {
+		resp.Diagnostics.AddError("Lock File Restore Error", err.Error())
+	}
+}
+
+// write applies the managed attributes of model on top of content and
+// writes the result to modPath.
+func (r *KclModResource) write(ctx context.Context, modPath, content string, model KclModResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	mod := parseKclMod(content)
+	mod.set("package", "name", tomlString(model.Name.ValueString()))
+	if !model.Edition.IsNull() {
+		mod.set("package", "edition", tomlString(model.Edition.ValueString()))
+	}
+	if !model.Version.IsNull() {
+		mod.set("package", "version", tomlString(model.Version.ValueString()))
+	}
+
+	if !model.Dependencies.IsNull() {
+		deps := make(map[string]string)
+		diags.Append(model.Dependencies.ElementsAs(ctx, &deps, false)...)
+		if diags.HasError() {
+			return diags
+		}
+
+		rendered := make(map[string]string, len(deps))
+		for name, spec := range deps {
+			rendered[name] = dependencySpecToToml(spec)
+		}
+		mod.setTable("dependencies", rendered)
+	}
+
+	tflog.Info(ctx, "Writing kcl.mod", map[string]interface{}{
+		"path": modPath,
+	})
+
+	if err := os.WriteFile(modPath, []byte(mod.String()), 0o644); err != nil {
+		diags.AddError("kcl.mod Write Error", err.Error())
+	}
+	return diags
+}
+
+// resolve downloads and locks the dependencies declared in kcl.mod with
+// `kcl mod update` and records the resulting lock file hash in model.
+// Without managed dependencies only the hash is refreshed. Resolving an
+// already locked dependency set leaves the lock file unchanged, so
+// re-applying the same configuration is a no-op.
+func (r *KclModResource) resolve(ctx context.Context, modPath string, model *KclModResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	dir := filepath.Dir(modPath)
+
+	if !model.Dependencies.IsNull() {
+		timeout := 300 * time.Second
+		if !model.Timeout.IsNull() {
+			timeout = time.Duration(model.Timeout.ValueInt64()) * time.Second
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, effectiveTimeout(ctx, timeout))
+		defer cancel()
+
+		kclCommand, err := r.provider.resolveKclCommand()
+		if err != nil {
+			diags.AddError("KCL Executable Not Found", err.Error())
+			return diags
+		}
+
+		args := []string{"mod", "update"}
+		cmd := exec.CommandContext(ctx, kclCommand, args...)
+		cmd.Dir = dir
+		configureGracefulStop(cmd)
+
+		tflog.Info(ctx, "Resolving KCL dependencies", map[string]interface{}{
+			"command":   kclCommand,
+			"arguments": args,
+			"directory": dir,
+		})
+
+		result, err := r.provider.runKcl(ctx, cmd)
+		if err != nil {
+			diags.AddError(
+				"KCL Dependency Resolution Failed",
+				fmt.Sprintf("Command: %s %s\nError: %v\nOutput: %s",
+					kclCommand, strings.Join(args, " "), err, strings.TrimSpace(string(result.Combined))),
+			)
+			return diags
+		}
+	}
+
+	lockHash, err := kclModLockHash(dir)
+	if err != nil {
+		diags.AddError("Lock File Read Error", err.Error())
+		return diags
+	}
+	model.LockFileHash = lockHash
+	return diags
+}
+
+// kclModLockPath returns the path of the lock file next to modPath.
+func kclModLockPath(modPath string) string {
+	return filepath.Join(filepath.Dir(modPath), kclModLockFileName)
+}
+
+// kclModLockHash returns the dependency closure hash of the lock file in
+// dir, null when there is none.
+func kclModLockHash(dir string) (types.String, error) {
+	deps, err := readKclModLock(dir)
+	if err != nil {
+		return types.StringNull(), err
+	}
+	if deps == nil {
+		return types.StringNull(), nil
+	}
+	return types.StringValue(dependencyClosureHash(deps)), nil
+}
+
+// restoreFile writes content back to path, or removes path when it did not
+// exist originally.
+func restoreFile(path string, existed bool, content string) error {
+	if !existed {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	return os.WriteFile(path, []byte(content), 0o644)
+}
+
+// kclModPath resolves the kcl.mod path inside sourceDir, which must exist.
+func kclModPath(sourceDir string) (string, error) {
+	absPath, err := filepath.Abs(sourceDir)
+	if err != nil {
+		return "", fmt.Errorf("invalid source directory path: %w", err)
+	}
+
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return "", fmt.Errorf("source directory does not exist: %s", absPath)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("source_dir is not a directory: %s", absPath)
+	}
+	return filepath.Join(absPath, kclModFileName), nil
+}
+
+// tomlStringValue converts a raw TOML value into a Terraform string, null
+// when the key is absent.
+func tomlStringValue(raw string) types.String {
+	if raw == "" {
+		return types.StringNull()
+	}
+	if s, err := unquoteTomlString(raw); err == nil {
+		return types.StringValue(s)
+	}
+	return types.StringValue(raw)
+}
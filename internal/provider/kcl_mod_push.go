@@ -0,0 +1,335 @@
+// internal/provider/kcl_mod_push.go
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// ociDigestPattern matches the manifest digest `kcl mod push` reports.
+var ociDigestPattern = regexp.MustCompile(`sha256:[0-9a-f]{64}`)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var (
+	_ resource.Resource               = &KclModPushResource{}
+	_ resource.ResourceWithConfigure  = &KclModPushResource{}
+	_ resource.ResourceWithModifyPlan = &KclModPushResource{}
+)
+
+func NewKclModPushResource() resource.Resource {
+	return &KclModPushResource{}
+}
+
+type KclModPushResource struct {
+	provider *kclProvider
+}
+
+type KclModPushResourceModel struct {
+	ID         types.String `tfsdk:"id"`
+	WorkingDir types.String `tfsdk:"working_dir"`
+	OCIURL     types.String `tfsdk:"oci_url"`
+	Timeout    types.Int64  `tfsdk:"timeout"`
+	Name       types.String `tfsdk:"name"`
+	Tag        types.String `tfsdk:"tag"`
+	Digest     types.String `tfsdk:"digest"`
+	Output     types.String `tfsdk:"output"`
+}
+
+func (r *KclModPushResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_mod_push"
+}
+
+func (r *KclModPushResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Publishes a KCL package to an OCI registry with `kcl mod push`, authenticated with the provider's " +
+			"registry credentials. The package is pushed again whenever the version in `kcl.mod` changes. " +
+			"Destroying the resource leaves the published package in the registry",
+
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Reference of the pushed package, `<oci_url or name>:<tag>`",
+			},
+			"working_dir": schema.StringAttribute{
+				Required:            true,
+				MarkdownDescription: "Path to the KCL package directory containing `kcl.mod`",
+			},
+			"oci_url": schema.StringAttribute{
+				Optional: true,
+				MarkdownDescription: "Repository to push to, e.g. `oci://ghcr.io/org/package`. Defaults to the registry " +
+					"and repository configured in the KCL settings",
+			},
+			"timeout": schema.Int64Attribute{
+				Optional:            true,
+				MarkdownDescription: "Push timeout in seconds (default: 300)",
+			},
+			"name": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Package name read from `kcl.mod`",
+			},
+			"tag": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Tag the package was pushed with, the package version read from `kcl.mod`",
+			},
+			"digest": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Manifest digest of the pushed package, null when KCL did not report one",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"output": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Output of `kcl mod push`",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+	}
+}
+
+func (r *KclModPushResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	provider, ok := req.ProviderData.(*kclProvider)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Provider Data Type",
+			fmt.Sprintf("Expected *kclProvider, got: %T", req.ProviderData),
+		)
+		return
+	}
+
+	r.provider = provider
+}
+
+// ModifyPlan plans the package name and version currently in kcl.mod, so a
+// version bump shows up as an update that pushes again. A missing or
+// unreadable kcl.mod is reported at apply time.
+func (r *KclModPushResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to do on destroy
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var plan KclModPushResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() || plan.WorkingDir.IsUnknown() || plan.OCIURL.IsUnknown() {
+		return
+	}
+
+	name, version, err := kclPackageVersion(plan.WorkingDir.ValueString())
+	if err != nil || version == "" {
+		return
+	}
+	plan.Name = types.StringValue(name)
+	plan.Tag = types.StringValue(version)
+	plan.ID = types.StringValue(ociPackageRef(plan.OCIURL.ValueString(), name, version))
+
+	// Without a state there is nothing to keep; with one, keep the digest
+	// and output unless the package is pushed again
+	if !req.State.Raw.IsNull() {
+		var state KclModPushResourceModel
+		resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		if needsPush(plan, state) {
+			plan.Digest = types.StringUnknown()
+			plan.Output = types.StringUnknown()
+		} else {
+			plan.Digest = state.Digest
+			plan.Output = state.Output
+		}
+	}
+
+	resp.Diagnostics.Append(resp.Plan.Set(ctx, plan)...)
+}
+
+func (r *KclModPushResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan KclModPushResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(r.push(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+// Read keeps the recorded push. The registry is not queried, so a package
+// deleted there outside Terraform is not noticed.
+func (r *KclModPushResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state KclModPushResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, state)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *KclModPushResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state KclModPushResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Registries refuse to overwrite a published version, so only push
+	// when the package or its destination changed
+	if needsPush(plan, state) {
+		resp.Diagnostics.Append(r.push(ctx, &plan)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	} else {
+		plan.ID = state.ID
+		plan.Name = state.Name
+		plan.Tag = state.Tag
+		plan.Digest = state.Digest
+		plan.Output = state.Output
+	}
+
+	diags := resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+// Delete only forgets the push. KCL cannot delete packages from a registry.
+func (r *KclModPushResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state KclModPushResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	tflog.Info(ctx, "Leaving pushed KCL package in the registry", map[string]interface{}{
+		"reference": state.ID.ValueString(),
+	})
+}
+
+// push runs `kcl mod push` in the working directory and records the pushed
+// reference, tag and digest in model.
+func (r *KclModPushResource) push(ctx context.Context, model *KclModPushResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	dir, err := filepath.Abs(model.WorkingDir.ValueString())
+	if err != nil {
+		diags.AddAttributeError(path.Root("working_dir"), "Path Resolution Error", err.Error())
+		return diags
+	}
+	name, version, err := kclPackageVersion(dir)
+	if err != nil {
+		diags.AddAttributeError(path.Root("working_dir"), "kcl.mod Read Error", err.Error())
+		return diags
+	}
+	if version == "" {
+		diags.AddAttributeError(path.Root("working_dir"), "Missing Package Version",
+			"kcl.mod in "+dir+" declares no package.version, which is the tag the package is pushed with.")
+		return diags
+	}
+
+	timeout := 300 * time.Second
+	if !model.Timeout.IsNull() {
+		timeout = time.Duration(model.Timeout.ValueInt64()) * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, effectiveTimeout(ctx, timeout))
+	defer cancel()
+
+	kclCommand, err := r.provider.resolveKclCommand()
+	if err != nil {
+		diags.AddError("KCL Executable Not Found", err.Error())
+		return diags
+	}
+
+	args := []string{"mod", "push"}
+	if !model.OCIURL.IsNull() {
+		args = append(args, model.OCIURL.ValueString())
+	}
+	cmd := exec.CommandContext(ctx, kclCommand, args...)
+	cmd.Dir = dir
+	configureGracefulStop(cmd)
+
+	tflog.Info(ctx, "Pushing KCL package", map[string]interface{}{
+		"command":   kclCommand,
+		"arguments": args,
+		"directory": dir,
+		"version":   version,
+	})
+
+	result, err := r.provider.runKcl(ctx, cmd)
+	output := stripANSI(strings.TrimSpace(string(result.Combined)))
+	if err != nil {
+		diags.AddError(
+			"KCL Package Push Failed",
+			fmt.Sprintf("Command: %s %s\nError: %v\nOutput: %s",
+				kclCommand, strings.Join(args, " "), err, output),
+		)
+		return diags
+	}
+
+	model.ID = types.StringValue(ociPackageRef(model.OCIURL.ValueString(), name, version))
+	model.Name = types.StringValue(name)
+	model.Tag = types.StringValue(version)
+	model.Digest = types.StringNull()
+	if digest := ociDigestPattern.FindString(output); digest != "" {
+		model.Digest = types.StringValue(digest)
+	}
+	model.Output = types.StringValue(output)
+	return diags
+}
+
+// needsPush reports whether plan publishes something state has not.
+func needsPush(plan, state KclModPushResourceModel) bool {
+	return !plan.Tag.Equal(state.Tag) || !plan.Name.Equal(state.Name) ||
+		!plan.OCIURL.Equal(state.OCIURL) || !plan.WorkingDir.Equal(state.WorkingDir)
+}
+
+// kclPackageVersion reads the package name and version from the kcl.mod in
+// dir. version is empty when kcl.mod declares none.
+func kclPackageVersion(dir string) (name, version string, err error) {
+	content, err := os.ReadFile(filepath.Join(dir, kclModFileName))
+	if err != nil {
+		return "", "", err
+	}
+	pkg := parseKclMod(string(content)).entries("package")
+	return tomlStringValue(pkg["name"]).ValueString(), tomlStringValue(pkg["version"]).ValueString(), nil
+}
+
+// ociPackageRef returns the reference a package is pushed to, the
+// repository when one is configured and the package name otherwise.
+func ociPackageRef(ociURL, name, tag string) string {
+	if ociURL == "" {
+		return name + ":" + tag
+	}
+	return ociURL + ":" + tag
+}
@@ -0,0 +1,106 @@
+// internal/provider/kcl_mod_push_test.go
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const pushDigest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+// pushKcl returns a fake KCL whose mod push records its arguments in calls
+// and reports pushDigest.
+func pushKcl(t *testing.T, calls string) string {
+	return fakeKcl(t, `[ "$1 $2" = "mod push" ] || exit 1
+echo "$*" >> "`+calls+`"
+printf '\033[32mpushed\033[0m %s\n' "`+pushDigest+`"`)
+}
+
+func newKclModPushHarness(t *testing.T, kclBinary string) *resourceHarness {
+	t.Helper()
+	return newResourceHarness(t, &KclModPushResource{provider: &kclProvider{KclPath: kclBinary, KclBinary: kclBinary}})
+}
+
+func writeKclMod(t *testing.T, dir, version string) {
+	t.Helper()
+	content := "[package]\nname = \"app\"\n"
+	if version != "" {
+		content += "version = \"" + version + "\"\n"
+	}
+	if err := os.WriteFile(filepath.Join(dir, kclModFileName), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestKclModPushResource_Lifecycle(t *testing.T) {
+	dir := t.TempDir()
+	writeKclMod(t, dir, "0.1.0")
+	calls := filepath.Join(t.TempDir(), "calls")
+	h := newKclModPushHarness(t, pushKcl(t, calls))
+	config := map[string]attr.Value{
+		"working_dir": types.StringValue(dir),
+		"oci_url":     types.StringValue("oci://ghcr.io/org/app"),
+	}
+
+	h.mustApply(config)
+	var model KclModPushResourceModel
+	h.model(&model)
+	if model.ID.ValueString() != "oci://ghcr.io/org/app:0.1.0" || model.Name.ValueString() != "app" ||
+		model.Tag.ValueString() != "0.1.0" || model.Digest.ValueString() != pushDigest {
+		t.Errorf("id, name, tag, digest = %s, %s, %s, %s", model.ID, model.Name, model.Tag, model.Digest)
+	}
+	if got := model.Output.ValueString(); got != "pushed "+pushDigest {
+		t.Errorf("output = %q, want it without color codes", got)
+	}
+
+	// The registry is not queried, so reading keeps the push
+	if !h.mustRead() {
+		t.Fatal("the push was removed from state")
+	}
+
+	// Unchanged, the package is not pushed again; a version bump is
+	h.mustApply(config)
+	writeKclMod(t, dir, "0.2.0")
+	h.mustApply(config)
+	h.model(&model)
+	if model.Tag.ValueString() != "0.2.0" || model.ID.ValueString() != "oci://ghcr.io/org/app:0.2.0" {
+		t.Errorf("tag, id after the version bump = %s, %s", model.Tag, model.ID)
+	}
+	want := "mod push oci://ghcr.io/org/app\nmod push oci://ghcr.io/org/app\n"
+	if got := readTestFile(t, calls); got != want {
+		t.Errorf("KCL was run as %q, want one push per version", got)
+	}
+}
+
+func TestKclModPushResource_Errors(t *testing.T) {
+	calls := filepath.Join(t.TempDir(), "calls")
+	h := newKclModPushHarness(t, pushKcl(t, calls))
+
+	diags := h.apply(map[string]attr.Value{"working_dir": types.StringValue(t.TempDir())})
+	if !diags.HasError() || diags.Errors()[0].Summary() != "kcl.mod Read Error" {
+		t.Errorf("apply diagnostics = %v, want a kcl.mod read error", diags)
+	}
+
+	dir := t.TempDir()
+	writeKclMod(t, dir, "")
+	diags = h.apply(map[string]attr.Value{"working_dir": types.StringValue(dir)})
+	if !diags.HasError() || diags.Errors()[0].Summary() != "Missing Package Version" {
+		t.Errorf("apply diagnostics = %v, want a missing version error", diags)
+	}
+	if _, err := os.Stat(calls); !os.IsNotExist(err) {
+		t.Errorf("KCL ran although the package could not be pushed: %v", err)
+	}
+
+	writeKclMod(t, dir, "0.1.0")
+	h = newKclModPushHarness(t, fakeKcl(t, `echo "unauthorized" >&2; exit 1`))
+	diags = h.apply(map[string]attr.Value{"working_dir": types.StringValue(dir)})
+	if !diags.HasError() || diags.Errors()[0].Summary() != "KCL Package Push Failed" ||
+		!strings.Contains(diags.Errors()[0].Detail(), "unauthorized") {
+		t.Errorf("apply diagnostics = %v, want a push error with KCL's output", diags)
+	}
+}
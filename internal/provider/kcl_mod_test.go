@@ -0,0 +1,203 @@
+// internal/provider/kcl_mod_test.go
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestTomlString(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"plain", `"plain"`},
+		{`say "hi"`, `"say \"hi\""`},
+		{`C:\path`, `"C:\\path"`},
+		{"\b\t\n\f\r", `"\b\t\n\f\r"`},
+		{"bell\a vt\v nul\x00 del\x7f esc\x1b", `"bell\u0007 vt\u000B nul\u0000 del\u007F esc\u001B"`},
+		{"é 😀", `"é 😀"`},
+	}
+	for _, tt := range tests {
+		got := tomlString(tt.in)
+		if got != tt.want {
+			t.Errorf("tomlString(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+		back, err := unquoteTomlString(got)
+		if err != nil || back != tt.in {
+			t.Errorf("unquoteTomlString(%s) = %q, %v, want %q", got, back, err, tt.in)
+		}
+		decoded, err := decodeTOML("s = " + got + "\n")
+		if err != nil || decoded["s"] != tt.in {
+			t.Errorf("decodeTOML(s = %s) = %v, %v, want %q", got, decoded, err, tt.in)
+		}
+	}
+}
+
+func TestKclModFile_QuotedKeys(t *testing.T) {
+	mod := parseKclMod("[dependencies]\n\"a\\\"b\" = \"1\" # pinned\n")
+	if got := mod.entries("dependencies"); got[`a"b`] != `"1"` {
+		t.Fatalf("entries = %q, want the escaped key unquoted", got)
+	}
+	mod.set("dependencies", "x y", tomlString("2"))
+	want := "[dependencies]\n\"a\\\"b\" = \"1\" # pinned\n\"x y\" = \"2\"\n"
+	if got := mod.String(); got != want {
+		t.Errorf("kcl.mod = %q, want %q", got, want)
+	}
+}
+
+// newKclModHarness returns a harness for a kcl_mod resource whose provider
+// runs kclBinary.
+func newKclModHarness(t *testing.T, kclBinary string) *resourceHarness {
+	t.Helper()
+	return newResourceHarness(t, &KclModResource{provider: &kclProvider{KclPath: kclBinary, KclBinary: kclBinary}})
+}
+
+func readTestFile(t *testing.T, file string) string {
+	t.Helper()
+	content, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func TestKclModResource_Lifecycle(t *testing.T) {
+	original := "# managed by hand\n[package]\nname = \"old\"\nedition = \"v0.9.0\"\ndescription = \"kept\"\n\n[profile]\nentries = [\"main.k\"]\n"
+	dir := writeTestFiles(t, map[string]string{"kcl.mod": original})
+	modPath := filepath.Join(dir, "kcl.mod")
+	h := newKclModHarness(t, fakeKcl(t, "exit 1"))
+
+	h.mustApply(map[string]attr.Value{
+		"source_dir": types.StringValue(dir),
+		"name":       types.StringValue(`app "quoted"`),
+		"version":    types.StringValue("0.1.0"),
+	})
+	want := "# managed by hand\n[package]\nname = \"app \\\"quoted\\\"\"\nedition = \"v0.9.0\"\ndescription = \"kept\"\nversion = \"0.1.0\"\n\n[profile]\nentries = [\"main.k\"]\n"
+	if got := readTestFile(t, modPath); got != want {
+		t.Fatalf("kcl.mod after create = %q, want %q", got, want)
+	}
+	var model KclModResourceModel
+	h.model(&model)
+	if model.ID.ValueString() != modPath || !model.LockFileHash.IsNull() {
+		t.Errorf("id = %s, lock_file_hash = %s, want %s and null", model.ID, model.LockFileHash, modPath)
+	}
+
+	h.mustApply(map[string]attr.Value{
+		"source_dir": types.StringValue(dir),
+		"name":       types.StringValue("app"),
+	})
+	if got := readTestFile(t, modPath); strings.Contains(got, "version") || !strings.Contains(got, "name = \"app\"\n") {
+		t.Errorf("kcl.mod after dropping version = %q", got)
+	}
+
+	if diags := h.destroy(); diags.HasError() {
+		t.Fatalf("destroy: %v", diags)
+	}
+	if got := readTestFile(t, modPath); got != original {
+		t.Errorf("kcl.mod after destroy = %q, want the original %q", got, original)
+	}
+}
+
+func TestKclModResource_DestroyRemovesCreatedFile(t *testing.T) {
+	dir := t.TempDir()
+	h := newKclModHarness(t, fakeKcl(t, "exit 1"))
+
+	h.mustApply(map[string]attr.Value{
+		"source_dir": types.StringValue(dir),
+		"name":       types.StringValue("app"),
+	})
+	if got := readTestFile(t, filepath.Join(dir, "kcl.mod")); got != "[package]\nname = \"app\"\n" {
+		t.Errorf("kcl.mod = %q", got)
+	}
+	if diags := h.destroy(); diags.HasError() {
+		t.Fatalf("destroy: %v", diags)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "kcl.mod")); !os.IsNotExist(err) {
+		t.Errorf("kcl.mod was not removed: %v", err)
+	}
+}
+
+func TestKclModResource_ReadDetectsDrift(t *testing.T) {
+	dir := t.TempDir()
+	modPath := filepath.Join(dir, "kcl.mod")
+	h := newKclModHarness(t, fakeKcl(t, "exit 0"))
+	h.mustApply(map[string]attr.Value{
+		"source_dir":   types.StringValue(dir),
+		"name":         types.StringValue("app"),
+		"edition":      types.StringValue("v0.11.0"),
+		"dependencies": types.MapValueMust(types.StringType, map[string]attr.Value{"k8s": types.StringValue("1.28")}),
+	})
+
+	edited := "[package]\nname = \"renamed\"\nedition = 'v0.10.0'\n\n[dependencies]\nk8s = \"1.29\"\nhelm = { git = \"https://example.com/helm\" }\n"
+	if err := os.WriteFile(modPath, []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if !h.mustRead() {
+		t.Fatal("the resource disappeared")
+	}
+	var model KclModResourceModel
+	h.model(&model)
+	if model.Name.ValueString() != "renamed" || model.Edition.ValueString() != "v0.10.0" || !model.Version.IsNull() {
+		t.Errorf("name, edition, version = %s, %s, %s, want the edited values", model.Name, model.Edition, model.Version)
+	}
+	var deps map[string]string
+	model.Dependencies.ElementsAs(h.ctx, &deps, false)
+	if len(deps) != 2 || deps["k8s"] != "1.29" || deps["helm"] != `{ git = "https://example.com/helm" }` {
+		t.Errorf("dependencies = %q, want the edited table", deps)
+	}
+
+	if err := os.Remove(modPath); err != nil {
+		t.Fatal(err)
+	}
+	if h.mustRead() {
+		t.Error("the resource is still in state after kcl.mod was deleted")
+	}
+}
+
+func TestKclModResource_DependenciesRunModUpdate(t *testing.T) {
+	dir := t.TempDir()
+	calls := filepath.Join(t.TempDir(), "calls")
+	h := newKclModHarness(t, fakeKcl(t, `echo "$PWD $*" >> "`+calls+`"`))
+
+	h.mustApply(map[string]attr.Value{
+		"source_dir":   types.StringValue(dir),
+		"name":         types.StringValue("app"),
+		"dependencies": types.MapValueMust(types.StringType, map[string]attr.Value{"k8s": types.StringValue("1.28")}),
+	})
+	if got := readTestFile(t, filepath.Join(dir, "kcl.mod")); got != "[package]\nname = \"app\"\n\n[dependencies]\nk8s = \"1.28\"\n" {
+		t.Errorf("kcl.mod = %q", got)
+	}
+	if got, want := strings.TrimSpace(readTestFile(t, calls)), dir+" mod update"; got != want {
+		t.Errorf("KCL was run as %q, want %q", got, want)
+	}
+}
+
+func TestKclModResource_ModUpdateFailure(t *testing.T) {
+	h := newKclModHarness(t, fakeKcl(t, `echo "no such package" >&2; exit 1`))
+	diags := h.apply(map[string]attr.Value{
+		"source_dir":   types.StringValue(t.TempDir()),
+		"name":         types.StringValue("app"),
+		"dependencies": types.MapValueMust(types.StringType, map[string]attr.Value{"nope": types.StringValue("0.0.1")}),
+	})
+	if !diags.HasError() || diags.Errors()[0].Summary() != "KCL Dependency Resolution Failed" ||
+		!strings.Contains(diags.Errors()[0].Detail(), "no such package") {
+		t.Fatalf("apply diagnostics = %v, want a dependency resolution error with KCL's output", diags)
+	}
+}
+
+func TestKclModResource_MissingSourceDir(t *testing.T) {
+	h := newKclModHarness(t, fakeKcl(t, "exit 0"))
+	diags := h.apply(map[string]attr.Value{
+		"source_dir": types.StringValue(filepath.Join(t.TempDir(), "missing")),
+		"name":       types.StringValue("app"),
+	})
+	if !diags.HasError() || diags.Errors()[0].Summary() != "Path Resolution Error" {
+		t.Fatalf("apply diagnostics = %v, want a path resolution error", diags)
+	}
+}
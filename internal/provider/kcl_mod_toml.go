@@ -0,0 +1,273 @@
+// internal/provider/kcl_mod_toml.go
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// kclModFile is a line-oriented view of a kcl.mod TOML document. Only the
+// keys the provider manages are rewritten; comments, blank lines, ordering
+// and any other tables are carried through untouched.
+type kclModFile struct {
+	lines []string
+}
+
+var (
+	tomlTableHeader = regexp.MustCompile(`^\s*\[\s*([^\[\]]+?)\s*\]\s*(#.*)?$`)
+	tomlKeyValue    = regexp.MustCompile(`^\s*("(?:[^"\\]|\\.)*"|[A-Za-z0-9_\-]+)\s*=\s*(.*?)\s*$`)
+	tomlBareKey     = regexp.MustCompile(`^[A-Za-z0-9_\-]+$`)
+)
+
+func parseKclMod(content string) *kclModFile {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	content = strings.TrimSuffix(content, "\n")
+	if content == "" {
+		return &kclModFile{}
+	}
+	return &kclModFile{lines: strings.Split(content, "\n")}
+}
+
+func (f *kclModFile) String() string {
+	if len(f.lines) == 0 {
+		return ""
+	}
+	return strings.Join(f.lines, "\n") + "\n"
+}
+
+// section returns the line range [start, end) holding the keys of table,
+// where start is the line after the header. ok is false when the table is
+// absent.
+func (f *kclModFile) section(table string) (start, end int, ok bool) {
+	start = -1
+	for i, line := range f.lines {
+		m := tomlTableHeader.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		if start >= 0 {
+			return start, i, true
+		}
+		if m[1] == table {
+			start = i + 1
+		}
+	}
+	if start < 0 {
+		return 0, 0, false
+	}
+	return start, len(f.lines), true
+}
+
+// tables returns the names of all tables whose name starts with prefix, in
+// document order.
+func (f *kclModFile) tables(prefix string) []string {
+	var names []string
+	for _, line := range f.lines {
+		if m := tomlTableHeader.FindStringSubmatch(line); m != nil && strings.HasPrefix(m[1], prefix) {
+			names = append(names, m[1])
+		}
+	}
+	return names
+}
+
+// entries returns the key/value pairs of table, with values left as raw TOML.
+func (f *kclModFile) entries(table string) map[string]string {
+	start, end, ok := f.section(table)
+	if !ok {
+		return nil
+	}
+
+	values := make(map[string]string)
+	for _, line := range f.lines[start:end] {
+		if key, value, ok := parseTomlKeyValue(line); ok {
+			values[key] = value
+		}
+	}
+	return values
+}
+
+// set writes key = rawValue into table, replacing an existing assignment in
+// place or appending after the last key of the table.
+func (f *kclModFile) set(table, key, rawValue string) {
+	line := formatTomlKey(key) + " = " + rawValue
+
+	start, end, ok := f.section(table)
+	if !ok {
+		if len(f.lines) > 0 && strings.TrimSpace(f.lines[len(f.lines)-1]) != "" {
+			f.lines = append(f.lines, "")
+		}
+		f.lines = append(f.lines, "["+table+"]", line)
+		return
+	}
+
+	insertAt := start
+	for i := start; i < end; i++ {
+		k, _, ok := parseTomlKeyValue(f.lines[i])
+		if !ok {
+			continue
+		}
+		if k == key {
+			f.lines[i] = line
+			return
+		}
+		insertAt = i + 1
+	}
+	f.insert(insertAt, line)
+}
+
+// remove deletes the assignment of key from table, if present.
+func (f *kclModFile) remove(table, key string) {
+	start, end, ok := f.section(table)
+	if !ok {
+		return
+	}
+	for i := start; i < end; i++ {
+		if k, _, ok := parseTomlKeyValue(f.lines[i]); ok && k == key {
+			f.lines = append(f.lines[:i], f.lines[i+1:]...)
+			return
+		}
+	}
+}
+
+// setTable makes table hold exactly values, keeping comments and the
+// position of keys that survive. Keys are added in sorted order.
+func (f *kclModFile) setTable(table string, values map[string]string) {
+	for key := range f.entries(table) {
+		if _, ok := values[key]; !ok {
+			f.remove(table, key)
+		}
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		f.set(table, key, values[key])
+	}
+
+	if _, _, ok := f.section(table); !ok {
+		if len(f.lines) > 0 && strings.TrimSpace(f.lines[len(f.lines)-1]) != "" {
+			f.lines = append(f.lines, "")
+		}
+		f.lines = append(f.lines, "["+table+"]")
+	}
+}
+
+func (f *kclModFile) insert(at int, line string) {
+	f.lines = append(f.lines, "")
+	copy(f.lines[at+1:], f.lines[at:])
+	f.lines[at] = line
+}
+
+func parseTomlKeyValue(line string) (key, value string, ok bool) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "[") {
+		return "", "", false
+	}
+
+	m := tomlKeyValue.FindStringSubmatch(line)
+	if m == nil {
+		return "", "", false
+	}
+
+	key = m[1]
+	if unquoted, err := unquoteTomlString(key); err == nil {
+		key = unquoted
+	}
+	return key, stripTomlComment(m[2]), true
+}
+
+// stripTomlComment removes a trailing comment that is not inside a string.
+func stripTomlComment(value string) string {
+	var quote byte
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote == 0 && c == '#':
+			return strings.TrimSpace(value[:i])
+		}
+	}
+	return strings.TrimSpace(value)
+}
+
+func formatTomlKey(key string) string {
+	if tomlBareKey.MatchString(key) {
+		return key
+	}
+	return tomlString(key)
+}
+
+// tomlString renders s as a TOML basic string. Go's strconv.Quote is no
+// substitute: TOML has no \a, \v or \x escapes.
+func tomlString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			// Other control characters are not allowed in a basic string
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// unquoteTomlString returns the content of a TOML basic or literal string,
+// or an error when raw is some other kind of value.
+func unquoteTomlString(raw string) (string, error) {
+	switch {
+	case len(raw) >= 2 && raw[0] == '\'' && raw[len(raw)-1] == '\'':
+		return raw[1 : len(raw)-1], nil
+	case len(raw) >= 2 && raw[0] == '"' && raw[len(raw)-1] == '"':
+		return unescapeTOML(raw[1:len(raw)-1], false)
+	}
+	return "", fmt.Errorf("not a TOML string: %s", raw)
+}
+
+// dependencySpecToToml renders a dependency spec from configuration. Specs
+// written as inline tables (e.g. `{ git = "...", tag = "v1" }`) are kept
+// verbatim; anything else is treated as a version string.
+func dependencySpecToToml(spec string) string {
+	trimmed := strings.TrimSpace(spec)
+	if strings.HasPrefix(trimmed, "{") && strings.HasSuffix(trimmed, "}") {
+		return trimmed
+	}
+	return tomlString(spec)
+}
+
+// dependencySpecFromToml is the inverse of dependencySpecToToml.
+func dependencySpecFromToml(raw string) string {
+	if s, err := unquoteTomlString(raw); err == nil {
+		return s
+	}
+	return raw
+}
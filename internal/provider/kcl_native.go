@@ -0,0 +1,193 @@
+// internal/provider/kcl_native.go
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Execution backends of kcl_exec, chosen by the provider's backend attribute.
+const (
+	// kclBackendBinary runs the KCL executable as a subprocess.
+	kclBackendBinary = "binary"
+	// kclBackendNative evaluates programs in-process through kcl-go. Only
+	// builds with the kclgo tag include it.
+	kclBackendNative = "native"
+)
+
+var validKclBackends = []string{kclBackendBinary, kclBackendNative}
+
+// nativeKclCommand stands in for the executable path in logs, the command
+// line and the cache key of native runs.
+const nativeKclCommand = "kcl-go"
+
+// errNativeBackendUnavailable is returned by builds without the kclgo tag.
+var errNativeBackendUnavailable = errors.New("this build of the provider does not include the native backend; " +
+	"build it with -tags kclgo, or set backend = \"binary\"")
+
+// nativeRun is a `kcl run` command line mapped onto the options of kcl-go.
+type nativeRun struct {
+	WorkDir string
+	// Files are the positional arguments, the programs to evaluate.
+	Files []string
+	// Arguments are top-level arguments as key=value (-D).
+	Arguments []string
+	// Settings are settings files (-Y).
+	Settings []string
+	// ExternalPkgs are external packages as name=path (-E).
+	ExternalPkgs []string
+	// Selectors are path selectors (-S).
+	Selectors []string
+	// Overrides are program overrides (-O).
+	Overrides []string
+	// DisableNone drops null values (-d) and SortKeys sorts keys (-k).
+	DisableNone bool
+	SortKeys    bool
+	// Format is json, yaml or toml, yaml when empty.
+	Format string
+}
+
+// nativeKclError is a failed native evaluation. Like a KCL process exiting
+// with status 1, its output holds KCL's error report.
+type nativeKclError struct {
+	Message string
+}
+
+func (e *nativeKclError) Error() string {
+	return "KCL evaluation failed: " + e.Message
+}
+
+// nativeValueFlags maps the value flags of `kcl run` the native backend
+// supports to the nativeRun list they append to.
+var nativeValueFlags = map[string]func(*nativeRun) *[]string{
+	"-D": func(r *nativeRun) *[]string { return &r.Arguments }, "--argument": func(r *nativeRun) *[]string { return &r.Arguments },
+	"-Y": func(r *nativeRun) *[]string { return &r.Settings }, "--setting": func(r *nativeRun) *[]string { return &r.Settings },
+	"-E": func(r *nativeRun) *[]string { return &r.ExternalPkgs }, "--external": func(r *nativeRun) *[]string { return &r.ExternalPkgs },
+	"-S": func(r *nativeRun) *[]string { return &r.Selectors }, "--path_selector": func(r *nativeRun) *[]string { return &r.Selectors },
+	"-O": func(r *nativeRun) *[]string { return &r.Overrides }, "--overrides": func(r *nativeRun) *[]string { return &r.Overrides },
+}
+
+// parseNativeArgs maps a `kcl run` argument list, as buildArgs assembles it
+// from args, arguments, settings_files and the other attributes, onto a
+// nativeRun. Flags kcl-go has no option for are an error rather than being
+// dropped silently.
+func parseNativeArgs(args []string, workDir string) (nativeRun, error) {
+	run := nativeRun{WorkDir: workDir}
+	subcommand := ""
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			if subcommand == "" {
+				if subcommand = arg; subcommand != "run" {
+					return nativeRun{}, fmt.Errorf("the native backend only runs `kcl run`, not `kcl %s`", subcommand)
+				}
+				continue
+			}
+			if arg == "-" {
+				return nativeRun{}, errors.New("the native backend cannot read a program from stdin")
+			}
+			run.Files = append(run.Files, arg)
+			continue
+		}
+
+		flag, value, hasValue := strings.Cut(arg, "=")
+		if !strings.HasPrefix(arg, "--") && len(arg) > 2 {
+			// A short flag may carry its value, e.g. -Dkey=value
+			flag, value, hasValue = arg[:2], arg[2:], true
+		}
+		switch {
+		case nativeValueFlags[flag] != nil || flag == "--format":
+			if !hasValue {
+				if i+1 == len(args) {
+					return nativeRun{}, fmt.Errorf("%s requires a value", flag)
+				}
+				i++
+				value = args[i]
+			}
+			if flag == "--format" {
+				run.Format = value
+			} else {
+				list := nativeValueFlags[flag](&run)
+				*list = append(*list, value)
+			}
+		case hasValue:
+			return nativeRun{}, fmt.Errorf("the native backend does not support %s", flag)
+		case flag == "-d" || flag == "--disable_none":
+			run.DisableNone = true
+		case flag == "-k" || flag == "--sort_keys":
+			run.SortKeys = true
+		case flag == "-q" || flag == "--quiet":
+			// Native runs print nothing but the result anyway
+		default:
+			return nativeRun{}, fmt.Errorf("the native backend does not support %s", flag)
+		}
+	}
+	if subcommand == "" {
+		return nativeRun{}, errors.New("the native backend only runs `kcl run`")
+	}
+	switch run.Format {
+	case "", resultFormatJSON, resultFormatYAML, resultFormatTOML:
+	default:
+		return nativeRun{}, fmt.Errorf("unknown format %q", run.Format)
+	}
+	return run, nil
+}
+
+// runNative evaluates a `kcl run` argument list in-process, taking an
+// execution slot like a KCL process would. A failed evaluation is returned
+// as a nativeKclError with KCL's report as output.
+func (p *kclProvider) runNative(ctx context.Context, args []string, workDir string) (commandOutput, error) {
+	run, err := parseNativeArgs(args, workDir)
+	if err != nil {
+		return commandOutput{}, fmt.Errorf("%w; set backend = \"binary\" to run the KCL executable instead", err)
+	}
+	if p != nil && p.executionSlots != nil {
+		select {
+		case p.executionSlots <- struct{}{}:
+			defer func() { <-p.executionSlots }()
+		case <-ctx.Done():
+			return commandOutput{}, fmt.Errorf("waiting for a free KCL execution slot: %w", ctx.Err())
+		}
+	}
+
+	// kcl-go cannot print TOML, so JSON is converted like for older releases
+	format := run.Format
+	if format == resultFormatTOML {
+		run.Format = resultFormatJSON
+	}
+
+	// An in-process evaluation cannot be interrupted, only abandoned
+	type evaluation struct {
+		output string
+		err    error
+	}
+	done := make(chan evaluation, 1)
+	go func() {
+		output, err := evaluateNative(run)
+		done <- evaluation{output, err}
+	}()
+	var result evaluation
+	select {
+	case result = <-done:
+	case <-ctx.Done():
+		return commandOutput{}, ctx.Err()
+	}
+
+	var failed *nativeKclError
+	if errors.As(result.err, &failed) {
+		report := []byte(failed.Message + "\n")
+		return commandOutput{Combined: report, Stderr: report}, result.err
+	}
+	if result.err != nil {
+		return commandOutput{}, result.err
+	}
+	output := result.output
+	if format == resultFormatTOML {
+		if output, err = jsonToTOML(output); err != nil {
+			return commandOutput{}, fmt.Errorf("converting the result to TOML: %w", err)
+		}
+	}
+	return commandOutput{Combined: []byte(output), Stdout: []byte(output)}, nil
+}
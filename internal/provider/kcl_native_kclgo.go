@@ -0,0 +1,47 @@
+// internal/provider/kcl_native_kclgo.go
+
+//go:build kclgo
+
+package provider
+
+import (
+	kcl "kcl-lang.io/kcl-go"
+)
+
+// nativeBackendAvailable reports whether this build includes kcl-go.
+const nativeBackendAvailable = true
+
+// evaluateNative runs run through kcl-go and returns the result in
+// run.Format.
+func evaluateNative(run nativeRun) (string, error) {
+	opts := []kcl.Option{
+		kcl.WithWorkDir(run.WorkDir),
+		kcl.WithOptions(run.Arguments...),
+		kcl.WithExternalPkgs(run.ExternalPkgs...),
+		kcl.WithSelectors(run.Selectors...),
+		kcl.WithOverrides(run.Overrides...),
+		kcl.WithDisableNone(run.DisableNone),
+		kcl.WithSortKeys(run.SortKeys),
+	}
+	for _, settings := range run.Settings {
+		opts = append(opts, kcl.WithSettings(settings))
+	}
+
+	result, err := kcl.RunFiles(run.Files, opts...)
+	if err != nil {
+		return "", &nativeKclError{Message: err.Error()}
+	}
+	if run.Format == resultFormatJSON {
+		return result.GetRawJsonResult(), nil
+	}
+	return result.GetRawYamlResult(), nil
+}
+
+// nativeKclVersion returns the version of the KCL kcl-go embeds.
+func nativeKclVersion() (string, error) {
+	result, err := kcl.GetVersion()
+	if err != nil {
+		return "", err
+	}
+	return result.Version, nil
+}
@@ -0,0 +1,16 @@
+// internal/provider/kcl_native_stub.go
+
+//go:build !kclgo
+
+package provider
+
+// nativeBackendAvailable reports whether this build includes kcl-go.
+const nativeBackendAvailable = false
+
+func evaluateNative(nativeRun) (string, error) {
+	return "", errNativeBackendUnavailable
+}
+
+func nativeKclVersion() (string, error) {
+	return "", errNativeBackendUnavailable
+}
@@ -0,0 +1,109 @@
+// internal/provider/kcl_native_test.go
+package provider
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestParseNativeArgs(t *testing.T) {
+	kclArgsSpec := kclArgs{
+		Subcommand:       "run",
+		EntryFiles:       []string{"a.k", "b.k"},
+		DefaultArgs:      []string{"-k"},
+		Args:             []string{"-Denv=prod", "--path_selector=app"},
+		Arguments:        map[string]string{"replicas": "2"},
+		SettingsFiles:    []string{"kcl.yaml"},
+		ExternalPackages: map[string]string{"k8s": "/pkgs/k8s"},
+		Overrides:        []string{"app.image=\"nginx\""},
+		Flags:            []string{"-d", "-q"},
+		Format:           "json",
+	}
+	got, err := parseNativeArgs(buildArgs(kclArgsSpec), "/work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := nativeRun{
+		WorkDir:      "/work",
+		Files:        []string{"a.k", "b.k"},
+		Arguments:    []string{"env=prod", "replicas=2"},
+		Settings:     []string{"kcl.yaml"},
+		ExternalPkgs: []string{"k8s=/pkgs/k8s"},
+		Selectors:    []string{"app"},
+		Overrides:    []string{"app.image=\"nginx\""},
+		DisableNone:  true,
+		SortKeys:     true,
+		Format:       "json",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseNativeArgs() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseNativeArgs_Unsupported(t *testing.T) {
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{[]string{"vet", "data.json", "schema.k"}, "only runs `kcl run`, not `kcl vet`"},
+		{[]string{"-D", "a=1"}, "only runs `kcl run`"},
+		{[]string{"run", "main.k", "--strict_range_check"}, "does not support --strict_range_check"},
+		{[]string{"run", "main.k", "-o", "out.yaml"}, "does not support -o"},
+		{[]string{"run", "main.k", "--tag=1.0"}, "does not support --tag"},
+		{[]string{"run", "-"}, "cannot read a program from stdin"},
+		{[]string{"run", "main.k", "-D"}, "-D requires a value"},
+		{[]string{"run", "main.k", "--format", "xml"}, `unknown format "xml"`},
+	}
+	for _, tt := range tests {
+		_, err := parseNativeArgs(tt.args, "/work")
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("parseNativeArgs(%q) error = %v, want %q", tt.args, err, tt.wantErr)
+		}
+	}
+}
+
+func TestKclProvider_Backend(t *testing.T) {
+	p, diags := configureProvider(t, nil)
+	if diags.HasError() || p.Backend != kclBackendBinary {
+		t.Fatalf("Configure = %q, %v, want the binary backend by default", p.Backend, diags)
+	}
+
+	_, diags = configureProvider(t, map[string]attr.Value{"backend": types.StringValue("wasm")})
+	if !diags.HasError() || diags.Errors()[0].Summary() != "Invalid Backend" {
+		t.Errorf("Configure diagnostics = %v, want an invalid backend error", diags)
+	}
+
+	if nativeBackendAvailable {
+		t.Skip("this build includes the native backend")
+	}
+	_, diags = configureProvider(t, map[string]attr.Value{"backend": types.StringValue("native")})
+	if !diags.HasError() || diags.Errors()[0].Summary() != "Native Backend Unavailable" ||
+		!strings.Contains(diags.Errors()[0].Detail(), "-tags kclgo") {
+		t.Errorf("Configure diagnostics = %v, want the native backend to be reported as unavailable", diags)
+	}
+}
+
+func TestKclExecResource_NativeBackendUnavailable(t *testing.T) {
+	if nativeBackendAvailable {
+		t.Skip("this build includes the native backend")
+	}
+	h := newExecHarness(t, "")
+	h.resource.provider = &kclProvider{KclPath: "missing-kcl-for-native-test", Backend: kclBackendNative}
+	_, diags := h.apply(map[string]attr.Value{
+		"source_dir": types.StringValue(writeTestFiles(t, map[string]string{"main.k": "a = 1\n"})),
+	})
+	if !diags.HasError() || !strings.Contains(diags.Errors()[0].Detail(), "-tags kclgo") {
+		t.Fatalf("apply diagnostics = %v, want the unavailable native backend to be reported", diags)
+	}
+
+	_, err := h.resource.provider.runNative(context.Background(), []string{"run", "main.k"}, t.TempDir())
+	if !errors.Is(err, errNativeBackendUnavailable) {
+		t.Errorf("runNative() error = %v, want errNativeBackendUnavailable", err)
+	}
+}
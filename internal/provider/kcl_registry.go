@@ -0,0 +1,167 @@
+// internal/provider/kcl_registry.go
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// kclRegistryLoginTimeout bounds how long `kcl registry login` may take.
+const kclRegistryLoginTimeout = 60 * time.Second
+
+// kclRegistryLogin stores credentials for registry with `kcl registry
+// login`, so later runs that pull packages from it are authenticated. The
+// password is masked in provider logs and never passed as an argument,
+// where other local users could read it from the process list: KCL prompts
+// for it instead, and the prompt is answered on stdin.
+func kclRegistryLogin(ctx context.Context, p *kclProvider, kclCommand, registry, username, password string) error {
+	ctx = tflog.MaskLogStrings(ctx, password)
+
+	ctx, cancel := context.WithTimeout(ctx, kclRegistryLoginTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, kclCommand, "registry", "login", registry, "--username", username)
+	cmd.Stdin = strings.NewReader(password + "\n")
+	configureGracefulStop(cmd)
+
+	tflog.Info(ctx, "Logging in to KCL registry", map[string]interface{}{
+		"command":  kclCommand,
+		"registry": registry,
+		"username": username,
+	})
+
+	result, err := p.runKcl(ctx, cmd)
+	if err != nil {
+		output := strings.ReplaceAll(strings.TrimSpace(string(result.Combined)), password, "***")
+		return fmt.Errorf("%s registry login %s: %w\nOutput: %s", kclCommand, registry, err, output)
+	}
+	return nil
+}
+
+// tomlPathDependency matches a dependency spec pointing at a local
+// directory, which is never pulled from a registry.
+var tomlPathDependency = regexp.MustCompile(`\bpath\s*=`)
+
+// kclModPackages returns the dependencies the kcl.mod in dir declares that
+// KCL may pull, as sorted "name=spec" keys. A directory without kcl.mod
+// yields none.
+func kclModPackages(dir string) []string {
+	if dir == "" {
+		return nil
+	}
+	content, err := os.ReadFile(filepath.Join(dir, kclModFileName))
+	if err != nil {
+		return nil
+	}
+
+	var packages []string
+	for name, spec := range parseKclMod(string(content)).entries("dependencies") {
+		if !tomlPathDependency.MatchString(spec) {
+			packages = append(packages, name+"="+spec)
+		}
+	}
+	sort.Strings(packages)
+	return packages
+}
+
+// acquirePackages waits until the packages not fetched before are free to
+// fetch: no other run is fetching them and, with registry_concurrency, a
+// registry slot is free. The returned release must be called once the run
+// is over, with whether it succeeded and so fetched the packages.
+func (p *kclProvider) acquirePackages(ctx context.Context, packages []string) (func(fetched bool), error) {
+	pending := p.pendingPackages(packages)
+	if len(pending) == 0 {
+		return func(bool) {}, nil
+	}
+
+	// Lock in sorted order, so runs sharing packages cannot deadlock
+	var held []chan struct{}
+	unlock := func() {
+		for _, lock := range held {
+			<-lock
+		}
+	}
+	for _, pkg := range pending {
+		lock := p.packageLock(pkg)
+		select {
+		case lock <- struct{}{}:
+			held = append(held, lock)
+		case <-ctx.Done():
+			unlock()
+			return nil, fmt.Errorf("waiting for another KCL run to fetch %s: %w", pkg, ctx.Err())
+		}
+	}
+
+	// The runs waited for may have fetched everything already
+	if len(p.pendingPackages(pending)) == 0 {
+		unlock()
+		return func(bool) {}, nil
+	}
+
+	if p.registrySlots != nil {
+		select {
+		case p.registrySlots <- struct{}{}:
+		case <-ctx.Done():
+			unlock()
+			return nil, fmt.Errorf("waiting for a free registry slot: %w", ctx.Err())
+		}
+	}
+
+	tflog.Debug(ctx, "Fetching KCL packages", map[string]interface{}{
+		"packages": pending,
+	})
+
+	return func(fetched bool) {
+		if p.registrySlots != nil {
+			<-p.registrySlots
+		}
+		if fetched {
+			p.packageMu.Lock()
+			for _, pkg := range pending {
+				p.fetchedPackages[pkg] = true
+			}
+			p.packageMu.Unlock()
+		}
+		unlock()
+	}, nil
+}
+
+// pendingPackages returns the packages no run has fetched yet, in order.
+func (p *kclProvider) pendingPackages(packages []string) []string {
+	p.packageMu.Lock()
+	defer p.packageMu.Unlock()
+
+	var pending []string
+	for _, pkg := range packages {
+		if !p.fetchedPackages[pkg] {
+			pending = append(pending, pkg)
+		}
+	}
+	return pending
+}
+
+// packageLock returns the lock of pkg, held while a run fetches it.
+func (p *kclProvider) packageLock(pkg string) chan struct{} {
+	p.packageMu.Lock()
+	defer p.packageMu.Unlock()
+
+	if p.packageLocks == nil {
+		p.packageLocks = make(map[string]chan struct{})
+		p.fetchedPackages = make(map[string]bool)
+	}
+	lock, ok := p.packageLocks[pkg]
+	if !ok {
+		lock = make(chan struct{}, 1)
+		p.packageLocks[pkg] = lock
+	}
+	return lock
+}
@@ -0,0 +1,48 @@
+// internal/provider/kcl_registry_test.go
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestKclRegistryLogin_PasswordNotInArguments(t *testing.T) {
+	record := t.TempDir()
+	kcl := fakeKcl(t, `echo "$@" > "`+filepath.Join(record, "args")+`"
+cat > "`+filepath.Join(record, "stdin")+`"`)
+
+	const password = "s3cr3t-token"
+	if err := kclRegistryLogin(context.Background(), &kclProvider{}, kcl, "ghcr.io", "robot", password); err != nil {
+		t.Fatal(err)
+	}
+
+	args, err := os.ReadFile(filepath.Join(record, "args"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.TrimSpace(string(args)), "registry login ghcr.io --username robot"; got != want {
+		t.Errorf("arguments = %q, want %q", got, want)
+	}
+	stdin, err := os.ReadFile(filepath.Join(record, "stdin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(stdin); got != password+"\n" {
+		t.Errorf("stdin = %q, want the password", got)
+	}
+}
+
+func TestKclRegistryLogin_FailureMasksPassword(t *testing.T) {
+	kcl := fakeKcl(t, `read password; echo "login with $password denied"; exit 1`)
+
+	err := kclRegistryLogin(context.Background(), &kclProvider{}, kcl, "ghcr.io", "robot", "s3cr3t-token")
+	if err == nil {
+		t.Fatal("a failed login succeeded")
+	}
+	if strings.Contains(err.Error(), "s3cr3t-token") || !strings.Contains(err.Error(), "login with *** denied") {
+		t.Errorf("error = %q, want the password masked", err)
+	}
+}
@@ -0,0 +1,192 @@
+// internal/provider/kcl_render.go
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var (
+	_ datasource.DataSource              = &KclRenderDataSource{}
+	_ datasource.DataSourceWithConfigure = &KclRenderDataSource{}
+)
+
+func NewKclRenderDataSource() datasource.DataSource {
+	return &KclRenderDataSource{}
+}
+
+type KclRenderDataSource struct {
+	provider *kclProvider
+}
+
+type KclRenderDataSourceModel struct {
+	SourceDir types.String  `tfsdk:"source_dir"`
+	Variables types.Dynamic `tfsdk:"variables"`
+	Timeout   types.Int64   `tfsdk:"timeout"`
+	Result    types.String  `tfsdk:"result"`
+}
+
+func (d *KclRenderDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_render"
+}
+
+func (d *KclRenderDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Renders a KCL program with variables supplied from Terraform and returns the result as JSON",
+
+		Attributes: map[string]schema.Attribute{
+			"source_dir": schema.StringAttribute{
+				Required:            true,
+				MarkdownDescription: "Path to directory containing KCL scripts",
+			},
+			"variables": schema.DynamicAttribute{
+				Optional: true,
+				MarkdownDescription: "Object whose top-level keys are passed to the program as top-level arguments (`-D key=<json>`), " +
+					"readable with `option(\"key\")`. Values keep their Terraform types through JSON encoding",
+			},
+			"timeout": schema.Int64Attribute{
+				Optional:            true,
+				MarkdownDescription: "Execution timeout in seconds (default: 300)",
+			},
+			"result": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Rendered result as a JSON document, suitable for `jsondecode()`",
+			},
+		},
+	}
+}
+
+func (d *KclRenderDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	provider, ok := req.ProviderData.(*kclProvider)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Provider Data Type",
+			fmt.Sprintf("Expected *kclProvider, got: %T", req.ProviderData),
+		)
+		return
+	}
+
+	d.provider = provider
+}
+
+func (d *KclRenderDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var config KclRenderDataSourceModel
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	absPath, err := filepath.Abs(config.SourceDir.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Path Resolution Error", "Invalid source directory path: "+err.Error())
+		return
+	}
+	if _, err := os.Stat(absPath); os.IsNotExist(err) {
+		resp.Diagnostics.AddError("Directory Not Found", "Source directory does not exist: "+absPath)
+		return
+	}
+
+	args := []string{"run", "--format", "json"}
+	variableArgs, err := renderVariableArgs(config.Variables)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("variables"), "Invalid Variables", err.Error())
+		return
+	}
+	args = append(args, variableArgs...)
+
+	timeout := 300 * time.Second
+	if !config.Timeout.IsNull() {
+		timeout = time.Duration(config.Timeout.ValueInt64()) * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	kclCommand, err := d.provider.resolveKclCommand()
+	if err != nil {
+		resp.Diagnostics.AddError("KCL Executable Not Found", err.Error())
+		return
+	}
+	cmd := exec.CommandContext(ctx, kclCommand, args...)
+	cmd.Dir = absPath
+	configureGracefulStop(cmd)
+
+	tflog.Info(ctx, "Rendering KCL program", map[string]interface{}{
+		"command":   kclCommand,
+		"arguments": args,
+		"directory": absPath,
+	})
+
+	output, err := d.provider.runKcl(ctx, cmd)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"KCL Render Failed",
+			fmt.Sprintf("Command: %s %s\nError: %v\nOutput: %s",
+				kclCommand, strings.Join(args, " "), err, string(output.Combined)),
+		)
+		return
+	}
+
+	result := strings.TrimSpace(string(output.Stdout))
+	if !json.Valid([]byte(result)) {
+		resp.Diagnostics.AddError("Invalid KCL Result", "KCL did not produce valid JSON:\n"+result)
+		return
+	}
+	config.Result = types.StringValue(result)
+
+	diags = resp.State.Set(ctx, config)
+	resp.Diagnostics.Append(diags...)
+}
+
+// renderVariableArgs turns an object of variables into -D key=<json> top-level
+// arguments, sorted by key so the command line is deterministic.
+func renderVariableArgs(variables basetypes.DynamicValue) ([]string, error) {
+	if variables.IsNull() || variables.IsUnderlyingValueNull() {
+		return nil, nil
+	}
+
+	value, err := attrValueToJSON(variables)
+	if err != nil {
+		return nil, err
+	}
+	vars, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("variables must be an object or map, got %s", variables.UnderlyingValue().Type(context.Background()))
+	}
+
+	keys := make([]string, 0, len(vars))
+	for k := range vars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	args := make([]string, 0, 2*len(keys))
+	for _, k := range keys {
+		encoded, err := json.Marshal(vars[k])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", k, err)
+		}
+		args = append(args, "-D", k+"="+string(encoded))
+	}
+	return args, nil
+}
@@ -0,0 +1,84 @@
+// internal/provider/kcl_render_test.go
+package provider
+
+import (
+	"context"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestRenderVariableArgs(t *testing.T) {
+	bigNumber := func(s string) types.Number {
+		f, _, err := big.ParseFloat(s, 10, 512, big.ToNearestEven)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return types.NumberValue(f)
+	}
+	object := func(attrs map[string]attr.Value) types.Dynamic {
+		attrTypes := make(map[string]attr.Type, len(attrs))
+		for k, v := range attrs {
+			attrTypes[k] = v.Type(context.Background())
+		}
+		return types.DynamicValue(types.ObjectValueMust(attrTypes, attrs))
+	}
+
+	tests := []struct {
+		name      string
+		variables types.Dynamic
+		want      []string
+	}{
+		{"null", types.DynamicNull(), nil},
+		{"string", object(map[string]attr.Value{"s": types.StringValue(`say "hi"`)}), []string{"-D", `s="say \"hi\""`}},
+		{"numeric string stays a string", object(map[string]attr.Value{"s": types.StringValue("42")}), []string{"-D", `s="42"`}},
+		{"bool", object(map[string]attr.Value{"b": types.BoolValue(true)}), []string{"-D", "b=true"}},
+		{"integer", object(map[string]attr.Value{"n": bigNumber("3")}), []string{"-D", "n=3"}},
+		{"large integer", object(map[string]attr.Value{"n": bigNumber("9007199254740993")}), []string{"-D", "n=9007199254740993"}},
+		{"negative integer", object(map[string]attr.Value{"n": bigNumber("-42")}), []string{"-D", "n=-42"}},
+		{"float", object(map[string]attr.Value{"n": bigNumber("1.5")}), []string{"-D", "n=1.5"}},
+		{"int64 and float64", object(map[string]attr.Value{"i": types.Int64Value(7), "f": types.Float64Value(0.25)}), []string{"-D", "f=0.25", "-D", "i=7"}},
+		{"null attribute", object(map[string]attr.Value{"z": types.StringNull()}), []string{"-D", "z=null"}},
+		{
+			"list and tuple",
+			object(map[string]attr.Value{
+				"l": types.ListValueMust(types.StringType, []attr.Value{types.StringValue("a"), types.StringValue("b")}),
+				"t": types.TupleValueMust([]attr.Type{types.StringType, types.BoolType}, []attr.Value{types.StringValue("x"), types.BoolValue(false)}),
+			}),
+			[]string{"-D", `l=["a","b"]`, "-D", `t=["x",false]`},
+		},
+		{
+			"nested object and map",
+			object(map[string]attr.Value{
+				"o": types.ObjectValueMust(
+					map[string]attr.Type{"name": types.StringType, "port": types.NumberType},
+					map[string]attr.Value{"name": types.StringValue("web"), "port": bigNumber("8080")},
+				),
+				"m": types.MapValueMust(types.StringType, map[string]attr.Value{"k": types.StringValue("v")}),
+			}),
+			[]string{"-D", `m={"k":"v"}`, "-D", `o={"name":"web","port":8080}`},
+		},
+		{"map of variables", types.DynamicValue(types.MapValueMust(types.BoolType, map[string]attr.Value{"b": types.BoolValue(false), "a": types.BoolValue(true)})), []string{"-D", "a=true", "-D", "b=false"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderVariableArgs(tt.variables)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("renderVariableArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := renderVariableArgs(types.DynamicValue(types.StringValue("x"))); err == nil {
+		t.Errorf("renderVariableArgs of a string succeeded")
+	}
+	if _, err := renderVariableArgs(types.DynamicValue(types.ListValueMust(types.StringType, nil))); err == nil {
+		t.Errorf("renderVariableArgs of a list succeeded")
+	}
+}
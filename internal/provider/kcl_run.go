@@ -0,0 +1,182 @@
+// internal/provider/kcl_run.go
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var (
+	_ datasource.DataSource              = &KclRunDataSource{}
+	_ datasource.DataSourceWithConfigure = &KclRunDataSource{}
+)
+
+func NewKclRunDataSource() datasource.DataSource {
+	return &KclRunDataSource{}
+}
+
+type KclRunDataSource struct {
+	provider *kclProvider
+}
+
+type KclRunDataSourceModel struct {
+	SourceDir   types.String `tfsdk:"source_dir"`
+	Args        types.List   `tfsdk:"args"`
+	Environment types.Map    `tfsdk:"environment"`
+	Timeout     types.Int64  `tfsdk:"timeout"`
+	Output      types.String `tfsdk:"output"`
+	Stdout      types.String `tfsdk:"stdout"`
+	Stderr      types.String `tfsdk:"stderr"`
+}
+
+func (d *KclRunDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_run"
+}
+
+func (d *KclRunDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Evaluates a KCL program with `kcl run` on every plan, for read-only use of the rendered output",
+
+		Attributes: map[string]schema.Attribute{
+			"source_dir": schema.StringAttribute{
+				Required:            true,
+				MarkdownDescription: "Path to directory containing KCL scripts",
+			},
+			"args": schema.ListAttribute{
+				ElementType:         types.StringType,
+				Optional:            true,
+				MarkdownDescription: "Additional arguments passed to `kcl run`",
+			},
+			"environment": schema.MapAttribute{
+				ElementType:         types.StringType,
+				Optional:            true,
+				MarkdownDescription: "Environment variables to set during execution",
+			},
+			"timeout": schema.Int64Attribute{
+				Optional:            true,
+				MarkdownDescription: "Execution timeout in seconds (default: 300)",
+			},
+			"output": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Combined standard output and error from KCL execution",
+			},
+			"stdout": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Standard output from KCL execution",
+			},
+			"stderr": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Standard error from KCL execution",
+			},
+		},
+	}
+}
+
+func (d *KclRunDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	provider, ok := req.ProviderData.(*kclProvider)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Provider Data Type",
+			fmt.Sprintf("Expected *kclProvider, got: %T", req.ProviderData),
+		)
+		return
+	}
+
+	d.provider = provider
+}
+
+func (d *KclRunDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var config KclRunDataSourceModel
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	absPath, err := filepath.Abs(config.SourceDir.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Path Resolution Error", "Invalid source directory path: "+err.Error())
+		return
+	}
+	if _, err := os.Stat(absPath); os.IsNotExist(err) {
+		resp.Diagnostics.AddError("Directory Not Found", "Source directory does not exist: "+absPath)
+		return
+	}
+
+	args := []string{"run"}
+	if !config.Args.IsNull() {
+		var userArgs []string
+		diags := config.Args.ElementsAs(ctx, &userArgs, false)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		args = append(args, userArgs...)
+	}
+
+	envMap := make(map[string]string)
+	if !config.Environment.IsNull() {
+		diags := config.Environment.ElementsAs(ctx, &envMap, false)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	timeout := 300 * time.Second
+	if !config.Timeout.IsNull() {
+		timeout = time.Duration(config.Timeout.ValueInt64()) * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, effectiveTimeout(ctx, timeout))
+	defer cancel()
+
+	kclCommand, err := d.provider.resolveKclCommand()
+	if err != nil {
+		resp.Diagnostics.AddError("KCL Executable Not Found", err.Error())
+		return
+	}
+	cmd := exec.CommandContext(ctx, kclCommand, args...)
+	cmd.Dir = absPath
+	cmd.Env = append(d.provider.kclEnvironment(), sortedEnv(envMap)...)
+	configureGracefulStop(cmd)
+
+	tflog.Info(ctx, "Executing KCL command", map[string]interface{}{
+		"command":   kclCommand,
+		"arguments": args,
+		"directory": absPath,
+		"timeout":   timeout,
+	})
+
+	result, err := d.provider.runKcl(ctx, cmd)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"KCL Execution Failed",
+			fmt.Sprintf("Command: %s %s\nError: %v\nOutput: %s",
+				kclCommand, strings.Join(args, " "), err, string(result.Combined)),
+		)
+		return
+	}
+
+	config.Output = types.StringValue(strings.TrimSpace(string(result.Combined)))
+	config.Stdout = types.StringValue(strings.TrimSpace(string(result.Stdout)))
+	config.Stderr = types.StringValue(strings.TrimSpace(string(result.Stderr)))
+
+	diags = resp.State.Set(ctx, config)
+	resp.Diagnostics.Append(diags...)
+}
@@ -0,0 +1,61 @@
+// internal/provider/kcl_run_test.go
+package provider
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func newKclRunDataSource(kclBinary string) *KclRunDataSource {
+	return &KclRunDataSource{provider: &kclProvider{KclPath: kclBinary, KclBinary: kclBinary}}
+}
+
+func TestKclRunDataSource_Read(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{"main.k": "name = \"web\"\n"})
+	kcl := fakeKcl(t, `echo "args: $*"
+echo "stage: $STAGE"
+echo "warning: unused" >&2
+cat main.k`)
+
+	var model KclRunDataSourceModel
+	diags := readDataSource(t, newKclRunDataSource(kcl), map[string]attr.Value{
+		"source_dir":  types.StringValue(dir),
+		"args":        types.ListValueMust(types.StringType, []attr.Value{types.StringValue("-D"), types.StringValue("env=prod")}),
+		"environment": types.MapValueMust(types.StringType, map[string]attr.Value{"STAGE": types.StringValue("blue")}),
+	}, &model)
+	if diags.HasError() {
+		t.Fatalf("read: %v", diags)
+	}
+	if want := "args: run -D env=prod\nstage: blue\nname = \"web\""; model.Stdout.ValueString() != want {
+		t.Errorf("stdout = %q, want %q", model.Stdout.ValueString(), want)
+	}
+	if got := model.Stderr.ValueString(); got != "warning: unused" {
+		t.Errorf("stderr = %q, want the warning", got)
+	}
+	if got := model.Output.ValueString(); !strings.Contains(got, "warning: unused") || !strings.Contains(got, "stage: blue") {
+		t.Errorf("output = %q, want stdout and stderr combined", got)
+	}
+}
+
+func TestKclRunDataSource_Errors(t *testing.T) {
+	diags := readDataSource(t, newKclRunDataSource(fakeKcl(t, "exit 0")), map[string]attr.Value{
+		"source_dir": types.StringValue(filepath.Join(t.TempDir(), "missing")),
+	}, nil)
+	if !diags.HasError() || diags.Errors()[0].Summary() != "Directory Not Found" {
+		t.Errorf("read diagnostics = %v, want Directory Not Found", diags)
+	}
+
+	diags = readDataSource(t, newKclRunDataSource(fakeKcl(t, `echo "error[E2L23]: CompileError" >&2; exit 1`)), map[string]attr.Value{
+		"source_dir": types.StringValue(t.TempDir()),
+	}, nil)
+	if !diags.HasError() || diags.Errors()[0].Summary() != "KCL Execution Failed" {
+		t.Fatalf("read diagnostics = %v, want KCL Execution Failed", diags)
+	}
+	if detail := diags.Errors()[0].Detail(); !strings.Contains(detail, "CompileError") {
+		t.Errorf("detail = %q, want KCL's error output", detail)
+	}
+}
@@ -0,0 +1,403 @@
+// internal/provider/kcl_test_source.go
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+var (
+	// kclTestResultLine matches a test outcome printed by `kcl test`, e.g.
+	// "test_app_name: PASS (2ms)".
+	kclTestResultLine = regexp.MustCompile(`^(\S+):\s+(PASS|FAIL)\s+\((\d+(?:\.\d+)?)\s*(ns|us|µs|ms|s)\)`)
+
+	// kclTestSeparator matches the rule `kcl test` prints before its summary.
+	kclTestSeparator = regexp.MustCompile(`^-{10,}$`)
+
+	// kclNoTests matches the notice `kcl test` prints when nothing was found.
+	kclNoTests = regexp.MustCompile(`(?i)no test (files|suites?)`)
+
+	// kclTotalCoverageLine matches the overall coverage `kcl test --cover`
+	// prints, e.g. "coverage: 85.7% of statements" or "total: 85.7%".
+	kclTotalCoverageLine = regexp.MustCompile(`(?i)^(?:total(?:\s+coverage)?|coverage)\s*:?\s+(\d+(?:\.\d+)?)%`)
+
+	// kclFileCoverageLine matches the coverage of a single file, e.g.
+	// "main.k: 80.0%".
+	kclFileCoverageLine = regexp.MustCompile(`^(\S+\.k)\s*:?\s+(\d+(?:\.\d+)?)%`)
+
+	// kclCoverFlagRejected matches the error of a KCL version without
+	// --cover.
+	kclCoverFlagRejected = regexp.MustCompile(`(?i)(unexpected|unknown|unrecognized|unsupported|invalid)[^\n]*--cover`)
+)
+
+// kclCoverFlag makes `kcl test` report coverage.
+const kclCoverFlag = "--cover"
+
+// kclTestResult is the outcome of a single KCL test.
+type kclTestResult struct {
+	Name       string `tfsdk:"name"`
+	Passed     bool   `tfsdk:"passed"`
+	DurationMs int64  `tfsdk:"duration_ms"`
+	Message    string `tfsdk:"message"`
+}
+
+var kclTestResultAttrTypes = map[string]attr.Type{
+	"name":        types.StringType,
+	"passed":      types.BoolType,
+	"duration_ms": types.Int64Type,
+	"message":     types.StringType,
+}
+
+// Ensure provider defined types fully satisfy framework interfaces
+var (
+	_ datasource.DataSource              = &KclTestDataSource{}
+	_ datasource.DataSourceWithConfigure = &KclTestDataSource{}
+)
+
+func NewKclTestDataSource() datasource.DataSource {
+	return &KclTestDataSource{}
+}
+
+type KclTestDataSource struct {
+	provider *kclProvider
+}
+
+type KclTestDataSourceModel struct {
+	SourceDir     types.String `tfsdk:"source_dir"`
+	Run           types.String `tfsdk:"run"`
+	Timeout       types.Int64  `tfsdk:"timeout"`
+	FailOnFailure types.Bool   `tfsdk:"fail_on_failure"`
+	Coverage      types.Bool   `tfsdk:"coverage"`
+	Tests         types.List   `tfsdk:"tests"`
+	Passed        types.Int64  `tfsdk:"passed"`
+	Failed        types.Int64  `tfsdk:"failed"`
+	AllPassed     types.Bool   `tfsdk:"all_passed"`
+
+	CoveragePercent types.Float64 `tfsdk:"coverage_percent"`
+	FileCoverage    types.Map     `tfsdk:"file_coverage"`
+
+	Output types.String `tfsdk:"output"`
+}
+
+func (d *KclTestDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_test"
+}
+
+func (d *KclTestDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Runs the KCL tests below a directory with `kcl test` and exposes the result of each test. " +
+			"Failing tests do not fail the plan unless `fail_on_failure` is set",
+
+		Attributes: map[string]schema.Attribute{
+			"source_dir": schema.StringAttribute{
+				Required:            true,
+				MarkdownDescription: "Path to directory containing KCL tests, searched recursively",
+			},
+			"run": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Regular expression selecting the tests to run (`--run`)",
+			},
+			"timeout": schema.Int64Attribute{
+				Optional:            true,
+				MarkdownDescription: "Test timeout in seconds (default: 300)",
+			},
+			"fail_on_failure": schema.BoolAttribute{
+				Optional:            true,
+				MarkdownDescription: "Fail the data source when any test fails (default: false)",
+			},
+			"coverage": schema.BoolAttribute{
+				Optional: true,
+				MarkdownDescription: "Collect coverage by passing `" + kclCoverFlag + "` (default: false). A KCL version " +
+					"without the flag runs the tests without coverage and adds a warning",
+			},
+			"tests": schema.ListNestedAttribute{
+				Computed:            true,
+				MarkdownDescription: "Result of every test, in the order reported",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"name": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "Test name",
+						},
+						"passed": schema.BoolAttribute{
+							Computed:            true,
+							MarkdownDescription: "Whether the test passed",
+						},
+						"duration_ms": schema.Int64Attribute{
+							Computed:            true,
+							MarkdownDescription: "Test duration in milliseconds",
+						},
+						"message": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "Failure message, empty for passing tests",
+						},
+					},
+				},
+			},
+			"passed": schema.Int64Attribute{
+				Computed:            true,
+				MarkdownDescription: "Number of passing tests",
+			},
+			"failed": schema.Int64Attribute{
+				Computed:            true,
+				MarkdownDescription: "Number of failing tests",
+			},
+			"all_passed": schema.BoolAttribute{
+				Computed:            true,
+				MarkdownDescription: "Whether no test failed. True when there are no tests",
+			},
+			"coverage_percent": schema.Float64Attribute{
+				Computed: true,
+				MarkdownDescription: "Overall coverage in percent, e.g. for a `precondition` enforcing a threshold. Null unless " +
+					"`coverage` is set and KCL reported it",
+			},
+			"file_coverage": schema.MapAttribute{
+				ElementType: types.Float64Type,
+				Computed:    true,
+				MarkdownDescription: "Coverage in percent keyed by file, as KCL names them. Null unless `coverage` is set and " +
+					"KCL reported it per file",
+			},
+			"output": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Combined standard output and error of `kcl test`",
+			},
+		},
+	}
+}
+
+func (d *KclTestDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	provider, ok := req.ProviderData.(*kclProvider)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Provider Data Type",
+			fmt.Sprintf("Expected *kclProvider, got: %T", req.ProviderData),
+		)
+		return
+	}
+
+	d.provider = provider
+}
+
+func (d *KclTestDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var config KclTestDataSourceModel
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	absPath, err := filepath.Abs(config.SourceDir.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Path Resolution Error", "Invalid source directory path: "+err.Error())
+		return
+	}
+	if _, err := os.Stat(absPath); os.IsNotExist(err) {
+		resp.Diagnostics.AddError("Directory Not Found", "Source directory does not exist: "+absPath)
+		return
+	}
+
+	args := []string{"test", "./..."}
+	if !config.Run.IsNull() {
+		args = append(args, "--run", config.Run.ValueString())
+	}
+	if config.Coverage.ValueBool() {
+		args = append(args, kclCoverFlag)
+	}
+
+	timeout := 300 * time.Second
+	if !config.Timeout.IsNull() {
+		timeout = time.Duration(config.Timeout.ValueInt64()) * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, effectiveTimeout(ctx, timeout))
+	defer cancel()
+
+	kclCommand, err := d.provider.resolveKclCommand()
+	if err != nil {
+		resp.Diagnostics.AddError("KCL Executable Not Found", err.Error())
+		return
+	}
+	runTests := func() (commandOutput, error) {
+		cmd := exec.CommandContext(ctx, kclCommand, args...)
+		cmd.Dir = absPath
+		configureGracefulStop(cmd)
+
+		tflog.Info(ctx, "Running KCL tests", map[string]interface{}{
+			"command":   kclCommand,
+			"arguments": args,
+			"directory": absPath,
+			"timeout":   timeout,
+		})
+		return d.provider.runKcl(ctx, cmd)
+	}
+
+	result, err := runTests()
+	output := strings.TrimSpace(string(result.Combined))
+
+	// Older KCL versions reject the coverage flag, so run without it
+	if err != nil && config.Coverage.ValueBool() && kclCoverFlagRejected.MatchString(output) {
+		resp.Diagnostics.AddAttributeWarning(path.Root("coverage"), "Coverage Not Supported",
+			"The KCL executable does not support "+kclCoverFlag+", so the tests ran without coverage.")
+		args = args[:len(args)-1]
+		result, err = runTests()
+		output = strings.TrimSpace(string(result.Combined))
+	}
+
+	// Failing tests exit non-zero; anything else going wrong is an error.
+	// Finding no tests at all is not.
+	exitCode, runErr := exitCodeOf(err)
+	tests := parseKclTestOutput(output)
+	noTests := len(tests) == 0 && kclNoTests.MatchString(output)
+	if runErr != nil || ctx.Err() != nil || (exitCode != 0 && len(tests) == 0 && !noTests) {
+		resp.Diagnostics.AddError(
+			"KCL Test Failed To Run",
+			fmt.Sprintf("Command: %s %s\nError: %v\nOutput: %s",
+				kclCommand, strings.Join(args, " "), err, output),
+		)
+		return
+	}
+
+	var passed, failed int64
+	for _, t := range tests {
+		if t.Passed {
+			passed++
+			continue
+		}
+		failed++
+		if config.FailOnFailure.ValueBool() {
+			resp.Diagnostics.AddError("KCL Test Failed", t.Name+": "+t.Message)
+		}
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	config.Tests, diags = types.ListValueFrom(ctx, types.ObjectType{AttrTypes: kclTestResultAttrTypes}, tests)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	config.Passed = types.Int64Value(passed)
+	config.Failed = types.Int64Value(failed)
+	config.AllPassed = types.BoolValue(failed == 0)
+	config.Output = types.StringValue(output)
+
+	config.CoveragePercent = types.Float64Null()
+	config.FileCoverage = types.MapNull(types.Float64Type)
+	if config.Coverage.ValueBool() {
+		total, files := parseKclCoverage(output)
+		if total != nil {
+			config.CoveragePercent = types.Float64Value(*total)
+		}
+		if len(files) > 0 {
+			config.FileCoverage, diags = types.MapValueFrom(ctx, types.Float64Type, files)
+			resp.Diagnostics.Append(diags...)
+			if resp.Diagnostics.HasError() {
+				return
+			}
+		}
+	}
+
+	diags = resp.State.Set(ctx, config)
+	resp.Diagnostics.Append(diags...)
+}
+
+// parseKclTestOutput extracts test results from `kcl test` output. Lines
+// following a result, up to the next result or the summary rule, form the
+// failure message of that test.
+func parseKclTestOutput(output string) []kclTestResult {
+	tests := []kclTestResult{}
+	var (
+		message    []string
+		collecting bool
+	)
+	flush := func() {
+		if collecting && !tests[len(tests)-1].Passed {
+			tests[len(tests)-1].Message = strings.TrimSpace(strings.Join(message, "\n"))
+		}
+		message = nil
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if m := kclTestResultLine.FindStringSubmatch(line); m != nil {
+			flush()
+			tests = append(tests, kclTestResult{
+				Name:       m[1],
+				Passed:     m[2] == "PASS",
+				DurationMs: durationMillis(m[3], m[4]),
+			})
+			collecting = true
+			continue
+		}
+		if kclTestSeparator.MatchString(strings.TrimSpace(line)) {
+			flush()
+			collecting = false
+			continue
+		}
+		if collecting {
+			message = append(message, line)
+		}
+	}
+	flush()
+
+	return tests
+}
+
+// parseKclCoverage extracts the overall and per-file coverage from `kcl
+// test --cover` output. total is nil and files empty when the output holds
+// none, e.g. for a KCL version that accepts the flag but reports nothing.
+func parseKclCoverage(output string) (total *float64, files map[string]float64) {
+	files = make(map[string]float64)
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if m := kclTotalCoverageLine.FindStringSubmatch(line); m != nil {
+			if v, err := strconv.ParseFloat(m[1], 64); err == nil {
+				total = &v
+			}
+			continue
+		}
+		if m := kclFileCoverageLine.FindStringSubmatch(line); m != nil {
+			if v, err := strconv.ParseFloat(m[2], 64); err == nil {
+				files[m[1]] = v
+			}
+		}
+	}
+	return total, files
+}
+
+// durationMillis converts a duration printed as value and unit to whole
+// milliseconds.
+func durationMillis(value, unit string) int64 {
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0
+	}
+	switch unit {
+	case "ns":
+		v /= 1e6
+	case "us", "µs":
+		v /= 1e3
+	case "s":
+		v *= 1e3
+	}
+	return int64(v)
+}
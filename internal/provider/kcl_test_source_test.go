@@ -0,0 +1,149 @@
+// internal/provider/kcl_test_source_test.go
+package provider
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// testKcl is a fake KCL whose test records its arguments in the file args
+// and prints one passing and one failing test. Coverage is only reported
+// when --cover is passed.
+const testKcl = `[ "$1" = test ] || exit 1
+echo "$*" > args
+echo "test_app_name: PASS (2ms)"
+echo "test_app_replicas: FAIL (1.5s)"
+echo "  replicas must be positive"
+echo "----------------------------------------"
+case "$*" in *--cover*)
+  echo "main.k: 80.0%"
+  echo "total coverage: 85.7%"
+esac
+echo "1 passed, 1 failed"
+exit 1`
+
+func newKclTestDataSource(kclBinary string) *KclTestDataSource {
+	return &KclTestDataSource{provider: &kclProvider{KclPath: kclBinary, KclBinary: kclBinary}}
+}
+
+func TestKclTestDataSource_Results(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{"main_test.k": "test_app_name = lambda {}\n"})
+	d := newKclTestDataSource(fakeKcl(t, testKcl))
+
+	var model KclTestDataSourceModel
+	diags := readDataSource(t, d, map[string]attr.Value{
+		"source_dir": types.StringValue(dir),
+		"run":        types.StringValue("test_app"),
+		"coverage":   types.BoolValue(true),
+	}, &model)
+	if diags.HasError() {
+		t.Fatalf("read: %v", diags)
+	}
+	if got := readTestFile(t, filepath.Join(dir, "args")); got != "test ./... --run test_app --cover\n" {
+		t.Errorf("arguments = %q", got)
+	}
+	if model.Passed.ValueInt64() != 1 || model.Failed.ValueInt64() != 1 || model.AllPassed.ValueBool() {
+		t.Errorf("passed = %s, failed = %s, all_passed = %s, want one of each", model.Passed, model.Failed, model.AllPassed)
+	}
+	var tests []kclTestResult
+	if diags := model.Tests.ElementsAs(context.Background(), &tests, false); diags.HasError() {
+		t.Fatal(diags)
+	}
+	want := []kclTestResult{
+		{Name: "test_app_name", Passed: true, DurationMs: 2},
+		{Name: "test_app_replicas", DurationMs: 1500, Message: "replicas must be positive"},
+	}
+	if !reflect.DeepEqual(tests, want) {
+		t.Errorf("tests = %+v, want %+v", tests, want)
+	}
+	if got := model.CoveragePercent.ValueFloat64(); got != 85.7 {
+		t.Errorf("coverage_percent = %v, want 85.7", got)
+	}
+	var files map[string]float64
+	if diags := model.FileCoverage.ElementsAs(context.Background(), &files, false); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if want := map[string]float64{"main.k": 80}; !reflect.DeepEqual(files, want) {
+		t.Errorf("file_coverage = %v, want %v", files, want)
+	}
+}
+
+func TestKclTestDataSource_FailOnFailure(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{"main_test.k": "test_app_name = lambda {}\n"})
+
+	diags := readDataSource(t, newKclTestDataSource(fakeKcl(t, testKcl)), map[string]attr.Value{
+		"source_dir":      types.StringValue(dir),
+		"fail_on_failure": types.BoolValue(true),
+	}, nil)
+	if len(diags.Errors()) != 1 || diags.Errors()[0].Summary() != "KCL Test Failed" {
+		t.Fatalf("read diagnostics = %v, want KCL Test Failed for the failing test", diags)
+	}
+	if got, want := diags.Errors()[0].Detail(), "test_app_replicas: replicas must be positive"; got != want {
+		t.Errorf("detail = %q, want %q", got, want)
+	}
+}
+
+func TestKclTestDataSource_CoverageNotSupported(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{"main_test.k": "test_app_name = lambda {}\n"})
+	kcl := fakeKcl(t, `case "$*" in *--cover*) echo "error: unexpected argument '--cover' found" >&2; exit 2; esac
+echo "test_app_name: PASS (2ms)"`)
+
+	var model KclTestDataSourceModel
+	diags := readDataSource(t, newKclTestDataSource(kcl), map[string]attr.Value{
+		"source_dir": types.StringValue(dir),
+		"coverage":   types.BoolValue(true),
+	}, &model)
+	if diags.HasError() || diags.WarningsCount() != 1 || diags.Warnings()[0].Summary() != "Coverage Not Supported" {
+		t.Fatalf("read diagnostics = %v, want a single Coverage Not Supported warning", diags)
+	}
+	if model.Passed.ValueInt64() != 1 || !model.CoveragePercent.IsNull() {
+		t.Errorf("passed = %s, coverage_percent = %s, want the tests run without coverage", model.Passed, model.CoveragePercent)
+	}
+}
+
+func TestKclTestDataSource_Errors(t *testing.T) {
+	diags := readDataSource(t, newKclTestDataSource(fakeKcl(t, testKcl)), map[string]attr.Value{
+		"source_dir": types.StringValue(filepath.Join(t.TempDir(), "missing")),
+	}, nil)
+	if !diags.HasError() || diags.Errors()[0].Summary() != "Directory Not Found" {
+		t.Errorf("read diagnostics = %v, want Directory Not Found", diags)
+	}
+
+	// A failure without any test results is not a test verdict
+	diags = readDataSource(t, newKclTestDataSource(fakeKcl(t, `echo "error[E2L23]: CompileError" >&2; exit 1`)), map[string]attr.Value{
+		"source_dir": types.StringValue(t.TempDir()),
+	}, nil)
+	if !diags.HasError() || diags.Errors()[0].Summary() != "KCL Test Failed To Run" {
+		t.Errorf("read diagnostics = %v, want KCL Test Failed To Run", diags)
+	}
+
+	var model KclTestDataSourceModel
+	diags = readDataSource(t, newKclTestDataSource(fakeKcl(t, `echo "no test files"; exit 1`)), map[string]attr.Value{
+		"source_dir": types.StringValue(t.TempDir()),
+	}, &model)
+	if diags.HasError() {
+		t.Fatalf("read diagnostics = %v, want no tests to be no error", diags)
+	}
+	if !model.AllPassed.ValueBool() || len(model.Tests.Elements()) != 0 {
+		t.Errorf("all_passed = %s, tests = %s, want an empty passing result", model.AllPassed, model.Tests)
+	}
+}
+
+func TestParseKclCoverage(t *testing.T) {
+	total, files := parseKclCoverage("main.k: 80.0%\nlib/util.k 50%\ncoverage: 72.5% of statements\n")
+	if total == nil || *total != 72.5 {
+		t.Errorf("total = %v, want 72.5", total)
+	}
+	if want := map[string]float64{"main.k": 80, "lib/util.k": 50}; !reflect.DeepEqual(files, want) {
+		t.Errorf("files = %v, want %v", files, want)
+	}
+
+	if total, files := parseKclCoverage("test_a: PASS (1ms)\n"); total != nil || len(files) != 0 {
+		t.Errorf("parseKclCoverage() = %v, %v, want nothing for output without coverage", total, files)
+	}
+}
@@ -0,0 +1,47 @@
+// internal/provider/kcl_verify.go
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// KclVerifyModel configures a command that validates the rendered output.
+type KclVerifyModel struct {
+	Command        types.String `tfsdk:"command"`
+	Args           types.List   `tfsdk:"args"`
+	TimeoutSeconds types.Int64  `tfsdk:"timeout_seconds"`
+}
+
+// runVerifier runs the verify command in dir with input on stdin. A non-nil
+// error means the verifier rejected the output or could not be run; the
+// verifier's combined output is returned either way.
+func runVerifier(ctx context.Context, command string, args []string, timeout time.Duration, dir string, env []string, input string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, command, args...)
+	cmd.Dir = dir
+	cmd.Env = env
+	cmd.Stdin = strings.NewReader(input)
+	configureGracefulStop(cmd)
+
+	tflog.Info(ctx, "Verifying KCL output", map[string]interface{}{
+		"command":   command,
+		"arguments": args,
+		"timeout":   timeout,
+	})
+
+	output, err := cmd.CombinedOutput()
+	stopGroupKill(cmd)
+	if err != nil {
+		return string(output), fmt.Errorf("%s %s: %w", command, strings.Join(args, " "), err)
+	}
+	return string(output), nil
+}
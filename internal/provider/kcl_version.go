@@ -0,0 +1,43 @@
+// internal/provider/kcl_version.go
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-version"
+)
+
+// kclVersionPattern finds the first semantic version in `kcl version`
+// output. Releases have printed bare versions, "v"-prefixed versions and
+// versions followed by platform suffixes, so only the core triple is taken.
+var kclVersionPattern = regexp.MustCompile(`v?(\d+\.\d+\.\d+)`)
+
+// kclVersionTimeout bounds how long `kcl version` may take.
+const kclVersionTimeout = 30 * time.Second
+
+// detectKclVersion runs `kcl version` and returns the parsed version.
+func detectKclVersion(ctx context.Context, kclCommand string) (*version.Version, error) {
+	ctx, cancel := context.WithTimeout(ctx, kclVersionTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, kclCommand, "version").CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("running %s version: %w\nOutput: %s", kclCommand, err, strings.TrimSpace(string(output)))
+	}
+
+	return parseKclVersion(string(output))
+}
+
+// parseKclVersion extracts the version from `kcl version` output.
+func parseKclVersion(output string) (*version.Version, error) {
+	m := kclVersionPattern.FindStringSubmatch(output)
+	if m == nil {
+		return nil, fmt.Errorf("no version found in output: %s", strings.TrimSpace(output))
+	}
+	return version.NewVersion(m[1])
+}
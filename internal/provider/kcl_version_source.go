@@ -0,0 +1,174 @@
+// internal/provider/kcl_version_source.go
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// kclVersionField matches a "Key: value" line of `kcl version` output.
+var kclVersionField = regexp.MustCompile(`^\s*([A-Za-z][A-Za-z _-]*?)\s*:\s*(.+?)\s*$`)
+
+// kclVersionKeys maps the normalized keys releases have used for each
+// field to the field.
+var kclVersionKeys = map[string]string{
+	"version":   "version",
+	"gitcommit": "git_sha",
+	"gitsha":    "git_sha",
+	"commit":    "git_sha",
+	"builddate": "build_date",
+	"buildtime": "build_date",
+	"built":     "build_date",
+	"date":      "build_date",
+}
+
+// Ensure provider defined types fully satisfy framework interfaces
+var (
+	_ datasource.DataSource              = &KclVersionDataSource{}
+	_ datasource.DataSourceWithConfigure = &KclVersionDataSource{}
+)
+
+func NewKclVersionDataSource() datasource.DataSource {
+	return &KclVersionDataSource{}
+}
+
+type KclVersionDataSource struct {
+	provider *kclProvider
+}
+
+type KclVersionDataSourceModel struct {
+	Version   types.String `tfsdk:"version"`
+	GitSHA    types.String `tfsdk:"git_sha"`
+	BuildDate types.String `tfsdk:"build_date"`
+	Output    types.String `tfsdk:"output"`
+}
+
+func (d *KclVersionDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_version"
+}
+
+func (d *KclVersionDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Runs `kcl version` on every read and exposes the installed KCL version, e.g. to check it in a `precondition`",
+
+		Attributes: map[string]schema.Attribute{
+			"version": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "KCL version as `major.minor.patch`, without platform suffixes",
+			},
+			"git_sha": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Commit KCL was built from. Null when the release does not report it",
+			},
+			"build_date": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Build date as reported by KCL. Null when the release does not report it",
+			},
+			"output": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Unparsed output of `kcl version`",
+			},
+		},
+	}
+}
+
+func (d *KclVersionDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	provider, ok := req.ProviderData.(*kclProvider)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Provider Data Type",
+			fmt.Sprintf("Expected *kclProvider, got: %T", req.ProviderData),
+		)
+		return
+	}
+
+	d.provider = provider
+}
+
+func (d *KclVersionDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data KclVersionDataSourceModel
+	diags := req.Config.Get(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	kclCommand, err := d.provider.resolveKclCommand()
+	if err != nil {
+		resp.Diagnostics.AddError("KCL Executable Not Found", err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, kclVersionTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, kclCommand, "version")
+	configureGracefulStop(cmd)
+
+	tflog.Debug(ctx, "Reading KCL version", map[string]interface{}{
+		"command": kclCommand,
+	})
+
+	result, err := d.provider.runKcl(ctx, cmd)
+	output := strings.TrimSpace(string(result.Combined))
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"KCL Version Failed",
+			fmt.Sprintf("Command: %s version\nError: %v\nOutput: %s", kclCommand, err, output),
+		)
+		return
+	}
+
+	fields := parseKclVersionFields(output)
+	parsed, err := parseKclVersion(fields["version"] + "\n" + output)
+	if err != nil {
+		resp.Diagnostics.AddError("KCL Version Not Recognized", err.Error())
+		return
+	}
+
+	data.Version = types.StringValue(parsed.String())
+	data.GitSHA = optionalString(fields["git_sha"])
+	data.BuildDate = optionalString(fields["build_date"])
+	data.Output = types.StringValue(output)
+
+	diags = resp.State.Set(ctx, data)
+	resp.Diagnostics.Append(diags...)
+}
+
+// parseKclVersionFields collects the "Key: value" lines of `kcl version`
+// output by field name. Releases differ in key spelling and in which
+// fields they print at all, so unknown keys are ignored.
+func parseKclVersionFields(output string) map[string]string {
+	fields := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		m := kclVersionField.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		key := strings.ToLower(strings.NewReplacer(" ", "", "_", "", "-", "").Replace(m[1]))
+		if field, ok := kclVersionKeys[key]; ok && fields[field] == "" {
+			fields[field] = m[2]
+		}
+	}
+	return fields
+}
+
+// optionalString returns s as a Terraform string, null when empty.
+func optionalString(s string) types.String {
+	if s == "" {
+		return types.StringNull()
+	}
+	return types.StringValue(s)
+}
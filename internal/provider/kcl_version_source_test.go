@@ -0,0 +1,78 @@
+// internal/provider/kcl_version_source_test.go
+package provider
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func newKclVersionDataSource(kclBinary string) *KclVersionDataSource {
+	return &KclVersionDataSource{provider: &kclProvider{KclPath: kclBinary, KclBinary: kclBinary}}
+}
+
+func TestKclVersionDataSource_Read(t *testing.T) {
+	kcl := fakeKcl(t, `[ "$1" = version ] || exit 1
+echo "Version: 0.11.2-linux-amd64"
+echo "GitCommit: 3c6e1f0"
+echo "Build Date: 2025-01-09"`)
+
+	var model KclVersionDataSourceModel
+	if diags := readDataSource(t, newKclVersionDataSource(kcl), nil, &model); diags.HasError() {
+		t.Fatalf("read: %v", diags)
+	}
+	if got := model.Version.ValueString(); got != "0.11.2" {
+		t.Errorf("version = %q, want 0.11.2 without the platform suffix", got)
+	}
+	if got := model.GitSHA.ValueString(); got != "3c6e1f0" {
+		t.Errorf("git_sha = %q, want 3c6e1f0", got)
+	}
+	if got := model.BuildDate.ValueString(); got != "2025-01-09" {
+		t.Errorf("build_date = %q, want 2025-01-09", got)
+	}
+}
+
+func TestKclVersionDataSource_BareVersion(t *testing.T) {
+	var model KclVersionDataSourceModel
+	if diags := readDataSource(t, newKclVersionDataSource(fakeKcl(t, "echo 0.9.8")), nil, &model); diags.HasError() {
+		t.Fatalf("read: %v", diags)
+	}
+	if model.Version.ValueString() != "0.9.8" || !model.GitSHA.IsNull() || !model.BuildDate.IsNull() {
+		t.Errorf("version = %s, git_sha = %s, build_date = %s, want only the version", model.Version, model.GitSHA, model.BuildDate)
+	}
+}
+
+func TestKclVersionDataSource_Errors(t *testing.T) {
+	for name, tc := range map[string]struct {
+		kcl  func(t *testing.T) string
+		want string
+	}{
+		"failing": {
+			kcl:  func(t *testing.T) string { return fakeKcl(t, "echo broken >&2; exit 1") },
+			want: "KCL Version Failed",
+		},
+		"unrecognized": {
+			kcl:  func(t *testing.T) string { return fakeKcl(t, "echo development build") },
+			want: "KCL Version Not Recognized",
+		},
+		"missing": {
+			kcl:  func(t *testing.T) string { return filepath.Join(t.TempDir(), "kcl") },
+			want: "KCL Version Failed",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			diags := readDataSource(t, newKclVersionDataSource(tc.kcl(t)), nil, nil)
+			if !diags.HasError() || diags.Errors()[0].Summary() != tc.want {
+				t.Errorf("read diagnostics = %v, want %s", diags, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseKclVersionFields(t *testing.T) {
+	got := parseKclVersionFields("version: 0.10.0\ngit_sha: abc\nbuilt: today\nplatform: linux\n")
+	want := map[string]string{"version": "0.10.0", "git_sha": "abc", "build_date": "today"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseKclVersionFields() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,258 @@
+// internal/provider/kcl_vet.go
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// kclErrorHeader matches the first line of a KCL error report, e.g.
+// "error[E2L23]: CompileError" or "EvaluationError".
+var kclErrorHeader = regexp.MustCompile(`^(?i:error)(\[\w+\])?:|^\w+Error\b`)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var (
+	_ datasource.DataSource              = &KclVetDataSource{}
+	_ datasource.DataSourceWithConfigure = &KclVetDataSource{}
+)
+
+func NewKclVetDataSource() datasource.DataSource {
+	return &KclVetDataSource{}
+}
+
+type KclVetDataSource struct {
+	provider *kclProvider
+}
+
+type KclVetDataSourceModel struct {
+	DataFile      types.String `tfsdk:"data_file"`
+	SchemaFile    types.String `tfsdk:"schema_file"`
+	SchemaName    types.String `tfsdk:"schema_name"`
+	Format        types.String `tfsdk:"format"`
+	FailOnInvalid types.Bool   `tfsdk:"fail_on_invalid"`
+	Timeout       types.Int64  `tfsdk:"timeout"`
+	Valid         types.Bool   `tfsdk:"valid"`
+	Errors        types.List   `tfsdk:"errors"`
+}
+
+func (d *KclVetDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_vet"
+}
+
+func (d *KclVetDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Validates a data file against a KCL schema with `kcl vet`. Validation failures are reported through " +
+			"`valid` and `errors` rather than failing the plan, so they can be checked in `precondition` blocks",
+
+		Attributes: map[string]schema.Attribute{
+			"data_file": schema.StringAttribute{
+				Required:            true,
+				MarkdownDescription: "Path to the JSON or YAML data file to validate",
+			},
+			"schema_file": schema.StringAttribute{
+				Required:            true,
+				MarkdownDescription: "Path to the KCL file defining the schema",
+			},
+			"schema_name": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Name of the schema to validate against (`--schema`). Defaults to KCL's choice of the schema in `schema_file`",
+			},
+			"format": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Format of `data_file`, `json` or `yaml` (`--format`). Defaults to KCL's detection",
+			},
+			"fail_on_invalid": schema.BoolAttribute{
+				Optional:            true,
+				MarkdownDescription: "Fail the data source when validation fails instead of setting `valid` to false (default: false)",
+			},
+			"timeout": schema.Int64Attribute{
+				Optional:            true,
+				MarkdownDescription: "Validation timeout in seconds (default: 300)",
+			},
+			"valid": schema.BoolAttribute{
+				Computed:            true,
+				MarkdownDescription: "Whether `data_file` conforms to the schema",
+			},
+			"errors": schema.ListAttribute{
+				ElementType:         types.StringType,
+				Computed:            true,
+				MarkdownDescription: "Validation errors reported by `kcl vet`, one entry per error. Empty when `valid` is true",
+			},
+		},
+	}
+}
+
+func (d *KclVetDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	provider, ok := req.ProviderData.(*kclProvider)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Provider Data Type",
+			fmt.Sprintf("Expected *kclProvider, got: %T", req.ProviderData),
+		)
+		return
+	}
+
+	d.provider = provider
+}
+
+func (d *KclVetDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var config KclVetDataSourceModel
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	files := []struct {
+		name  string
+		value types.String
+	}{
+		{"data_file", config.DataFile},
+		{"schema_file", config.SchemaFile},
+	}
+	resolved := make([]string, len(files))
+	for i, f := range files {
+		absPath, err := filepath.Abs(f.value.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root(f.name), "Path Resolution Error", "Invalid path: "+err.Error())
+			continue
+		}
+		if _, err := os.Stat(absPath); os.IsNotExist(err) {
+			resp.Diagnostics.AddAttributeError(path.Root(f.name), "File Not Found", "File does not exist: "+absPath)
+			continue
+		}
+		resolved[i] = absPath
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	args := []string{"vet", resolved[0], resolved[1]}
+	if !config.SchemaName.IsNull() {
+		args = append(args, "--schema", config.SchemaName.ValueString())
+	}
+	if !config.Format.IsNull() {
+		args = append(args, "--format", config.Format.ValueString())
+	}
+
+	timeout := 300 * time.Second
+	if !config.Timeout.IsNull() {
+		timeout = time.Duration(config.Timeout.ValueInt64()) * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, effectiveTimeout(ctx, timeout))
+	defer cancel()
+
+	kclCommand, err := d.provider.resolveKclCommand()
+	if err != nil {
+		resp.Diagnostics.AddError("KCL Executable Not Found", err.Error())
+		return
+	}
+	cmd := exec.CommandContext(ctx, kclCommand, args...)
+	cmd.Dir = filepath.Dir(resolved[1])
+	configureGracefulStop(cmd)
+
+	tflog.Info(ctx, "Validating data with KCL", map[string]interface{}{
+		"command":   kclCommand,
+		"arguments": args,
+		"timeout":   timeout,
+	})
+
+	result, err := d.provider.runKcl(ctx, cmd)
+	output := strings.TrimSpace(string(result.Combined))
+
+	// Only a completed run with an exit code is a validation verdict
+	exitCode, runErr := exitCodeOf(err)
+	if runErr != nil || ctx.Err() != nil {
+		resp.Diagnostics.AddError(
+			"KCL Vet Failed",
+			fmt.Sprintf("Command: %s %s\nError: %v\nOutput: %s",
+				kclCommand, strings.Join(args, " "), err, output),
+		)
+		return
+	}
+
+	vetErrors := []string{}
+	if exitCode != 0 {
+		vetErrors = parseKclVetErrors(output)
+	}
+
+	if exitCode != 0 && config.FailOnInvalid.ValueBool() {
+		for _, e := range vetErrors {
+			resp.Diagnostics.AddError("KCL Validation Failed", e)
+		}
+		return
+	}
+
+	config.Valid = types.BoolValue(exitCode == 0)
+	config.Errors, diags = types.ListValueFrom(ctx, types.StringType, vetErrors)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, config)
+	resp.Diagnostics.Append(diags...)
+}
+
+// parseKclVetErrors splits `kcl vet` output into individual errors. JSON
+// diagnostics are used when present; otherwise each KCL error report, from
+// its header line up to the next header, becomes one entry. Output without
+// any recognizable header is returned as a single error.
+func parseKclVetErrors(output string) []string {
+	if kclDiags, _ := parseKclDiagnostics(output); len(kclDiags) > 0 {
+		var errs []string
+		for _, d := range kclDiags {
+			if !d.IsFatal() {
+				continue
+			}
+			if pos := d.Position(); pos != "" {
+				errs = append(errs, pos+": "+d.Message)
+				continue
+			}
+			errs = append(errs, d.Message)
+		}
+		if len(errs) > 0 {
+			return errs
+		}
+	}
+
+	var (
+		errs    []string
+		current []string
+	)
+	flush := func() {
+		if block := strings.TrimSpace(strings.Join(current, "\n")); block != "" {
+			errs = append(errs, block)
+		}
+		current = nil
+	}
+	for _, line := range strings.Split(output, "\n") {
+		if kclErrorHeader.MatchString(strings.TrimSpace(line)) {
+			flush()
+		}
+		current = append(current, line)
+	}
+	flush()
+
+	if len(errs) == 0 {
+		return []string{"kcl vet reported a validation failure without details"}
+	}
+	return errs
+}
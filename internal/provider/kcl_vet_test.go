@@ -0,0 +1,125 @@
+// internal/provider/kcl_vet_test.go
+package provider
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// vetKcl is a fake KCL whose vet records its arguments in the file args
+// next to the schema and reports two errors when the data file holds
+// "invalid".
+const vetKcl = `[ "$1" = vet ] || exit 1
+echo "$*" > args
+grep -q invalid "$2" || exit 0
+printf 'error[E3M38]: EvaluationError\n  | name must be a string\nerror[E3M38]: EvaluationError\n  | replicas must be positive\n' >&2
+exit 1`
+
+func newKclVetDataSource(kclBinary string) *KclVetDataSource {
+	return &KclVetDataSource{provider: &kclProvider{KclPath: kclBinary, KclBinary: kclBinary}}
+}
+
+func TestKclVetDataSource_Valid(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"data.json": `{"name": "web"}`,
+		"schema.k":  "schema App:\n    name: str\n",
+	})
+	d := newKclVetDataSource(fakeKcl(t, vetKcl))
+
+	var model KclVetDataSourceModel
+	diags := readDataSource(t, d, map[string]attr.Value{
+		"data_file":   types.StringValue(filepath.Join(dir, "data.json")),
+		"schema_file": types.StringValue(filepath.Join(dir, "schema.k")),
+		"schema_name": types.StringValue("App"),
+		"format":      types.StringValue("json"),
+	}, &model)
+	if diags.HasError() {
+		t.Fatalf("read: %v", diags)
+	}
+	if !model.Valid.ValueBool() || len(model.Errors.Elements()) != 0 {
+		t.Errorf("valid = %s, errors = %s, want a valid result without errors", model.Valid, model.Errors)
+	}
+	want := "vet " + filepath.Join(dir, "data.json") + " " + filepath.Join(dir, "schema.k") + " --schema App --format json\n"
+	if got := readTestFile(t, filepath.Join(dir, "args")); got != want {
+		t.Errorf("arguments = %q, want %q run from the schema's directory", got, want)
+	}
+}
+
+func TestKclVetDataSource_Invalid(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"data.json": `{"name": "invalid"}`,
+		"schema.k":  "schema App:\n    name: str\n",
+	})
+	d := newKclVetDataSource(fakeKcl(t, vetKcl))
+	config := map[string]attr.Value{
+		"data_file":   types.StringValue(filepath.Join(dir, "data.json")),
+		"schema_file": types.StringValue(filepath.Join(dir, "schema.k")),
+	}
+
+	var model KclVetDataSourceModel
+	if diags := readDataSource(t, d, config, &model); diags.HasError() {
+		t.Fatalf("read: %v", diags)
+	}
+	if model.Valid.ValueBool() {
+		t.Error("valid = true for data the schema rejects")
+	}
+	if got := len(model.Errors.Elements()); got != 2 {
+		t.Errorf("errors = %s, want one entry per error report", model.Errors)
+	}
+
+	config["fail_on_invalid"] = types.BoolValue(true)
+	diags := readDataSource(t, d, config, nil)
+	if got := len(diags.Errors()); got != 2 {
+		t.Fatalf("read diagnostics = %v, want one error per validation error", diags)
+	}
+	for _, diag := range diags.Errors() {
+		if diag.Summary() != "KCL Validation Failed" {
+			t.Errorf("diagnostic %q, want KCL Validation Failed", diag.Summary())
+		}
+	}
+}
+
+func TestKclVetDataSource_Errors(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"data.json": `{"name": "web"}`,
+		"schema.k":  "schema App:\n    name: str\n",
+	})
+
+	diags := readDataSource(t, newKclVetDataSource(fakeKcl(t, vetKcl)), map[string]attr.Value{
+		"data_file":   types.StringValue(filepath.Join(dir, "missing.json")),
+		"schema_file": types.StringValue(filepath.Join(dir, "schema.k")),
+	}, nil)
+	if !diags.HasError() || diags.Errors()[0].Summary() != "File Not Found" {
+		t.Errorf("read diagnostics = %v, want File Not Found for the missing data file", diags)
+	}
+
+	missing := filepath.Join(t.TempDir(), "kcl")
+	diags = readDataSource(t, newKclVetDataSource(missing), map[string]attr.Value{
+		"data_file":   types.StringValue(filepath.Join(dir, "data.json")),
+		"schema_file": types.StringValue(filepath.Join(dir, "schema.k")),
+	}, nil)
+	if !diags.HasError() || diags.Errors()[0].Summary() != "KCL Vet Failed" {
+		t.Errorf("read diagnostics = %v, want KCL Vet Failed when KCL cannot be run", diags)
+	}
+}
+
+func TestParseKclVetErrors(t *testing.T) {
+	output := "error[E3M38]: EvaluationError\n  | name must be a string\nerror[E3M38]: EvaluationError\n  | replicas must be positive"
+	want := []string{
+		"error[E3M38]: EvaluationError\n  | name must be a string",
+		"error[E3M38]: EvaluationError\n  | replicas must be positive",
+	}
+	if got := parseKclVetErrors(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseKclVetErrors() = %q, want %q", got, want)
+	}
+
+	got := parseKclVetErrors("something went wrong")
+	if len(got) != 1 || !strings.Contains(got[0], "something went wrong") {
+		t.Errorf("parseKclVetErrors() = %q, want the whole output as one error", got)
+	}
+}
@@ -0,0 +1,187 @@
+// internal/provider/oci_source.go
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ociManifestTypes are the manifest media types accepted when resolving a
+// tag, KCL packages being published as OCI image manifests.
+var ociManifestTypes = []string{
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+var (
+	// ociRepositoryPattern matches a repository path as the OCI
+	// distribution spec defines it.
+	ociRepositoryPattern = regexp.MustCompile(`^[a-z0-9]+(?:(?:\.|_|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:\.|_|__|-+)[a-z0-9]+)*)*$`)
+
+	// ociTagPattern matches a tag as the OCI distribution spec defines it.
+	ociTagPattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9._-]{0,127}$`)
+
+	// ociChallengeParam matches a parameter of a WWW-Authenticate challenge.
+	ociChallengeParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+)
+
+// ociReference is a parsed oci_ref, "oci://<registry>/<repository>:<tag>".
+type ociReference struct {
+	Registry   string
+	Repository string
+	Tag        string
+}
+
+// URL returns the reference without its tag, as KCL expects it.
+func (r ociReference) URL() string {
+	return "oci://" + r.Registry + "/" + r.Repository
+}
+
+// parseOCIReference validates ref and splits it into its parts. A tag is
+// required, so the package a run uses is always identifiable.
+func parseOCIReference(ref string) (ociReference, error) {
+	rest, ok := strings.CutPrefix(ref, "oci://")
+	if !ok {
+		return ociReference{}, fmt.Errorf("OCI reference %q must start with oci://", ref)
+	}
+	registry, repoTag, ok := strings.Cut(rest, "/")
+	if !ok || registry == "" {
+		return ociReference{}, fmt.Errorf("OCI reference %q names no registry and repository, expected oci://<registry>/<repository>:<tag>", ref)
+	}
+	if strings.Contains(repoTag, "@") {
+		return ociReference{}, fmt.Errorf("OCI reference %q pins a digest, which KCL cannot run; pin a tag instead", ref)
+	}
+
+	i := strings.LastIndex(repoTag, ":")
+	if i < 0 {
+		return ociReference{}, fmt.Errorf("OCI reference %q has no tag, expected oci://<registry>/<repository>:<tag>", ref)
+	}
+	repository, tag := repoTag[:i], repoTag[i+1:]
+	if !ociRepositoryPattern.MatchString(repository) {
+		return ociReference{}, fmt.Errorf("OCI reference %q has an invalid repository %q", ref, repository)
+	}
+	if !ociTagPattern.MatchString(tag) {
+		return ociReference{}, fmt.Errorf("OCI reference %q has an invalid tag %q", ref, tag)
+	}
+	return ociReference{Registry: registry, Repository: repository, Tag: tag}, nil
+}
+
+// resolveOCIDigest returns the digest of the manifest ref's tag points to.
+// The provider's registry credentials are used when ref is on its
+// registry; other registries are accessed anonymously.
+func resolveOCIDigest(ctx context.Context, p *kclProvider, ref ociReference) (string, error) {
+	manifestURL := "https://" + ref.Registry + "/v2/" + ref.Repository + "/manifests/" + ref.Tag
+
+	res, err := ociManifestRequest(ctx, manifestURL, "")
+	if err != nil {
+		return "", err
+	}
+	if res.StatusCode == http.StatusUnauthorized {
+		challenge := res.Header.Get("WWW-Authenticate")
+		res.Body.Close()
+
+		token, err := ociBearerToken(ctx, p, ref, challenge)
+		if err != nil {
+			return "", err
+		}
+		if res, err = ociManifestRequest(ctx, manifestURL, token); err != nil {
+			return "", err
+		}
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("resolving %s: unexpected HTTP status %s", ref.URL()+":"+ref.Tag, res.Status)
+	}
+	if digest := res.Header.Get("Docker-Content-Digest"); ociDigestPattern.MatchString(digest) {
+		return digest, nil
+	}
+
+	// Without the header the digest is that of the manifest itself
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", fmt.Errorf("reading manifest: %w", err)
+	}
+	sum := sha256.Sum256(body)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+func ociManifestRequest(ctx context.Context, manifestURL, token string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, manifestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", strings.Join(ociManifestTypes, ", "))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return http.DefaultClient.Do(req)
+}
+
+// ociBearerToken obtains a pull token for ref from the token service named
+// in a Bearer challenge.
+func ociBearerToken(ctx context.Context, p *kclProvider, ref ociReference, challenge string) (string, error) {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "", fmt.Errorf("registry %s requires unsupported authentication %q", ref.Registry, scheme)
+	}
+	values := map[string]string{}
+	for _, m := range ociChallengeParam.FindAllStringSubmatch(params, -1) {
+		values[strings.ToLower(m[1])] = m[2]
+	}
+	if values["realm"] == "" {
+		return "", fmt.Errorf("registry %s sent an authentication challenge without realm", ref.Registry)
+	}
+
+	query := url.Values{}
+	if values["service"] != "" {
+		query.Set("service", values["service"])
+	}
+	query.Set("scope", "repository:"+ref.Repository+":pull")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, values["realm"]+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	if p != nil && p.RegistryPassword != "" && strings.EqualFold(registryHost(p.Registry), ref.Registry) {
+		req.SetBasicAuth(p.RegistryUsername, p.RegistryPassword)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("requesting a pull token for %s: unexpected HTTP status %s", ref.URL(), res.Status)
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("decoding pull token: %w", err)
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	return body.AccessToken, nil
+}
+
+// registryHost returns the host of the registry setting, which may be
+// given with or without a scheme.
+func registryHost(registry string) string {
+	if r, err := url.Parse(registry); err == nil && r.Host != "" {
+		return r.Host
+	}
+	return strings.TrimSuffix(registry, "/")
+}
@@ -0,0 +1,96 @@
+// internal/provider/output_encoding.go
+package provider
+
+import (
+	"encoding/binary"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Supported values for output_encoding.
+const (
+	outputEncodingUTF8   = "utf8"
+	outputEncodingLatin1 = "latin1"
+	outputEncodingUTF16  = "utf16"
+)
+
+var validOutputEncodings = []string{
+	outputEncodingUTF8,
+	outputEncodingLatin1,
+	outputEncodingUTF16,
+}
+
+// validateOutputEncoding returns an error when encoding is not supported.
+func validateOutputEncoding(encoding string) error {
+	if encoding == "" {
+		return nil
+	}
+	for _, v := range validOutputEncodings {
+		if encoding == v {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown output encoding %q, expected one of: %s",
+		encoding, strings.Join(validOutputEncodings, ", "))
+}
+
+// decodeOutput converts raw process output in the given encoding to UTF-8.
+// For utf8 input, invalid byte sequences are replaced with U+FFFD and
+// replaced is set so callers can warn about it.
+func decodeOutput(raw []byte, encoding string) (decoded string, replaced bool, err error) {
+	switch encoding {
+	case "", outputEncodingUTF8:
+		if utf8.Valid(raw) {
+			return string(raw), false, nil
+		}
+		return strings.ToValidUTF8(string(raw), string(utf8.RuneError)), true, nil
+	case outputEncodingLatin1:
+		runes := make([]rune, len(raw))
+		for i, b := range raw {
+			runes[i] = rune(b)
+		}
+		return string(runes), false, nil
+	case outputEncodingUTF16:
+		return decodeUTF16(raw), false, nil
+	}
+	return "", false, validateOutputEncoding(encoding)
+}
+
+// decodeUTF16 decodes UTF-16 honoring a leading byte order mark and
+// defaulting to little endian, which is what Windows tools emit.
+func decodeUTF16(raw []byte) string {
+	var order binary.ByteOrder = binary.LittleEndian
+	switch {
+	case len(raw) >= 2 && raw[0] == 0xFE && raw[1] == 0xFF:
+		order = binary.BigEndian
+		raw = raw[2:]
+	case len(raw) >= 2 && raw[0] == 0xFF && raw[1] == 0xFE:
+		raw = raw[2:]
+	}
+
+	units := make([]uint16, 0, len(raw)/2)
+	for i := 0; i+1 < len(raw); i += 2 {
+		units = append(units, order.Uint16(raw[i:]))
+	}
+	return string(utf16.Decode(units))
+}
+
+// ansiEscape matches ANSI escape sequences: CSI sequences such as colors and
+// cursor movement, OSC sequences such as hyperlinks, and two-character
+// escapes. A sequence cut off at the end of the text is matched too.
+var ansiEscape = regexp.MustCompile(
+	"\x1b\\[[0-?]*[ -/]*(?:[@-~]|$)" +
+		"|\x1b\\][^\x07\x1b]*(?:\x07|\x1b\\\\|$)" +
+		"|\x1b[@-Z\\\\-_]?")
+
+// stripANSI removes ANSI escape sequences from s. It runs on whole captured
+// streams or complete lines, so a sequence is never split between calls.
+func stripANSI(s string) string {
+	if !strings.Contains(s, "\x1b") {
+		return s
+	}
+	return ansiEscape.ReplaceAllString(s, "")
+}
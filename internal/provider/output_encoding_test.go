@@ -0,0 +1,72 @@
+// internal/provider/output_encoding_test.go
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestDecodeOutput(t *testing.T) {
+	tests := []struct {
+		name         string
+		raw          []byte
+		encoding     string
+		want         string
+		wantReplaced bool
+	}{
+		{"utf8 valid", []byte("héllo"), outputEncodingUTF8, "héllo", false},
+		{"default is utf8", []byte("héllo"), "", "héllo", false},
+		{"utf8 invalid byte", []byte("caf\xe9 ok"), outputEncodingUTF8, "caf� ok", true},
+		{"utf8 truncated sequence", []byte("a\xe2\x82"), "", "a�", true},
+		{"latin1", []byte("caf\xe9 \xa9"), outputEncodingLatin1, "café ©", false},
+		{"utf16 little endian without BOM", []byte{'h', 0, 0xe9, 0}, outputEncodingUTF16, "hé", false},
+		{"utf16 little endian BOM", []byte{0xff, 0xfe, 'h', 0, 'i', 0}, outputEncodingUTF16, "hi", false},
+		{"utf16 big endian BOM", []byte{0xfe, 0xff, 0, 'h', 0, 'i'}, outputEncodingUTF16, "hi", false},
+		{"utf16 surrogate pair", []byte{0x3d, 0xd8, 0x00, 0xde}, outputEncodingUTF16, "😀", false},
+		{"utf16 odd trailing byte", []byte{'a', 0, 'b'}, outputEncodingUTF16, "a", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, replaced, err := decodeOutput(tt.raw, tt.encoding)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want || replaced != tt.wantReplaced {
+				t.Errorf("decodeOutput(%q, %q) = %q, %t, want %q, %t", tt.raw, tt.encoding, got, replaced, tt.want, tt.wantReplaced)
+			}
+		})
+	}
+
+	if _, _, err := decodeOutput([]byte("x"), "ebcdic"); err == nil {
+		t.Errorf("decodeOutput with an unknown encoding succeeded")
+	}
+}
+
+func TestKclExecResource_InvalidUTF8Output(t *testing.T) {
+	h := newExecHarness(t, fakeKcl(t, `case "$1" in
+version) echo "0.11.0" ;;
+*) printf 'caf\351\n' ;;
+esac`))
+	dir := writeTestFiles(t, map[string]string{"main.k": "a = 1\n"})
+
+	model, diags := h.apply(map[string]attr.Value{"source_dir": types.StringValue(dir)})
+	if diags.HasError() {
+		t.Fatalf("apply: %v", diags)
+	}
+	if got := model.Stdout.ValueString(); got != "caf�" {
+		t.Errorf("stdout = %q, want the invalid byte replaced", got)
+	}
+	if len(diags.Warnings()) != 1 || diags.Warnings()[0].Summary() != "Invalid UTF-8 In KCL Output" {
+		t.Errorf("warnings = %v, want one about invalid UTF-8", diags.Warnings())
+	}
+
+	latin1 := newExecHarness(t, h.resource.provider.KclBinary).mustApply(map[string]attr.Value{
+		"source_dir":      types.StringValue(dir),
+		"output_encoding": types.StringValue(outputEncodingLatin1),
+	})
+	if got := latin1.Stdout.ValueString(); got != "café" {
+		t.Errorf("stdout = %q, want latin1 transcoded to UTF-8", got)
+	}
+}
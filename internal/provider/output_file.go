@@ -0,0 +1,57 @@
+// internal/provider/output_file.go
+package provider
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// defaultOutputFilePermission is the mode output_file is written with.
+const defaultOutputFilePermission = "0644"
+
+// parseFilePermission parses an octal permission string such as "0644".
+func parseFilePermission(s string) (os.FileMode, error) {
+	mode, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || mode > 0o777 {
+		return 0, fmt.Errorf("%q is not an octal file permission such as \"0644\"", s)
+	}
+	return os.FileMode(mode), nil
+}
+
+// writeFileAtomic writes content to a temporary file next to path and
+// renames it into place, so readers never see a partial file. Missing
+// parent directories are created.
+func writeFileAtomic(path, content string, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating directory %s: %w", dir, err)
+	}
+
+	f, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := f.Name()
+
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	// CreateTemp uses 0600 regardless of umask, so set the mode explicitly
+	if err := os.Chmod(tmpName, perm); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	return nil
+}
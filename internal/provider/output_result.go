@@ -0,0 +1,240 @@
+// internal/provider/output_result.go
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"gopkg.in/yaml.v2"
+)
+
+// Supported result formats, describing how stdout is parsed into `result`.
+const (
+	resultFormatJSON = "json"
+	resultFormatYAML = "yaml"
+	resultFormatTOML = "toml"
+)
+
+var validResultFormats = []string{
+	resultFormatJSON,
+	resultFormatYAML,
+	resultFormatTOML,
+}
+
+// resultSnippetRadius is the number of characters shown on either side of a
+// parse error.
+const resultSnippetRadius = 40
+
+var yamlErrorLine = regexp.MustCompile(`line (\d+)`)
+
+// validateResultFormat returns an error unless format is one of the supported
+// result formats.
+func validateResultFormat(format string) error {
+	for _, v := range validResultFormats {
+		if format == v {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown format %q, expected one of: %s",
+		format, strings.Join(validResultFormats, ", "))
+}
+
+// parseResult decodes output in the given format into a Terraform value.
+// Errors include a snippet of output around the offending position.
+func parseResult(output, format string) (attr.Value, error) {
+	var v interface{}
+	switch format {
+	case resultFormatJSON:
+		dec := json.NewDecoder(strings.NewReader(output))
+		dec.UseNumber()
+		if err := dec.Decode(&v); err != nil {
+			return nil, jsonResultError(output, err)
+		}
+		if dec.More() {
+			return nil, fmt.Errorf("output holds more than one JSON document:\n%s",
+				snippetAt(output, int(dec.InputOffset())))
+		}
+	case resultFormatYAML:
+		if err := yaml.Unmarshal([]byte(output), &v); err != nil {
+			return nil, yamlResultError(output, err)
+		}
+	case resultFormatTOML:
+		table, err := decodeTOML(output)
+		if err != nil {
+			return nil, fmt.Errorf("%w\nA TOML document is a table and holds no null values; "+
+				"use format json or yaml for a top-level list, a scalar or null values", err)
+		}
+		v = table
+	default:
+		return nil, validateResultFormat(format)
+	}
+	return goValueToAttr(v)
+}
+
+func jsonResultError(output string, err error) error {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return fmt.Errorf("%v at offset %d:\n%s", err, syntaxErr.Offset, snippetAt(output, int(syntaxErr.Offset)))
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return fmt.Errorf("%v at offset %d:\n%s", err, typeErr.Offset, snippetAt(output, int(typeErr.Offset)))
+	}
+	return fmt.Errorf("%v:\n%s", err, snippetAt(output, 0))
+}
+
+// yamlResultError points at the line named in a yaml.v2 error, falling back
+// to the start of output.
+func yamlResultError(output string, err error) error {
+	if m := yamlErrorLine.FindStringSubmatch(err.Error()); m != nil {
+		if n, convErr := strconv.Atoi(m[1]); convErr == nil {
+			lines := strings.Split(output, "\n")
+			if n >= 1 && n <= len(lines) {
+				return fmt.Errorf("%v:\n%d | %s", err, n, lines[n-1])
+			}
+		}
+	}
+	return fmt.Errorf("%v:\n%s", err, snippetAt(output, 0))
+}
+
+// snippetAt returns the text of output around byte offset, marking elided
+// text with an ellipsis.
+func snippetAt(output string, offset int) string {
+	if offset > len(output) {
+		offset = len(output)
+	}
+	start, end := offset-resultSnippetRadius, offset+resultSnippetRadius
+	prefix, suffix := "...", "..."
+	if start <= 0 {
+		start, prefix = 0, ""
+	}
+	if end >= len(output) {
+		end, suffix = len(output), ""
+	}
+	return prefix + output[start:end] + suffix
+}
+
+// parseEntryResults decodes the output of each independently run entry in
+// format. It returns every result encoded as compact JSON, and an object
+// holding the results keyed by entry.
+func parseEntryResults(outputs map[string]string, format string) (map[string]string, attr.Value, error) {
+	entries := make([]string, 0, len(outputs))
+	for entry := range outputs {
+		entries = append(entries, entry)
+	}
+	sort.Strings(entries)
+
+	encoded := make(map[string]string, len(entries))
+	attrTypes := make(map[string]attr.Type, len(entries))
+	values := make(map[string]attr.Value, len(entries))
+	for _, entry := range entries {
+		value, err := parseResult(strings.TrimSpace(outputs[entry]), format)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to parse the output of entry %s as %s: %w", entry, format, err)
+		}
+		plain, err := attrValueToJSON(value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("entry %s: %w", entry, err)
+		}
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(plain); err != nil {
+			return nil, nil, fmt.Errorf("entry %s: %w", entry, err)
+		}
+		encoded[entry] = strings.TrimSuffix(buf.String(), "\n")
+		attrTypes[entry], values[entry] = value.Type(context.Background()), value
+	}
+
+	object, diags := types.ObjectValue(attrTypes, values)
+	if diags.HasError() {
+		return nil, nil, fmt.Errorf("building entry results: %s", diags[0].Detail())
+	}
+	return encoded, object, nil
+}
+
+// goValueToAttr converts a decoded JSON or YAML document into a Terraform
+// value. Objects become object values and arrays become tuples, so members
+// keep their individual types; nulls are represented as null strings.
+func goValueToAttr(v interface{}) (attr.Value, error) {
+	switch val := v.(type) {
+	case nil:
+		return types.StringNull(), nil
+	case string:
+		return types.StringValue(val), nil
+	case bool:
+		return types.BoolValue(val), nil
+	case json.Number:
+		f, _, err := big.ParseFloat(val.String(), 10, 512, big.ToNearestEven)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s: %w", val, err)
+		}
+		return types.NumberValue(f), nil
+	case int:
+		return types.NumberValue(new(big.Float).SetInt64(int64(val))), nil
+	case int64:
+		return types.NumberValue(new(big.Float).SetInt64(val)), nil
+	case uint64:
+		return types.NumberValue(new(big.Float).SetUint64(val)), nil
+	case float64:
+		return types.NumberValue(big.NewFloat(val)), nil
+	case []interface{}:
+		elemTypes := make([]attr.Type, len(val))
+		elems := make([]attr.Value, len(val))
+		for i, item := range val {
+			elem, err := goValueToAttr(item)
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+			elemTypes[i], elems[i] = elem.Type(context.Background()), elem
+		}
+		value, diags := types.TupleValue(elemTypes, elems)
+		if diags.HasError() {
+			return nil, fmt.Errorf("building list: %s", diags[0].Detail())
+		}
+		return value, nil
+	case map[string]interface{}:
+		return goMapToAttr(val)
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			m[fmt.Sprint(k)] = item
+		}
+		return goMapToAttr(m)
+	}
+	return nil, fmt.Errorf("unsupported value type %T", v)
+}
+
+func goMapToAttr(m map[string]interface{}) (attr.Value, error) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	attrTypes := make(map[string]attr.Type, len(m))
+	attrs := make(map[string]attr.Value, len(m))
+	for _, k := range keys {
+		value, err := goValueToAttr(m[k])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", k, err)
+		}
+		attrTypes[k], attrs[k] = value.Type(context.Background()), value
+	}
+
+	value, diags := types.ObjectValue(attrTypes, attrs)
+	if diags.HasError() {
+		return nil, fmt.Errorf("building object: %s", diags[0].Detail())
+	}
+	return value, nil
+}
@@ -0,0 +1,91 @@
+// internal/provider/output_result_test.go
+package provider
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestParseResult_TOML(t *testing.T) {
+	output := `name = "web"
+replicas = 3
+enabled = true
+ratio = 0.5
+tags = ["a", "b"]
+
+[labels]
+app = "web"
+
+[[ports]]
+port = 80
+
+[[ports]]
+port = 443
+`
+	got, err := parseResult(output, resultFormatTOML)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	number := func(f float64) attr.Value { return types.NumberValue(big.NewFloat(f)) }
+	obj := got.(types.Object).Attributes()
+	checks := map[string]attr.Value{
+		"name":     types.StringValue("web"),
+		"replicas": number(3),
+		"enabled":  types.BoolValue(true),
+		"ratio":    number(0.5),
+	}
+	for name, want := range checks {
+		if value, ok := obj[name]; !ok || !sameResultValue(value, want) {
+			t.Errorf("result.%s = %v, want %v", name, value, want)
+		}
+	}
+	if tags := obj["tags"].(types.Tuple).Elements(); len(tags) != 2 || !tags[1].Equal(types.StringValue("b")) {
+		t.Errorf("result.tags = %v, want [a b]", tags)
+	}
+	if app := obj["labels"].(types.Object).Attributes()["app"]; !app.Equal(types.StringValue("web")) {
+		t.Errorf("result.labels.app = %v, want web", app)
+	}
+	ports := obj["ports"].(types.Tuple).Elements()
+	if len(ports) != 2 || !sameResultValue(ports[1].(types.Object).Attributes()["port"], number(443)) {
+		t.Errorf("result.ports = %v, want two tables ending with port 443", ports)
+	}
+}
+
+func TestParseResult_TOMLIncompatibleShape(t *testing.T) {
+	for _, output := range []string{
+		"[1, 2]",
+		"- a\n- b\n",
+		"42",
+		"a = 1\nb = null\n",
+	} {
+		_, err := parseResult(output, resultFormatTOML)
+		if err == nil {
+			t.Errorf("parseResult(%q, toml) succeeded", output)
+			continue
+		}
+		if !strings.Contains(err.Error(), "use format json or yaml") {
+			t.Errorf("parseResult(%q, toml) error = %q, want it to suggest another format", output, err)
+		}
+	}
+}
+
+// sameResultValue compares values by their Terraform representation, so
+// numbers of different precision compare equal.
+func sameResultValue(got, want attr.Value) bool {
+	if n, ok := got.(types.Number); ok {
+		w, ok := want.(types.Number)
+		return ok && n.ValueBigFloat().Cmp(w.ValueBigFloat()) == 0
+	}
+	gotTF, err := got.ToTerraformValue(context.Background())
+	if err != nil {
+		return false
+	}
+	wantTF, err := want.ToTerraformValue(context.Background())
+	return err == nil && gotTF.Equal(wantTF)
+}
@@ -0,0 +1,176 @@
+// internal/provider/output_transforms.go
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Supported output transforms. sort_keys and strip_nulls only apply to
+// output that is valid JSON; other output passes through unchanged.
+const (
+	outputTransformSortKeys   = "sort_keys"
+	outputTransformStripNulls = "strip_nulls"
+	outputTransformTrim       = "trim"
+)
+
+var validOutputTransforms = []string{
+	outputTransformSortKeys,
+	outputTransformStripNulls,
+	outputTransformTrim,
+}
+
+// validateOutputTransforms returns an error naming the first unknown transform.
+func validateOutputTransforms(transforms []string) error {
+	for _, t := range transforms {
+		known := false
+		for _, v := range validOutputTransforms {
+			if t == v {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown output transform %q, expected one of: %s",
+				t, strings.Join(validOutputTransforms, ", "))
+		}
+	}
+	return nil
+}
+
+// applyOutputTransforms applies transforms to output in the order given.
+func applyOutputTransforms(output string, transforms []string) (string, error) {
+	for _, t := range transforms {
+		switch t {
+		case outputTransformTrim:
+			output = strings.TrimSpace(output)
+		case outputTransformSortKeys:
+			v, ok := decodeJSONOutput(output)
+			if !ok {
+				continue
+			}
+			encoded, err := encodeJSONOutput(v)
+			if err != nil {
+				return "", err
+			}
+			output = encoded
+		case outputTransformStripNulls:
+			v, ok := decodeJSONOutput(output)
+			if !ok {
+				continue
+			}
+			encoded, err := encodeJSONOutput(stripJSONNulls(v))
+			if err != nil {
+				return "", err
+			}
+			output = encoded
+		default:
+			return "", fmt.Errorf("unknown output transform %q", t)
+		}
+	}
+	return output, nil
+}
+
+func decodeJSONOutput(output string) (interface{}, bool) {
+	dec := json.NewDecoder(strings.NewReader(output))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, false
+	}
+	if dec.More() {
+		return nil, false
+	}
+	return v, true
+}
+
+// encodeJSONOutput re-encodes v as indented JSON. encoding/json always emits
+// object keys in sorted order.
+func encodeJSONOutput(v interface{}) (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return "", fmt.Errorf("encoding transformed output: %w", err)
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// compactJSON returns output as minified JSON with sorted object keys, the
+// canonical form used for stable comparison. ok is false when output is not
+// a single JSON document.
+func compactJSON(output string) (string, bool) {
+	v, ok := decodeJSONOutput(output)
+	if !ok {
+		return "", false
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return "", false
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), true
+}
+
+// stripJSONNulls removes null-valued object members recursively. Nulls inside
+// arrays are kept so element positions are preserved.
+func stripJSONNulls(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, item := range val {
+			if item == nil {
+				delete(val, k)
+				continue
+			}
+			val[k] = stripJSONNulls(item)
+		}
+		return val
+	case []interface{}:
+		for i, item := range val {
+			val[i] = stripJSONNulls(item)
+		}
+		return val
+	}
+	return v
+}
+
+// canonicalOutput normalizes output for comparison: JSON is re-encoded with
+// sorted keys, anything else is compared with surrounding whitespace trimmed.
+func canonicalOutput(output string) string {
+	if v, ok := decodeJSONOutput(output); ok {
+		if encoded, err := encodeJSONOutput(v); err == nil {
+			return encoded
+		}
+	}
+	return strings.TrimSpace(output)
+}
+
+// compareCanonicalOutputs returns a description of the first line at which
+// the canonical forms of a and b differ, or "" when they are equal.
+func compareCanonicalOutputs(a, b string) string {
+	ca, cb := canonicalOutput(a), canonicalOutput(b)
+	if ca == cb {
+		return ""
+	}
+
+	linesA, linesB := strings.Split(ca, "\n"), strings.Split(cb, "\n")
+	for i := 0; i < len(linesA) || i < len(linesB); i++ {
+		var la, lb string
+		if i < len(linesA) {
+			la = linesA[i]
+		}
+		if i < len(linesB) {
+			lb = linesB[i]
+		}
+		if la != lb {
+			return fmt.Sprintf("line %d:\n- %s\n+ %s", i+1, la, lb)
+		}
+	}
+	return "outputs differ in line structure"
+}
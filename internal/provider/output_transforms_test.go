@@ -0,0 +1,28 @@
+// internal/provider/output_transforms_test.go
+package provider
+
+import "testing"
+
+func TestCompactJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   string
+		ok     bool
+	}{
+		{"minified", `{"b":1,"a":[true,null]}`, `{"a":[true,null],"b":1}`, true},
+		{"indented", "{\n  \"a\": [\n    true,\n    null\n  ],\n  \"b\": 1\n}\n", `{"a":[true,null],"b":1}`, true},
+		{"nested keys sorted", `{"z":{"y":1,"x":2}}`, `{"z":{"x":2,"y":1}}`, true},
+		{"html kept", `{"a":"<b>&"}`, `{"a":"<b>&"}`, true},
+		{"not json", "a: 1", "", false},
+		{"two documents", `{"a":1} {"b":2}`, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := compactJSON(tt.output)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("compactJSON(%q) = %q, %v, want %q, %v", tt.output, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+// internal/provider/process.go
+package provider
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// killGracePeriod is how long a cancelled KCL process gets to exit after
+// SIGTERM before it is killed.
+const killGracePeriod = 10 * time.Second
+
+// configureGracefulStop makes cmd terminate its process on context
+// cancellation and escalate to a kill once killGracePeriod has passed. On
+// Unix the command runs in its own process group and the signals go to the
+// whole group, so helpers spawned by KCL (e.g. for package resolution) do
+// not outlive it.
+func configureGracefulStop(cmd *exec.Cmd) {
+	configureGracefulStopWithin(cmd, killGracePeriod)
+}
+
+// configureGracefulStopWithin is configureGracefulStop with a grace period
+// of grace. A zero grace period kills the process right away.
+func configureGracefulStopWithin(cmd *exec.Cmd, grace time.Duration) {
+	startProcessGroup(cmd)
+	cmd.Cancel = func() error {
+		if grace <= 0 {
+			return killProcess(cmd.Process)
+		}
+		err := terminateProcess(cmd.Process)
+		if err == nil {
+			// WaitDelay only kills the direct child; take the group down too.
+			// Cancel returns before Wait does, so the timer is registered in
+			// time for stopGroupKill.
+			process := cmd.Process
+			pendingGroupKills.Store(cmd, time.AfterFunc(grace, func() {
+				_ = killProcess(process)
+			}))
+		}
+		return err
+	}
+	cmd.WaitDelay = grace
+}
+
+// pendingGroupKills holds the group kill timer of every cancelled command,
+// keyed by *exec.Cmd, until its Wait returns.
+var pendingGroupKills sync.Map
+
+// stopGroupKill stops the group kill scheduled when cmd was cancelled. It is
+// called once Wait has returned: the process group may be gone by then and
+// its ID reused, so the timer must not fire.
+func stopGroupKill(cmd *exec.Cmd) {
+	if timer, ok := pendingGroupKills.LoadAndDelete(cmd); ok {
+		timer.(*time.Timer).Stop()
+	}
+}
+
+// commandOutput holds everything a finished command wrote.
+type commandOutput struct {
+	// Combined is stdout and stderr interleaved in the order they arrived.
+	Combined []byte
+	Stdout   []byte
+	Stderr   []byte
+}
+
+// lockedBuffer is a bytes.Buffer safe for the concurrent writes made by
+// the stdout and stderr copy goroutines of an exec.Cmd.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// logFunc is a tflog level function such as tflog.Debug.
+type logFunc func(ctx context.Context, msg string, additionalFields ...map[string]interface{})
+
+// logLineWriter forwards every complete line written to it to logf, so
+// output is visible while a long-running command is still going. Each
+// stream gets its own writer, so no locking is needed.
+type logLineWriter struct {
+	ctx     context.Context
+	stream  string
+	logf    logFunc
+	partial []byte
+}
+
+func (w *logLineWriter) Write(p []byte) (int, error) {
+	w.partial = append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+		w.log(w.partial[:i])
+		w.partial = w.partial[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush logs a trailing line that was not terminated by a newline.
+func (w *logLineWriter) Flush() {
+	if len(w.partial) > 0 {
+		w.log(w.partial)
+		w.partial = nil
+	}
+}
+
+func (w *logLineWriter) log(line []byte) {
+	w.logf(w.ctx, "KCL output", map[string]interface{}{
+		"stream": w.stream,
+		"line":   stripANSI(strings.TrimSuffix(string(line), "\r")),
+	})
+}
+
+// runCapturingOutput runs cmd and captures stdout and stderr both
+// separately and combined. Lines are streamed to the debug log as they
+// arrive.
+func runCapturingOutput(ctx context.Context, cmd *exec.Cmd) (commandOutput, error) {
+	return runStreamingOutput(ctx, cmd, tflog.Debug)
+}
+
+// runStreamingOutput is runCapturingOutput with the output lines logged
+// through logf.
+func runStreamingOutput(ctx context.Context, cmd *exec.Cmd, logf logFunc) (commandOutput, error) {
+	var (
+		combined       lockedBuffer
+		stdout, stderr bytes.Buffer
+	)
+	stdoutLog := &logLineWriter{ctx: ctx, stream: "stdout", logf: logf}
+	stderrLog := &logLineWriter{ctx: ctx, stream: "stderr", logf: logf}
+	cmd.Stdout = io.MultiWriter(&stdout, &combined, stdoutLog)
+	cmd.Stderr = io.MultiWriter(&stderr, &combined, stderrLog)
+
+	err := cmd.Run()
+	stopGroupKill(cmd)
+	stdoutLog.Flush()
+	stderrLog.Flush()
+	return commandOutput{
+		Combined: combined.buf.Bytes(),
+		Stdout:   stdout.Bytes(),
+		Stderr:   stderr.Bytes(),
+	}, err
+}
@@ -0,0 +1,41 @@
+// internal/provider/process_unix.go
+
+//go:build !windows
+
+package provider
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// startProcessGroup runs the command in a new process group, so helpers it
+// spawns can be signalled together with it.
+func startProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// terminateProcess asks the process and the rest of its group to shut down
+// cleanly.
+func terminateProcess(p *os.Process) error {
+	return signalProcessGroup(p, syscall.SIGTERM)
+}
+
+// killProcess kills the process and every other member of its group.
+func killProcess(p *os.Process) error {
+	return signalProcessGroup(p, syscall.SIGKILL)
+}
+
+func signalProcessGroup(p *os.Process, sig syscall.Signal) error {
+	// A negative PID addresses the whole process group led by p
+	err := syscall.Kill(-p.Pid, sig)
+	if errors.Is(err, syscall.ESRCH) {
+		return os.ErrProcessDone
+	}
+	return err
+}
@@ -0,0 +1,145 @@
+// internal/provider/process_unix_test.go
+
+//go:build !windows
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// processAlive reports whether pid is a running process. A zombie left for
+// an init that does not reap counts as gone.
+func processAlive(pid int) bool {
+	if err := syscall.Kill(pid, 0); errors.Is(err, syscall.ESRCH) {
+		return false
+	}
+	stat, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return !os.IsNotExist(err)
+	}
+	// The state follows the parenthesised command name
+	fields := strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+1:]))
+	return len(fields) == 0 || fields[0] != "Z"
+}
+
+// waitForFile waits up to ten seconds for a line to be written to file and
+// returns it, or "" when none was.
+func waitForFile(file string) string {
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		if content, err := os.ReadFile(file); err == nil && strings.HasSuffix(string(content), "\n") {
+			return strings.TrimSpace(string(content))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return ""
+}
+
+// waitForExit waits up to ten seconds for pid to exit.
+func waitForExit(t *testing.T, pid int) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for processAlive(pid) {
+		if time.Now().After(deadline) {
+			t.Fatalf("process %d is still running", pid)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestKclExecResource_CancelCleansUp(t *testing.T) {
+	started := filepath.Join(t.TempDir(), "started")
+	h := newExecHarness(t, fakeKcl(t, `[ "$1" = version ] && { echo "0.11.0"; exit; }
+sleep 30 &
+echo "$! $PWD" > "`+started+`"
+wait`))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	h.ctx = ctx
+
+	// Interrupt once KCL is running, like Terraform does on Ctrl-C
+	info := make(chan []string, 1)
+	go func() {
+		info <- strings.Fields(waitForFile(started))
+		cancel()
+	}()
+	_, diags := h.apply(map[string]attr.Value{
+		"code":         types.StringValue("a = 1\n"),
+		"kill_timeout": types.Int64Value(1),
+	})
+
+	if !diags.HasError() || diags.Errors()[0].Summary() != "KCL Execution Cancelled" {
+		t.Fatalf("apply diagnostics = %v, want a cancellation error", diags)
+	}
+	fields := <-info
+	if len(fields) != 2 {
+		t.Fatalf("the fake KCL did not record its child, got %q", fields)
+	}
+	pid, err := strconv.Atoi(fields[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForExit(t, pid)
+	if _, err := os.Stat(fields[1]); !os.IsNotExist(err) {
+		t.Errorf("temporary code directory %s was not removed: %v", fields[1], err)
+	}
+}
+
+func TestKclExecResource_TimeoutKillsProcessGroup(t *testing.T) {
+	started := filepath.Join(t.TempDir(), "started")
+	h := newExecHarness(t, fakeKcl(t, `[ "$1" = version ] && { echo "0.11.0"; exit; }
+trap '' TERM
+sleep 60 &
+echo "$!" > "`+started+`"
+wait`))
+	dir := writeTestFiles(t, map[string]string{"main.k": "a = 1\n"})
+
+	start := time.Now()
+	_, diags := h.apply(map[string]attr.Value{
+		"source_dir":   types.StringValue(dir),
+		"timeout":      types.Int64Value(1),
+		"kill_timeout": types.Int64Value(1),
+	})
+	if !diags.HasError() || !strings.HasPrefix(diags.Errors()[0].Summary(), "KCL Execution Timed Out") {
+		t.Fatalf("apply diagnostics = %v, want a timeout error", diags)
+	}
+	if elapsed := time.Since(start); elapsed > 30*time.Second {
+		t.Errorf("the run took %s, well past its timeout", elapsed)
+	}
+
+	// Both processes ignore SIGTERM, and the sleeping one is not the direct
+	// child, so only the group kill after kill_timeout stops it
+	pid, err := strconv.Atoi(waitForFile(started))
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForExit(t, pid)
+}
+
+func TestConfigureGracefulStop_GroupKillStoppedAfterExit(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cmd := exec.CommandContext(ctx, "sleep", "30")
+	configureGracefulStopWithin(cmd, time.Hour)
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	// sleep exits on SIGTERM, well within the grace period
+	if _, err := runCapturingOutput(context.Background(), cmd); err == nil {
+		t.Fatal("the cancelled command succeeded")
+	}
+	if _, ok := pendingGroupKills.Load(cmd); ok {
+		t.Error("the group kill is still scheduled after the command exited")
+	}
+}
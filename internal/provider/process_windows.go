@@ -0,0 +1,25 @@
+// internal/provider/process_windows.go
+
+//go:build windows
+
+package provider
+
+import (
+	"os"
+	"os/exec"
+)
+
+// startProcessGroup is a no-op on Windows, where only the direct child is
+// stopped, as with exec.CommandContext's default.
+func startProcessGroup(cmd *exec.Cmd) {}
+
+// terminateProcess stops the process. Windows has no SIGTERM, so the process
+// is killed outright.
+func terminateProcess(p *os.Process) error {
+	return p.Kill()
+}
+
+// killProcess kills the process.
+func killProcess(p *os.Process) error {
+	return p.Kill()
+}
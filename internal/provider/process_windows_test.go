@@ -0,0 +1,140 @@
+// internal/provider/process_windows_test.go
+
+//go:build windows
+
+package provider
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// fakeKclCmd writes a batch file standing in for the KCL executable, since
+// the shell scripts of fakeKcl do not run on Windows. It answers
+// `kcl version` and otherwise runs script.
+func fakeKclCmd(t *testing.T, script string) string {
+	t.Helper()
+	binary := filepath.Join(t.TempDir(), "kcl.cmd")
+	content := "@echo off\r\nif \"%~1\"==\"version\" (echo 0.11.0& exit /b 0)\r\n" +
+		strings.ReplaceAll(script, "\n", "\r\n") + "\r\n"
+	if err := os.WriteFile(binary, []byte(content), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return binary
+}
+
+// waitForStart waits up to ten seconds for file to be created.
+func waitForStart(file string) bool {
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := os.Stat(file); err == nil {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
+
+func TestLookupKclExecutable_PathExt(t *testing.T) {
+	binary := fakeKclCmd(t, "exit /b 0")
+	t.Setenv("PATH", filepath.Dir(binary))
+
+	got, err := lookupKclExecutable("kcl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.EqualFold(got, binary) {
+		t.Errorf("lookupKclExecutable(kcl) = %s, want %s found through PATHEXT", got, binary)
+	}
+}
+
+func TestKclExecResource_WindowsRun(t *testing.T) {
+	h := newExecHarness(t, fakeKclCmd(t, "type main.k"))
+	dir := writeTestFiles(t, map[string]string{"main.k": "app:\n  name: web\n"})
+
+	model := h.mustApply(map[string]attr.Value{
+		"source_dir": types.StringValue(dir),
+		"format":     types.StringValue("yaml"),
+	})
+	if got := strings.TrimSpace(strings.ReplaceAll(model.Stdout.ValueString(), "\r\n", "\n")); got != "app:\n  name: web" {
+		t.Errorf("stdout = %q, want main.k as printed from the source directory", got)
+	}
+	if got := model.Result.String(); !strings.Contains(got, `"name":"web"`) {
+		t.Errorf("result = %s, want the parsed program output", got)
+	}
+}
+
+func TestKclExecResource_WindowsCancel(t *testing.T) {
+	started := filepath.Join(t.TempDir(), "started")
+	h := newExecHarness(t, fakeKclCmd(t, `echo started>"`+started+`"
+ping -n 60 127.0.0.1 >nul`))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	h.ctx = ctx
+
+	go func() {
+		waitForStart(started)
+		cancel()
+	}()
+	start := time.Now()
+	_, diags := h.apply(map[string]attr.Value{
+		"code":         types.StringValue("a = 1\n"),
+		"kill_timeout": types.Int64Value(1),
+	})
+	if !diags.HasError() || diags.Errors()[0].Summary() != "KCL Execution Cancelled" {
+		t.Fatalf("apply diagnostics = %v, want a cancellation error", diags)
+	}
+	// ping keeps the output pipes open, so only WaitDelay ends the wait
+	if elapsed := time.Since(start); elapsed > 30*time.Second {
+		t.Errorf("the cancelled run took %s", elapsed)
+	}
+}
+
+func TestKclExecResource_WindowsTimeout(t *testing.T) {
+	h := newExecHarness(t, fakeKclCmd(t, "ping -n 60 127.0.0.1 >nul"))
+	dir := writeTestFiles(t, map[string]string{"main.k": "a = 1\n"})
+
+	start := time.Now()
+	_, diags := h.apply(map[string]attr.Value{
+		"source_dir":   types.StringValue(dir),
+		"timeout":      types.Int64Value(1),
+		"kill_timeout": types.Int64Value(1),
+	})
+	if !diags.HasError() || !strings.HasPrefix(diags.Errors()[0].Summary(), "KCL Execution Timed Out") {
+		t.Fatalf("apply diagnostics = %v, want a timeout error", diags)
+	}
+	if elapsed := time.Since(start); elapsed > 30*time.Second {
+		t.Errorf("the run took %s, well past its timeout", elapsed)
+	}
+}
+
+func TestTerminateProcess(t *testing.T) {
+	cmd := exec.Command(fakeKclCmd(t, "ping -n 60 127.0.0.1 >nul"))
+	startProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	if err := terminateProcess(cmd.Process); err != nil {
+		t.Fatalf("terminateProcess: %v", err)
+	}
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("the terminated process exited successfully")
+		}
+	case <-time.After(10 * time.Second):
+		_ = killProcess(cmd.Process)
+		t.Fatal("the process is still running after terminateProcess")
+	}
+}
@@ -3,20 +3,65 @@ package provider
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
 
+	"github.com/hashicorp/go-version"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
-var _ provider.Provider = &kclProvider{}
+var (
+	_ provider.Provider              = &kclProvider{}
+	_ provider.ProviderWithFunctions = &kclProvider{}
+)
 
 type kclProvider struct {
 	// Add provider configuration fields here
-	KclPath string
-	version string
+	KclPath                 string
+	DefaultOutputTransforms []string
+	DefaultArgs             []string
+	DefaultEnvironment      map[string]string
+	CacheDir                string
+	// PkgPath is the KCL package storage exported as KCL_PKG_PATH to every
+	// KCL process, empty to leave KCL's default in place.
+	PkgPath string
+	// Registry is the OCI registry logged in to at configure time, empty
+	// when none is configured.
+	Registry         string
+	RegistryUsername string
+	RegistryPassword string
+	// KclVersion is the detected KCL version, set when supported_version
+	// or min_version is configured, or on first use of the cache.
+	KclVersion string
+	versionMu  sync.Mutex
+	// KclBinary is the absolute path kcl_path resolved to, empty when the
+	// executable could not be found at configure time.
+	KclBinary string
+	// Backend is how kcl_exec runs KCL, kclBackendBinary unless configured.
+	Backend string
+	// executionSlots bounds concurrent KCL processes; each running process
+	// holds one element. Nil means no limit.
+	executionSlots chan struct{}
+	// registrySlots bounds concurrent KCL processes that may pull packages
+	// not fetched before. Nil means no limit.
+	registrySlots chan struct{}
+	// packageLocks serializes the first runs touching each package, and
+	// fetchedPackages records the packages a run has fetched since.
+	packageMu       sync.Mutex
+	packageLocks    map[string]chan struct{}
+	fetchedPackages map[string]bool
+	version         string
 }
 
 func New(version string) func() provider.Provider {
@@ -35,7 +80,100 @@ func (p *kclProvider) Schema(_ context.Context, _ provider.SchemaRequest, resp *
 		Attributes: map[string]schema.Attribute{
 			"kcl_path": schema.StringAttribute{
 				Optional:    true,
-				Description: "Path to the KCL executable",
+				Description: "Path to the KCL executable. A bare name is looked up on PATH; on Windows the .exe extension may be left out",
+			},
+			"auto_install": schema.BoolAttribute{
+				Optional: true,
+				Description: "Download KCL kcl_version from the KCL GitHub releases when no executable is found, verify it " +
+					"against the published checksums and use it. Installs go below cache_dir, or the user cache directory, " +
+					"and are reused. This is the only way the provider downloads KCL itself (default: false)",
+			},
+			"backend": schema.StringAttribute{
+				Optional: true,
+				Description: "How kcl_exec runs KCL: \"binary\" runs the KCL executable, \"native\" evaluates programs inside the " +
+					"provider through kcl-go, without a process per run. The native backend only runs kcl run, maps args, arguments " +
+					"and settings_files onto kcl-go options and fails on flags it has no option for. It does not pass environment or " +
+					"stdin to KCL, and is only included in provider builds with the kclgo tag. Other resources and data sources " +
+					"always run the executable (default: \"binary\")",
+			},
+			"kcl_version": schema.StringAttribute{
+				Optional:    true,
+				Description: "KCL release to install with auto_install, e.g. \"0.10.0\". Required when auto_install is true",
+			},
+			"supported_version": schema.StringAttribute{
+				Optional: true,
+				Description: "Version constraint the KCL executable must satisfy, e.g. \">= 0.9.0, < 0.11.0\". " +
+					"Checked once when the provider is configured",
+			},
+			"min_version": schema.StringAttribute{
+				Optional: true,
+				Description: "Minimum KCL version, e.g. \"0.10.0\". The version reported by kcl version is checked once " +
+					"when the provider is configured",
+			},
+			"cache_dir": schema.StringAttribute{
+				Optional: true,
+				Description: "Directory where successful kcl_exec results are cached, keyed by a hash of the source directory " +
+					"contents, arguments, declared environment and the KCL version. A run with a matching key reuses the cached " +
+					"output instead of executing. Cached entries are trusted as-is, so the directory must only be writable by " +
+					"trusted users; anyone able to write it can substitute the output of any cached run. Unless pkg_path is set, " +
+					"downloaded KCL packages are stored in its pkg subdirectory",
+			},
+			"pkg_path": schema.StringAttribute{
+				Optional: true,
+				Description: "Directory where KCL stores downloaded packages, exported as KCL_PKG_PATH to every KCL process " +
+					"the provider runs, so resources share one warm package cache. Defaults to the pkg directory below cache_dir " +
+					"when that is set. The directory is created if needed. It is set even for kcl_exec resources with " +
+					"inherit_environment = false, and a KCL_PKG_PATH in a resource's environment or vendor_dir takes precedence",
+			},
+			"default_args": schema.ListAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Arguments placed before the args of every kcl_exec resource. Resource args are appended after them, " +
+					"so for repeatable flags such as -D a later resource value takes effect",
+			},
+			"default_environment": schema.MapAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Environment variables set for every kcl_exec resource. A variable of the same name in a resource's " +
+					"environment takes precedence over the default",
+			},
+			"registry": schema.StringAttribute{
+				Optional: true,
+				Description: "OCI registry hosting private KCL packages, e.g. \"ghcr.io\". When set, the provider runs " +
+					"kcl registry login with registry_username and the registry password when it is configured, so resources can pull from it",
+			},
+			"registry_username": schema.StringAttribute{
+				Optional:    true,
+				Sensitive:   true,
+				Description: "Username for registry",
+			},
+			"registry_password": schema.StringAttribute{
+				Optional:    true,
+				Sensitive:   true,
+				Description: "Password or token for registry. Conflicts with registry_password_env",
+			},
+			"registry_password_env": schema.StringAttribute{
+				Optional: true,
+				Description: "Name of an environment variable holding the password or token for registry, read when the provider " +
+					"is configured. Keeps the secret out of the configuration. Conflicts with registry_password",
+			},
+			"max_concurrent_executions": schema.Int64Attribute{
+				Optional: true,
+				Description: "Maximum number of KCL processes the provider runs at the same time. Further runs wait for a " +
+					"running one to finish. Unset means no limit beyond Terraform's own parallelism",
+			},
+			"registry_concurrency": schema.Int64Attribute{
+				Optional: true,
+				Description: "Maximum number of KCL processes that may pull packages from registries at the same time. Only " +
+					"runs in a package whose kcl.mod declares a dependency no earlier run has fetched count, and runs needing " +
+					"the same dependency always take turns, so a large apply downloads each package once. Waiting runs do " +
+					"not hold a max_concurrent_executions slot. Unset means no limit",
+			},
+			"default_output_transforms": schema.ListAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Transforms applied, in order, to the output of every resource: sort_keys, strip_nulls, trim. " +
+					"A resource's own output_transforms replaces this list entirely; set it to an empty list to disable the defaults",
 			},
 		},
 	}
@@ -43,7 +181,23 @@ func (p *kclProvider) Schema(_ context.Context, _ provider.SchemaRequest, resp *
 
 func (p *kclProvider) Configure(ctx context.Context, req provider.ConfigureRequest, resp *provider.ConfigureResponse) {
 	var config struct {
-		KclPath types.String `tfsdk:"kcl_path"`
+		KclPath                 types.String `tfsdk:"kcl_path"`
+		DefaultOutputTransforms types.List   `tfsdk:"default_output_transforms"`
+		DefaultArgs             types.List   `tfsdk:"default_args"`
+		DefaultEnvironment      types.Map    `tfsdk:"default_environment"`
+		SupportedVersion        types.String `tfsdk:"supported_version"`
+		MinVersion              types.String `tfsdk:"min_version"`
+		CacheDir                types.String `tfsdk:"cache_dir"`
+		PkgPath                 types.String `tfsdk:"pkg_path"`
+		Registry                types.String `tfsdk:"registry"`
+		RegistryUsername        types.String `tfsdk:"registry_username"`
+		RegistryPassword        types.String `tfsdk:"registry_password"`
+		RegistryPasswordEnv     types.String `tfsdk:"registry_password_env"`
+		MaxConcurrentExecutions types.Int64  `tfsdk:"max_concurrent_executions"`
+		RegistryConcurrency     types.Int64  `tfsdk:"registry_concurrency"`
+		AutoInstall             types.Bool   `tfsdk:"auto_install"`
+		InstallVersion          types.String `tfsdk:"kcl_version"`
+		Backend                 types.String `tfsdk:"backend"`
 	}
 
 	diags := req.Config.Get(ctx, &config)
@@ -57,16 +211,379 @@ func (p *kclProvider) Configure(ctx context.Context, req provider.ConfigureReque
 		p.KclPath = config.KclPath.ValueString()
 	}
 
-	// Make the provider configuration available to resources
+	p.Backend = kclBackendBinary
+	if !config.Backend.IsNull() {
+		p.Backend = config.Backend.ValueString()
+		switch p.Backend {
+		case kclBackendBinary:
+		case kclBackendNative:
+			if !nativeBackendAvailable {
+				resp.Diagnostics.AddAttributeError(path.Root("backend"), "Native Backend Unavailable",
+					"The native backend cannot be used: "+errNativeBackendUnavailable.Error()+".")
+				return
+			}
+		default:
+			resp.Diagnostics.AddAttributeError(path.Root("backend"), "Invalid Backend",
+				fmt.Sprintf("unknown backend %q, expected one of: %s", p.Backend, strings.Join(validKclBackends, ", ")))
+			return
+		}
+	}
+
+	if !config.DefaultOutputTransforms.IsNull() {
+		var transforms []string
+		diags := config.DefaultOutputTransforms.ElementsAs(ctx, &transforms, false)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		if err := validateOutputTransforms(transforms); err != nil {
+			resp.Diagnostics.AddError("Invalid Provider Configuration", err.Error())
+			return
+		}
+		p.DefaultOutputTransforms = transforms
+	}
+
+	if !config.MaxConcurrentExecutions.IsNull() {
+		limit := config.MaxConcurrentExecutions.ValueInt64()
+		if limit < 1 {
+			resp.Diagnostics.AddAttributeError(path.Root("max_concurrent_executions"), "Invalid Provider Configuration",
+				fmt.Sprintf("max_concurrent_executions must be at least 1, got %d", limit))
+			return
+		}
+		p.executionSlots = make(chan struct{}, limit)
+	}
+
+	if !config.RegistryConcurrency.IsNull() {
+		limit := config.RegistryConcurrency.ValueInt64()
+		if limit < 1 {
+			resp.Diagnostics.AddAttributeError(path.Root("registry_concurrency"), "Invalid Provider Configuration",
+				fmt.Sprintf("registry_concurrency must be at least 1, got %d", limit))
+			return
+		}
+		p.registrySlots = make(chan struct{}, limit)
+	}
+
+	if !config.CacheDir.IsNull() {
+		cacheDir, err := filepath.Abs(config.CacheDir.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("cache_dir"), "Invalid Cache Directory", err.Error())
+			return
+		}
+		p.CacheDir = cacheDir
+	}
+
+	switch {
+	case !config.PkgPath.IsNull():
+		pkgPath, err := filepath.Abs(config.PkgPath.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("pkg_path"), "Invalid Package Directory", err.Error())
+			return
+		}
+		p.PkgPath = pkgPath
+	case p.CacheDir != "":
+		p.PkgPath = filepath.Join(p.CacheDir, "pkg")
+	}
+	if p.PkgPath != "" {
+		if err := os.MkdirAll(p.PkgPath, 0o755); err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("pkg_path"), "Invalid Package Directory",
+				"Unable to create the package directory: "+err.Error())
+			return
+		}
+	}
+
+	if !config.DefaultArgs.IsNull() {
+		diags := config.DefaultArgs.ElementsAs(ctx, &p.DefaultArgs, false)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	if !config.DefaultEnvironment.IsNull() {
+		diags := config.DefaultEnvironment.ElementsAs(ctx, &p.DefaultEnvironment, false)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	// Resolve the executable once so logs show exactly what runs. A missing
+	// executable is only an error for the resources that need it, unless
+	// it is to be installed.
+	binary, err := p.resolveKclCommand()
+	if err != nil && config.AutoInstall.ValueBool() {
+		if config.InstallVersion.IsNull() || config.InstallVersion.ValueString() == "" {
+			resp.Diagnostics.AddAttributeError(path.Root("kcl_version"), "Missing KCL Version",
+				"auto_install requires kcl_version, the KCL release to install.")
+			return
+		}
+		installDir, dirErr := p.defaultKclInstallDir()
+		if dirErr != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("auto_install"), "KCL Installation Failed", dirErr.Error())
+			return
+		}
+		binary, err = installKcl(ctx, config.InstallVersion.ValueString(), installDir)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("auto_install"), "KCL Installation Failed", err.Error())
+			return
+		}
+	}
+	if err == nil {
+		p.KclBinary = binary
+		tflog.Debug(ctx, "Resolved KCL executable", map[string]interface{}{
+			"command": p.kclCommand(),
+			"path":    binary,
+		})
+	}
+
+	// Fail fast when the installed KCL does not satisfy the required version
+	if !config.SupportedVersion.IsNull() || !config.MinVersion.IsNull() {
+		var (
+			constraints version.Constraints
+			minVersion  *version.Version
+			err         error
+		)
+		if !config.SupportedVersion.IsNull() {
+			constraints, err = version.NewConstraint(config.SupportedVersion.ValueString())
+			if err != nil {
+				resp.Diagnostics.AddAttributeError(path.Root("supported_version"), "Invalid Version Constraint", err.Error())
+				return
+			}
+		}
+		if !config.MinVersion.IsNull() {
+			minVersion, err = version.NewVersion(config.MinVersion.ValueString())
+			if err != nil {
+				resp.Diagnostics.AddAttributeError(path.Root("min_version"), "Invalid Minimum Version", err.Error())
+				return
+			}
+		}
+
+		// The native backend is checked instead of the executable it replaces
+		var (
+			kclBinary string
+			detected  *version.Version
+		)
+		if p.nativeBackend() {
+			kclBinary = nativeKclCommand
+			var native string
+			if native, err = nativeKclVersion(); err == nil {
+				detected, err = version.NewVersion(native)
+			}
+		} else {
+			kclBinary, err = p.resolveKclCommand()
+			if err != nil {
+				resp.Diagnostics.AddError("KCL Executable Not Found", err.Error())
+				return
+			}
+			detected, err = detectKclVersion(ctx, kclBinary)
+		}
+		if err != nil {
+			resp.Diagnostics.AddError("KCL Version Detection Failed", err.Error())
+			return
+		}
+		p.KclVersion = detected.String()
+
+		tflog.Info(ctx, "Detected KCL version", map[string]interface{}{
+			"path":    kclBinary,
+			"version": p.KclVersion,
+		})
+
+		if minVersion != nil && detected.LessThan(minVersion) {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("min_version"),
+				"Unsupported KCL Version",
+				fmt.Sprintf("Detected KCL %s at %s, but the provider requires at least %s.",
+					detected, kclBinary, minVersion),
+			)
+			return
+		}
+
+		if constraints != nil && !constraints.Check(detected) {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("supported_version"),
+				"Unsupported KCL Version",
+				fmt.Sprintf("Detected KCL %s at %s, but the provider requires %s.",
+					detected, kclBinary, constraints),
+			)
+			return
+		}
+	}
+
+	// Log in to the registry now, so bad credentials fail the plan up front
+	// rather than surfacing as a package pull error inside some resource
+	if !config.Registry.IsNull() {
+		password := config.RegistryPassword.ValueString()
+		switch {
+		case !config.RegistryPassword.IsNull() && !config.RegistryPasswordEnv.IsNull():
+			resp.Diagnostics.AddAttributeError(path.Root("registry_password_env"), "Conflicting Registry Credentials",
+				"Only one of registry_password and registry_password_env may be set.")
+			return
+		case !config.RegistryPasswordEnv.IsNull():
+			name := config.RegistryPasswordEnv.ValueString()
+			value, ok := os.LookupEnv(name)
+			if !ok {
+				resp.Diagnostics.AddAttributeError(path.Root("registry_password_env"), "Registry Password Not Set",
+					fmt.Sprintf("The environment variable %q is not set.", name))
+				return
+			}
+			password = value
+		}
+		if config.RegistryUsername.IsNull() || password == "" {
+			resp.Diagnostics.AddAttributeError(path.Root("registry"), "Missing Registry Credentials",
+				"registry requires registry_username and either registry_password or registry_password_env.")
+			return
+		}
+
+		kclBinary, err := p.resolveKclCommand()
+		if err != nil {
+			resp.Diagnostics.AddError("KCL Executable Not Found", err.Error())
+			return
+		}
+
+		p.Registry = config.Registry.ValueString()
+		p.RegistryUsername = config.RegistryUsername.ValueString()
+		p.RegistryPassword = password
+		if err := kclRegistryLogin(ctx, p, kclBinary, p.Registry, p.RegistryUsername, p.RegistryPassword); err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("registry"), "KCL Registry Login Failed", err.Error())
+			return
+		}
+	}
+
+	// Make the provider configuration available to resources and data sources
 	resp.ResourceData = p
+	resp.DataSourceData = p
+}
+
+// nativeBackend reports whether kcl_exec evaluates programs through kcl-go.
+func (p *kclProvider) nativeBackend() bool {
+	return p != nil && p.Backend == kclBackendNative
+}
+
+// kclCommand returns the KCL executable to run, honoring kcl_path.
+func (p *kclProvider) kclCommand() string {
+	if p != nil && p.KclPath != "" {
+		return p.KclPath
+	}
+	return "kcl"
+}
+
+// kclVersion returns the detected KCL version, or "unknown" when no version
+// check was configured.
+func (p *kclProvider) kclVersion() string {
+	if p != nil && p.KclVersion != "" {
+		return p.KclVersion
+	}
+	return "unknown"
+}
+
+// detectedKclVersion returns the KCL version, running `kcl version` the
+// first time it is needed when no version check did so at configure time.
+func (p *kclProvider) detectedKclVersion(ctx context.Context, kclBinary string) (string, error) {
+	p.versionMu.Lock()
+	defer p.versionMu.Unlock()
+
+	if p.KclVersion == "" {
+		detected, err := detectKclVersion(ctx, kclBinary)
+		if err != nil {
+			return "", err
+		}
+		p.KclVersion = detected.String()
+	}
+	return p.KclVersion, nil
+}
+
+// resolveKclCommand returns the absolute path of the KCL executable, looking
+// it up on PATH when kcl_path is a bare name.
+func (p *kclProvider) resolveKclCommand() (string, error) {
+	if p != nil && p.KclBinary != "" {
+		return p.KclBinary, nil
+	}
+
+	return lookupKclExecutable(p.kclCommand())
+}
+
+// lookupKclExecutable returns the absolute path of command, looking it up on
+// PATH when it is a bare name. It fails unless the file is executable.
+func lookupKclExecutable(command string) (string, error) {
+	resolved, err := exec.LookPath(command)
+	if err != nil {
+		return "", fmt.Errorf("the KCL executable %q could not be found: %v. "+
+			"Install KCL (https://www.kcl-lang.io/docs/user_docs/getting-started/install) "+
+			"or set kcl_path in the provider configuration to its location", command, err)
+	}
+	return filepath.Abs(resolved)
+}
+
+// runKcl runs a KCL command like runCapturingOutput, once the packages it
+// may pull are free to fetch and one of the max_concurrent_executions slots
+// is free. Waiting ends with ctx.
+func (p *kclProvider) runKcl(ctx context.Context, cmd *exec.Cmd) (commandOutput, error) {
+	return p.runKclLogging(ctx, cmd, tflog.Debug)
+}
+
+// runKclLogging is runKcl with the output lines logged through logf.
+func (p *kclProvider) runKclLogging(ctx context.Context, cmd *exec.Cmd, logf logFunc) (result commandOutput, err error) {
+	if p != nil {
+		release, acquireErr := p.acquirePackages(ctx, kclModPackages(cmd.Dir))
+		if acquireErr != nil {
+			return commandOutput{}, acquireErr
+		}
+		defer func() { release(err == nil) }()
+	}
+	if p != nil && p.executionSlots != nil {
+		select {
+		case p.executionSlots <- struct{}{}:
+			defer func() { <-p.executionSlots }()
+		case <-ctx.Done():
+			return commandOutput{}, fmt.Errorf("waiting for a free KCL execution slot: %w", ctx.Err())
+		}
+	}
+	if cmd.Env == nil {
+		cmd.Env = p.kclEnvironment()
+	}
+	return runStreamingOutput(ctx, cmd, logf)
+}
+
+// kclEnvironment returns the inherited environment with the provider's
+// package storage added. Variables appended to it take precedence.
+func (p *kclProvider) kclEnvironment() []string {
+	return append(os.Environ(), p.pkgPathEnv()...)
+}
+
+// pkgPathEnv returns the KCL_PKG_PATH assignment for pkg_path, if any.
+func (p *kclProvider) pkgPathEnv() []string {
+	if p == nil || p.PkgPath == "" {
+		return nil
+	}
+	return []string{pkgPathEnvVar + "=" + p.PkgPath}
 }
 
 func (p *kclProvider) Resources(_ context.Context) []func() resource.Resource {
 	return []func() resource.Resource{
 		NewKclExecResource,
+		NewKclModResource,
+		NewKclFmtResource,
+		NewKclImportResource,
+		NewKclDocResource,
+		NewKclModPushResource,
 	}
 }
 
 func (p *kclProvider) DataSources(_ context.Context) []func() datasource.DataSource {
-	return nil
+	return []func() datasource.DataSource{
+		NewKclRenderDataSource,
+		NewKclRunDataSource,
+		NewKclVetDataSource,
+		NewKclTestDataSource,
+		NewKclVersionDataSource,
+	}
+}
+
+func (p *kclProvider) Functions(_ context.Context) []func() function.Function {
+	return []func() function.Function{
+		NewRenderFunction(p),
+		NewIsValidFunction(p),
+		NewDocumentsFunction,
+	}
 }
@@ -0,0 +1,539 @@
+// internal/provider/provider_test.go
+package provider
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// testAccKclVersion is the KCL release acceptance tests install when no kcl
+// executable is on PATH.
+const testAccKclVersion = "0.11.0"
+
+// testAccKclBinary skips the test unless TF_ACC is set and returns a real
+// KCL executable, the one on PATH or else testAccKclVersion installed with
+// auto_install's installer.
+func testAccKclBinary(t *testing.T) string {
+	t.Helper()
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("acceptance tests run only with TF_ACC set")
+	}
+
+	if binary, err := lookupKclExecutable("kcl"); err == nil {
+		return binary
+	}
+	installDir, err := (&kclProvider{}).defaultKclInstallDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	binary, err := installKcl(context.Background(), testAccKclVersion, installDir)
+	if err != nil {
+		t.Fatalf("installing KCL %s: %v", testAccKclVersion, err)
+	}
+	return binary
+}
+
+// fakeKcl writes script as a shell script standing in for the KCL
+// executable and returns its path. The test is skipped on Windows.
+func fakeKcl(t *testing.T, script string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake KCL executable is a shell script")
+	}
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh to run the fake KCL executable")
+	}
+
+	binary := filepath.Join(t.TempDir(), "kcl")
+	if err := os.WriteFile(binary, []byte("#!/bin/sh\n"+script+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return binary
+}
+
+// writeTestFiles creates files, keyed by slash-separated path, below a new
+// temporary directory and returns the directory.
+func writeTestFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// configureProvider runs Configure on a new provider with config, leaving
+// every other attribute null.
+func configureProvider(t *testing.T, config map[string]attr.Value) (*kclProvider, diag.Diagnostics) {
+	t.Helper()
+	ctx := context.Background()
+	p := New("test")().(*kclProvider)
+
+	var schemaResp provider.SchemaResponse
+	p.Schema(ctx, provider.SchemaRequest{}, &schemaResp)
+	objectType := schemaResp.Schema.Type().TerraformType(ctx).(tftypes.Object)
+	values := make(map[string]tftypes.Value, len(objectType.AttributeTypes))
+	for name, attrType := range objectType.AttributeTypes {
+		values[name] = tftypes.NewValue(attrType, nil)
+	}
+	for name, value := range config {
+		tfValue, err := value.ToTerraformValue(ctx)
+		if err != nil {
+			t.Fatalf("config %s: %v", name, err)
+		}
+		values[name] = tfValue
+	}
+
+	var resp provider.ConfigureResponse
+	p.Configure(ctx, provider.ConfigureRequest{
+		Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: tftypes.NewValue(objectType, values)},
+	}, &resp)
+	return p, resp.Diagnostics
+}
+
+func TestKclProvider_SupportedVersion(t *testing.T) {
+	kcl := fakeKcl(t, `echo "kcl version 0.11.2"`)
+	tests := []struct {
+		constraint string
+		// wantErr is the summary of the expected error, empty for none
+		wantErr string
+	}{
+		{">= 0.11.0", ""},
+		{"~> 0.11", ""},
+		{">= 0.10, < 0.12", ""},
+		{">= 0.12.0", "Unsupported KCL Version"},
+		{"< 0.11.2", "Unsupported KCL Version"},
+		{"not a constraint", "Invalid Version Constraint"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			p, diags := configureProvider(t, map[string]attr.Value{
+				"kcl_path":          types.StringValue(kcl),
+				"supported_version": types.StringValue(tt.constraint),
+			})
+			if tt.wantErr == "" {
+				if diags.HasError() {
+					t.Fatalf("Configure: %v", diags)
+				}
+				if p.KclVersion != "0.11.2" {
+					t.Errorf("KclVersion = %q, want the detected 0.11.2", p.KclVersion)
+				}
+				return
+			}
+			if !diags.HasError() || diags.Errors()[0].Summary() != tt.wantErr {
+				t.Fatalf("Configure diagnostics = %v, want %q", diags, tt.wantErr)
+			}
+			if tt.wantErr == "Unsupported KCL Version" {
+				detail := diags.Errors()[0].Detail()
+				if !strings.Contains(detail, "0.11.2") || !strings.Contains(detail, tt.constraint) {
+					t.Errorf("detail %q does not name the detected and required versions", detail)
+				}
+			}
+		})
+	}
+}
+
+// execHarness plans and applies kcl_exec configurations in process, the way
+// Terraform drives the provider, and keeps the resulting state.
+type execHarness struct {
+	t *testing.T
+	// ctx is the context Terraform would cancel on interrupt
+	ctx      context.Context
+	resource *KclExecResource
+	schema   schema.Schema
+	state    tftypes.Value
+	// private is the private state of the last apply, nil before the first
+	private interface{}
+}
+
+// newExecHarness returns a harness for a kcl_exec resource whose provider
+// runs kclBinary.
+func newExecHarness(t *testing.T, kclBinary string) *execHarness {
+	t.Helper()
+	r := &KclExecResource{provider: &kclProvider{KclPath: kclBinary, KclBinary: kclBinary}}
+
+	var schemaResp resource.SchemaResponse
+	r.Schema(context.Background(), resource.SchemaRequest{}, &schemaResp)
+	if schemaResp.Diagnostics.HasError() {
+		t.Fatalf("schema: %v", schemaResp.Diagnostics)
+	}
+
+	return &execHarness{
+		t:        t,
+		ctx:      context.Background(),
+		resource: r,
+		schema:   schemaResp.Schema,
+		state:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(context.Background()), nil),
+	}
+}
+
+// apply validates config, plans it against the current state, applies the
+// plan and records the new state. Like Terraform, it fails the test when a
+// known planned value differs from the applied one.
+func (h *execHarness) apply(config map[string]attr.Value) (KclExecResourceModel, diag.Diagnostics) {
+	h.t.Helper()
+	ctx := h.ctx
+	objectType := h.schema.Type().TerraformType(ctx).(tftypes.Object)
+
+	configValues := make(map[string]tftypes.Value, len(objectType.AttributeTypes))
+	for name, attrType := range objectType.AttributeTypes {
+		configValues[name] = tftypes.NewValue(attrType, nil)
+	}
+	for name, value := range config {
+		tfValue, err := value.ToTerraformValue(ctx)
+		if err != nil {
+			h.t.Fatalf("config %s: %v", name, err)
+		}
+		configValues[name] = tfValue
+	}
+	tfConfig := tfsdk.Config{Schema: h.schema, Raw: tftypes.NewValue(objectType, configValues)}
+
+	var diags diag.Diagnostics
+	var validateResp resource.ValidateConfigResponse
+	h.resource.ValidateConfig(ctx, resource.ValidateConfigRequest{Config: tfConfig}, &validateResp)
+	diags.Append(validateResp.Diagnostics...)
+	if diags.HasError() {
+		return KclExecResourceModel{}, diags
+	}
+
+	// Computed attributes keep their prior value, unless anything changed,
+	// in which case the framework marks them unknown
+	var prior map[string]tftypes.Value
+	if !h.state.IsNull() {
+		if err := h.state.As(&prior); err != nil {
+			h.t.Fatal(err)
+		}
+	}
+	proposed := make(map[string]tftypes.Value, len(configValues))
+	for name, value := range configValues {
+		proposed[name] = value
+		if attribute, ok := h.schema.Attributes[name]; ok && attribute.IsComputed() && value.IsNull() && prior != nil {
+			proposed[name] = prior[name]
+		}
+	}
+	if prior == nil || !tftypes.NewValue(objectType, proposed).Equal(h.state) {
+		for name, attribute := range h.schema.Attributes {
+			if attribute.IsComputed() && configValues[name].IsNull() {
+				proposed[name] = tftypes.NewValue(objectType.AttributeTypes[name], tftypes.UnknownValue)
+			}
+		}
+	}
+	plan := tfsdk.Plan{Schema: h.schema, Raw: tftypes.NewValue(objectType, proposed)}
+	state := tfsdk.State{Schema: h.schema, Raw: h.state}
+
+	modifyResp := resource.ModifyPlanResponse{Plan: plan}
+	h.resource.ModifyPlan(ctx, resource.ModifyPlanRequest{Config: tfConfig, Plan: plan, State: state}, &modifyResp)
+	diags.Append(modifyResp.Diagnostics...)
+	if diags.HasError() {
+		return KclExecResourceModel{}, diags
+	}
+	plan = modifyResp.Plan
+
+	// Terraform does not call Update when nothing changed
+	if !h.state.IsNull() && plan.Raw.Equal(h.state) {
+		var model KclExecResourceModel
+		diags.Append(state.Get(ctx, &model)...)
+		return model, diags
+	}
+
+	newState := tfsdk.State{Schema: h.schema, Raw: plan.Raw.Copy()}
+	var private interface{}
+	if h.state.IsNull() {
+		resp := resource.CreateResponse{State: newState}
+		setPrivate(&resp, nil)
+		h.resource.Create(ctx, resource.CreateRequest{Config: tfConfig, Plan: plan}, &resp)
+		diags.Append(resp.Diagnostics...)
+		newState, private = resp.State, privateOf(&resp)
+	} else {
+		req := resource.UpdateRequest{Config: tfConfig, Plan: plan, State: state}
+		setPrivate(&req, h.private)
+		resp := resource.UpdateResponse{State: newState}
+		setPrivate(&resp, h.private)
+		h.resource.Update(ctx, req, &resp)
+		diags.Append(resp.Diagnostics...)
+		newState, private = resp.State, privateOf(&resp)
+	}
+	if diags.HasError() {
+		return KclExecResourceModel{}, diags
+	}
+	h.private = private
+
+	var planned, applied map[string]tftypes.Value
+	if err := plan.Raw.As(&planned); err != nil {
+		h.t.Fatal(err)
+	}
+	if err := newState.Raw.As(&applied); err != nil {
+		h.t.Fatal(err)
+	}
+	for name, value := range planned {
+		if value.IsFullyKnown() && !value.Equal(applied[name]) {
+			h.t.Errorf("provider produced inconsistent result after apply: %s planned as %s, applied as %s", name, value, applied[name])
+		}
+	}
+	h.state = newState.Raw
+
+	var model KclExecResourceModel
+	diags.Append(newState.Get(ctx, &model)...)
+	return model, diags
+}
+
+// mustApply is apply failing the test on error diagnostics.
+func (h *execHarness) mustApply(config map[string]attr.Value) KclExecResourceModel {
+	h.t.Helper()
+	model, diags := h.apply(config)
+	if diags.HasError() {
+		h.t.Fatalf("apply: %v", diags)
+	}
+	return model
+}
+
+// resourceHarness drives the CRUD methods of any resource in process, keeping
+// its state and private state between calls the way Terraform does.
+type resourceHarness struct {
+	t        *testing.T
+	ctx      context.Context
+	resource resource.Resource
+	schema   schema.Schema
+	state    tftypes.Value
+	// private is the private state of the last call, nil before the first
+	private interface{}
+}
+
+// newResourceHarness returns a harness for r, which must already hold its
+// provider.
+func newResourceHarness(t *testing.T, r resource.Resource) *resourceHarness {
+	t.Helper()
+	var schemaResp resource.SchemaResponse
+	r.Schema(context.Background(), resource.SchemaRequest{}, &schemaResp)
+	if schemaResp.Diagnostics.HasError() {
+		t.Fatalf("schema: %v", schemaResp.Diagnostics)
+	}
+	return &resourceHarness{
+		t:        t,
+		ctx:      context.Background(),
+		resource: r,
+		schema:   schemaResp.Schema,
+		state:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(context.Background()), nil),
+	}
+}
+
+// setPrivate sets the Private field of the request or response target
+// points to, to private or to an empty private state when private is nil.
+// The framework's private state type is internal, so it is only reachable
+// through reflection.
+func setPrivate(target, private interface{}) {
+	field := reflect.ValueOf(target).Elem().FieldByName("Private")
+	if private == nil || reflect.ValueOf(private).IsNil() {
+		field.Set(reflect.New(field.Type().Elem()))
+		return
+	}
+	field.Set(reflect.ValueOf(private))
+}
+
+// privateOf returns the Private field of the response target points to.
+func privateOf(target interface{}) interface{} {
+	return reflect.ValueOf(target).Elem().FieldByName("Private").Interface()
+}
+
+// apply validates config, plans it against the current state and creates or
+// updates the resource. Computed attributes left out of config are planned
+// unknown, and ValidateConfig and ModifyPlan run when the resource has them.
+func (h *resourceHarness) apply(config map[string]attr.Value) diag.Diagnostics {
+	h.t.Helper()
+	ctx := h.ctx
+	objectType := h.schema.Type().TerraformType(ctx).(tftypes.Object)
+
+	values := make(map[string]tftypes.Value, len(objectType.AttributeTypes))
+	for name, attrType := range objectType.AttributeTypes {
+		values[name] = tftypes.NewValue(attrType, nil)
+	}
+	for name, value := range config {
+		tfValue, err := value.ToTerraformValue(ctx)
+		if err != nil {
+			h.t.Fatalf("config %s: %v", name, err)
+		}
+		values[name] = tfValue
+	}
+	tfConfig := tfsdk.Config{Schema: h.schema, Raw: tftypes.NewValue(objectType, values)}
+
+	var diags diag.Diagnostics
+	if validator, ok := h.resource.(resource.ResourceWithValidateConfig); ok {
+		var resp resource.ValidateConfigResponse
+		validator.ValidateConfig(ctx, resource.ValidateConfigRequest{Config: tfConfig}, &resp)
+		diags.Append(resp.Diagnostics...)
+		if diags.HasError() {
+			return diags
+		}
+	}
+
+	for name, attribute := range h.schema.Attributes {
+		if attribute.IsComputed() && values[name].IsNull() {
+			values[name] = tftypes.NewValue(objectType.AttributeTypes[name], tftypes.UnknownValue)
+		}
+	}
+	plan := tfsdk.Plan{Schema: h.schema, Raw: tftypes.NewValue(objectType, values)}
+	state := tfsdk.State{Schema: h.schema, Raw: h.state}
+
+	if modifier, ok := h.resource.(resource.ResourceWithModifyPlan); ok {
+		resp := resource.ModifyPlanResponse{Plan: plan}
+		modifier.ModifyPlan(ctx, resource.ModifyPlanRequest{Config: tfConfig, Plan: plan, State: state}, &resp)
+		diags.Append(resp.Diagnostics...)
+		if diags.HasError() {
+			return diags
+		}
+		plan = resp.Plan
+	}
+
+	newState := tfsdk.State{Schema: h.schema, Raw: plan.Raw.Copy()}
+	if h.state.IsNull() {
+		resp := resource.CreateResponse{State: newState}
+		setPrivate(&resp, nil)
+		h.resource.Create(ctx, resource.CreateRequest{Config: tfConfig, Plan: plan}, &resp)
+		diags.Append(resp.Diagnostics...)
+		newState, h.private = resp.State, privateOf(&resp)
+	} else {
+		req := resource.UpdateRequest{Config: tfConfig, Plan: plan, State: state}
+		setPrivate(&req, h.private)
+		resp := resource.UpdateResponse{State: newState}
+		setPrivate(&resp, h.private)
+		h.resource.Update(ctx, req, &resp)
+		diags.Append(resp.Diagnostics...)
+		newState, h.private = resp.State, privateOf(&resp)
+	}
+	if !diags.HasError() {
+		h.state = newState.Raw
+	}
+	return diags
+}
+
+// read refreshes the state, as terraform plan does before planning. It
+// reports whether the resource still exists.
+func (h *resourceHarness) read() (bool, diag.Diagnostics) {
+	h.t.Helper()
+	state := tfsdk.State{Schema: h.schema, Raw: h.state}
+	req := resource.ReadRequest{State: state}
+	setPrivate(&req, h.private)
+	resp := resource.ReadResponse{State: tfsdk.State{Schema: h.schema, Raw: h.state.Copy()}}
+	setPrivate(&resp, h.private)
+	h.resource.Read(h.ctx, req, &resp)
+	if !resp.Diagnostics.HasError() {
+		h.state, h.private = resp.State.Raw, privateOf(&resp)
+	}
+	return !h.state.IsNull(), resp.Diagnostics
+}
+
+// destroy deletes the resource.
+func (h *resourceHarness) destroy() diag.Diagnostics {
+	h.t.Helper()
+	req := resource.DeleteRequest{State: tfsdk.State{Schema: h.schema, Raw: h.state}}
+	setPrivate(&req, h.private)
+	resp := resource.DeleteResponse{State: tfsdk.State{Schema: h.schema, Raw: h.state}}
+	setPrivate(&resp, h.private)
+	h.resource.Delete(h.ctx, req, &resp)
+	if !resp.Diagnostics.HasError() {
+		h.state = tftypes.NewValue(h.schema.Type().TerraformType(h.ctx), nil)
+	}
+	return resp.Diagnostics
+}
+
+// model decodes the current state into target, a pointer to the resource's
+// model.
+func (h *resourceHarness) model(target interface{}) {
+	h.t.Helper()
+	if diags := (tfsdk.State{Schema: h.schema, Raw: h.state}).Get(h.ctx, target); diags.HasError() {
+		h.t.Fatalf("state: %v", diags)
+	}
+}
+
+// mustApply is apply failing the test on error diagnostics.
+func (h *resourceHarness) mustApply(config map[string]attr.Value) {
+	h.t.Helper()
+	if diags := h.apply(config); diags.HasError() {
+		h.t.Fatalf("apply: %v", diags)
+	}
+}
+
+// mustRead is read failing the test on error diagnostics.
+func (h *resourceHarness) mustRead() bool {
+	h.t.Helper()
+	exists, diags := h.read()
+	if diags.HasError() {
+		h.t.Fatalf("read: %v", diags)
+	}
+	return exists
+}
+
+// readDataSource reads d, which must already hold its provider, with config
+// and decodes the resulting state into target.
+func readDataSource(t *testing.T, d datasource.DataSource, config map[string]attr.Value, target interface{}) diag.Diagnostics {
+	t.Helper()
+	ctx := context.Background()
+	var schemaResp datasource.SchemaResponse
+	d.Schema(ctx, datasource.SchemaRequest{}, &schemaResp)
+	if schemaResp.Diagnostics.HasError() {
+		t.Fatalf("schema: %v", schemaResp.Diagnostics)
+	}
+	objectType := schemaResp.Schema.Type().TerraformType(ctx).(tftypes.Object)
+
+	values := make(map[string]tftypes.Value, len(objectType.AttributeTypes))
+	for name, attrType := range objectType.AttributeTypes {
+		values[name] = tftypes.NewValue(attrType, nil)
+	}
+	for name, value := range config {
+		tfValue, err := value.ToTerraformValue(ctx)
+		if err != nil {
+			t.Fatalf("config %s: %v", name, err)
+		}
+		values[name] = tfValue
+	}
+	tfConfig := tfsdk.Config{Schema: schemaResp.Schema, Raw: tftypes.NewValue(objectType, values)}
+
+	var diags diag.Diagnostics
+	if validator, ok := d.(datasource.DataSourceWithValidateConfig); ok {
+		var resp datasource.ValidateConfigResponse
+		validator.ValidateConfig(ctx, datasource.ValidateConfigRequest{Config: tfConfig}, &resp)
+		diags.Append(resp.Diagnostics...)
+		if diags.HasError() {
+			return diags
+		}
+	}
+
+	resp := datasource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: tftypes.NewValue(objectType, nil)}}
+	d.Read(ctx, datasource.ReadRequest{Config: tfConfig}, &resp)
+	diags.Append(resp.Diagnostics...)
+	if !diags.HasError() && target != nil {
+		diags.Append(resp.State.Get(ctx, target)...)
+	}
+	return diags
+}
+
+// callFunction runs f with args, one value per parameter and a tuple for
+// the variadic parameter, and returns its result.
+func callFunction(f function.Function, result attr.Value, args ...attr.Value) (attr.Value, *function.FuncError) {
+	resp := function.RunResponse{Result: function.NewResultData(result)}
+	f.Run(context.Background(), function.RunRequest{Arguments: function.NewArgumentsData(args)}, &resp)
+	return resp.Result.Value(), resp.Error
+}
@@ -0,0 +1,130 @@
+// internal/provider/render_function.go
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// inlineFunctionTimeout bounds every KCL run made by a provider function.
+const inlineFunctionTimeout = 60 * time.Second
+
+// Ensure provider defined types fully satisfy framework interfaces
+var (
+	_ function.Function = &RenderFunction{}
+)
+
+// NewRenderFunction returns a factory for the render function. Functions
+// can be called before the provider is configured, in which case kcl_path
+// is not yet known and `kcl` is looked up on PATH.
+func NewRenderFunction(p *kclProvider) func() function.Function {
+	return func() function.Function {
+		return &RenderFunction{provider: p}
+	}
+}
+
+type RenderFunction struct {
+	provider *kclProvider
+}
+
+func (f *RenderFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "render"
+}
+
+func (f *RenderFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Render KCL code to JSON",
+		MarkdownDescription: "Evaluates a KCL program with `kcl run --format json` and returns the result as a JSON string. " +
+			"An optional map of top-level arguments is passed as `-D key=value`",
+
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:                "code",
+				MarkdownDescription: "KCL source code to evaluate",
+			},
+		},
+		VariadicParameter: function.MapParameter{
+			Name:                "arguments",
+			ElementType:         types.StringType,
+			MarkdownDescription: "At most one map of top-level arguments readable with `option(\"key\")`",
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *RenderFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var (
+		code      string
+		arguments []map[string]string
+	)
+	resp.Error = req.Arguments.Get(ctx, &code, &arguments)
+	if resp.Error != nil {
+		return
+	}
+	if len(arguments) > 1 {
+		resp.Error = function.NewArgumentFuncError(1, "At most one arguments map may be given")
+		return
+	}
+
+	spec := kclArgs{
+		Subcommand: "run",
+		Args:       []string{"--format", "json"},
+		CodeFile:   inlineCodeFileName,
+	}
+	if len(arguments) == 1 {
+		spec.Arguments = arguments[0]
+	}
+
+	result, err := runInlineKcl(ctx, f.provider, code, buildArgs(spec))
+	if err != nil {
+		resp.Error = function.NewFuncError(fmt.Sprintf("KCL evaluation failed: %v\n%s", err,
+			strings.TrimSpace(string(result.Combined))))
+		return
+	}
+
+	rendered := strings.TrimSpace(string(result.Stdout))
+	if !json.Valid([]byte(rendered)) {
+		resp.Error = function.NewFuncError("KCL did not produce valid JSON:\n" + rendered)
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, rendered)
+}
+
+// runInlineKcl writes code to a temporary directory and runs KCL there with
+// args, bounded by inlineFunctionTimeout.
+func runInlineKcl(ctx context.Context, p *kclProvider, code string, args []string) (commandOutput, error) {
+	kclCommand, err := p.resolveKclCommand()
+	if err != nil {
+		return commandOutput{}, err
+	}
+
+	dir, err := writeInlineCode(code)
+	if err != nil {
+		return commandOutput{}, fmt.Errorf("writing code to a temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, cancel := context.WithTimeout(ctx, inlineFunctionTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, kclCommand, args...)
+	cmd.Dir = dir
+	configureGracefulStop(cmd)
+
+	tflog.Debug(ctx, "Running KCL for provider function", map[string]interface{}{
+		"command":   kclCommand,
+		"arguments": args,
+	})
+
+	return p.runKcl(ctx, cmd)
+}
@@ -0,0 +1,67 @@
+// internal/provider/render_function_test.go
+package provider
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// renderKcl is a fake KCL printing its arguments and the evaluated code as
+// a JSON object.
+const renderKcl = `[ "$1" = run ] || exit 1
+printf '{"args": "%s", "code": "%s"}\n' "$*" "$(tr -d '\n' < main.k)"`
+
+func noArguments() types.Tuple {
+	return types.TupleValueMust([]attr.Type{}, []attr.Value{})
+}
+
+func TestRenderFunction_Run(t *testing.T) {
+	f := NewRenderFunction(&kclProvider{KclPath: fakeKcl(t, renderKcl)})()
+
+	result, err := callFunction(f, types.StringUnknown(), types.StringValue("name = 1"), noArguments())
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if want := `{"args": "run --format json main.k", "code": "name = 1"}`; result.(types.String).ValueString() != want {
+		t.Errorf("render() = %s, want %s", result, want)
+	}
+
+	argumentsType := types.MapType{ElemType: types.StringType}
+	arguments := types.TupleValueMust([]attr.Type{argumentsType}, []attr.Value{
+		types.MapValueMust(types.StringType, map[string]attr.Value{"env": types.StringValue("prod")}),
+	})
+	result, err = callFunction(f, types.StringUnknown(), types.StringValue("name = 1"), arguments)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if got := result.(types.String).ValueString(); !strings.Contains(got, `"args": "run --format json -D env=prod main.k"`) {
+		t.Errorf("render() = %s, want the arguments passed with -D", got)
+	}
+}
+
+func TestRenderFunction_Errors(t *testing.T) {
+	failing := NewRenderFunction(&kclProvider{KclPath: fakeKcl(t, `echo "error[E2L23]: CompileError" >&2; exit 1`)})()
+	_, err := callFunction(failing, types.StringUnknown(), types.StringValue("name ="), noArguments())
+	if err == nil || !strings.Contains(err.Text, "CompileError") {
+		t.Errorf("render() error = %v, want KCL's diagnostic text", err)
+	}
+
+	notJSON := NewRenderFunction(&kclProvider{KclPath: fakeKcl(t, "echo 'name: web'")})()
+	_, err = callFunction(notJSON, types.StringUnknown(), types.StringValue("name = 1"), noArguments())
+	if err == nil || !strings.Contains(err.Text, "valid JSON") {
+		t.Errorf("render() error = %v, want an invalid JSON error", err)
+	}
+
+	argumentsType := types.MapType{ElemType: types.StringType}
+	twoMaps := types.TupleValueMust([]attr.Type{argumentsType, argumentsType}, []attr.Value{
+		types.MapValueMust(types.StringType, map[string]attr.Value{}),
+		types.MapValueMust(types.StringType, map[string]attr.Value{}),
+	})
+	_, err = callFunction(notJSON, types.StringUnknown(), types.StringValue("name = 1"), twoMaps)
+	if err == nil || err.FunctionArgument == nil || *err.FunctionArgument != 1 {
+		t.Errorf("render() error = %v, want an error for the second argument", err)
+	}
+}
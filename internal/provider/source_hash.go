@@ -0,0 +1,50 @@
+// internal/provider/source_hash.go
+package provider
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+)
+
+// hashSourceDir fingerprints the KCL inputs below dir: every .k file and
+// kcl.mod, each together with its relative path so renames are detected as
+// well as edits. Other files are left out, since KCL runs commonly write
+// outputs and kcl.mod.lock into the same tree. Paths matched by a
+// .kclignore or by exclude are left out as well, as is .git.
+func hashSourceDir(dir string, exclude []string) (string, error) {
+	return hashSourceDirWithout(dir, exclude, nil)
+}
+
+// hashSourceDirWithout is hashSourceDir leaving out the files in without,
+// keyed by slash-separated path relative to dir.
+func hashSourceDirWithout(dir string, exclude []string, without map[string]bool) (string, error) {
+	h := sha256.New()
+	err := walkSourceTree(dir, exclude, func(rel, abs string) error {
+		if !isKclInput(rel) || without[rel] {
+			return nil
+		}
+
+		f, err := os.Open(abs)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		fileHash := sha256.New()
+		if _, err := io.Copy(fileHash, f); err != nil {
+			return err
+		}
+
+		// The walk visits entries in lexical order, so the digest is stable
+		h.Write([]byte(rel))
+		h.Write([]byte{0})
+		h.Write(fileHash.Sum(nil))
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,209 @@
+// internal/provider/source_ignore.go
+package provider
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// kclIgnoreFileName is the file listing source paths to leave out of hashing
+// and copying, in gitignore syntax. Every directory may have one; its
+// patterns are relative to that directory.
+const kclIgnoreFileName = ".kclignore"
+
+// ignoreRule is a single gitignore-style pattern.
+type ignoreRule struct {
+	// base is the slash-separated directory, relative to the walk root, the
+	// pattern was declared in. Empty for the root.
+	base    string
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// ignoreMatcher decides which paths of a source tree are ignored. Rules are
+// checked in declaration order and the last matching rule wins, so a later
+// "!pattern" re-includes what an earlier pattern excluded.
+type ignoreMatcher struct {
+	rules []ignoreRule
+}
+
+// addPatterns adds gitignore-style patterns declared in the directory base.
+// Blank lines and comments are skipped.
+func (m *ignoreMatcher) addPatterns(base string, patterns []string) {
+	for _, p := range patterns {
+		p = strings.TrimRight(p, " \t\r")
+		if p == "" || strings.HasPrefix(p, "#") {
+			continue
+		}
+
+		rule := ignoreRule{base: base}
+		if strings.HasPrefix(p, "!") {
+			rule.negate = true
+			p = p[1:]
+		} else if strings.HasPrefix(p, `\`) {
+			p = p[1:]
+		}
+		if strings.HasSuffix(p, "/") {
+			rule.dirOnly = true
+			p = strings.TrimSuffix(p, "/")
+		}
+		if p == "" {
+			continue
+		}
+
+		// A slash anywhere but the end anchors the pattern to base;
+		// otherwise it matches a name at any depth
+		anchored := strings.Contains(p, "/")
+		p = strings.TrimPrefix(p, "/")
+		expr := globToRegexp(p)
+		if !anchored {
+			expr = "(?:.*/)?" + expr
+		}
+		re, err := regexp.Compile("^" + expr + "$")
+		if err != nil {
+			continue
+		}
+		rule.re = re
+		m.rules = append(m.rules, rule)
+	}
+}
+
+// loadIgnoreFile adds the patterns of the .kclignore in dir, declared for
+// base, when there is one.
+func (m *ignoreMatcher) loadIgnoreFile(dir, base string) error {
+	f, err := os.Open(filepath.Join(dir, kclIgnoreFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		patterns = append(patterns, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	m.addPatterns(base, patterns)
+	return nil
+}
+
+// ignored reports whether the slash-separated path rel, relative to the
+// walk root, is ignored.
+func (m *ignoreMatcher) ignored(rel string, isDir bool) bool {
+	ignored := false
+	for _, rule := range m.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+
+		target := rel
+		if rule.base != "" {
+			if !strings.HasPrefix(rel, rule.base+"/") {
+				continue
+			}
+			target = strings.TrimPrefix(rel, rule.base+"/")
+		}
+		if rule.re.MatchString(target) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// walkSourceTree calls fn for every regular file below root that is not
+// ignored, with its slash-separated path relative to root, in lexical order.
+// Ignored directories are not descended into, so their contents cannot be
+// re-included, as in Git. The .git directory and .kclignore files are always
+// skipped. exclude holds extra patterns declared for root.
+func walkSourceTree(root string, exclude []string, fn func(rel, abs string) error) error {
+	m := &ignoreMatcher{}
+	m.addPatterns("", exclude)
+
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if p != root {
+				if d.Name() == ".git" || m.ignored(rel, true) {
+					return filepath.SkipDir
+				}
+			}
+
+			// Patterns declared here apply to everything below
+			base := rel
+			if p == root {
+				base = ""
+			}
+			return m.loadIgnoreFile(p, base)
+		}
+
+		if !d.Type().IsRegular() || d.Name() == kclIgnoreFileName || m.ignored(rel, false) {
+			return nil
+		}
+		return fn(rel, p)
+	})
+}
+
+// globToRegexp translates a gitignore glob into a regular expression. "*"
+// and "?" do not match "/", while "**" matches across directories.
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "/**") && i+3 == len(glob):
+			b.WriteString("(?:/.*)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// isKclInput reports whether a source file affects what KCL produces.
+func isKclInput(rel string) bool {
+	return path.Ext(rel) == kclSourceExt || path.Base(rel) == kclModFileName
+}
@@ -0,0 +1,109 @@
+// internal/provider/source_ignore_test.go
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWalkSourceTree(t *testing.T) {
+	root := writeTestFiles(t, map[string]string{
+		".kclignore":      "# generated output\n*.log\n!keep.log\nbuild/\n/top.k\n**/tmp/**\n",
+		"main.k":          "",
+		"top.k":           "",
+		"debug.log":       "",
+		"keep.log":        "",
+		"build/out.k":     "",
+		"sub/top.k":       "",
+		"sub/trace.log":   "",
+		"sub/tmp/x.k":     "",
+		"sub/.kclignore":  "*.k\n!main.k\n",
+		"sub/main.k":      "",
+		"sub/other.k":     "",
+		"sub/deep/b.k":    "",
+		"sub/deep/main.k": "",
+		"lib/.kclignore":  "!*.log\n",
+		"lib/kept.log":    "",
+		"lib/a.k":         "",
+		"vendor/v.k":      "",
+		"editor.swp":      "",
+		".git/HEAD":       "",
+	})
+
+	var got []string
+	err := walkSourceTree(root, []string{"vendor/", "*.swp"}, func(rel, abs string) error {
+		if abs != filepath.Join(root, filepath.FromSlash(rel)) {
+			t.Errorf("abs = %s for %s", abs, rel)
+		}
+		got = append(got, rel)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"keep.log", // negated in the root .kclignore
+		"lib/a.k",
+		"lib/kept.log", // negated in a nested .kclignore
+		"main.k",
+		"sub/deep/main.k", // the nested negation applies at any depth below it
+		"sub/main.k",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("walkSourceTree() = %q, want %q", got, want)
+	}
+}
+
+func TestWalkSourceTree_IgnoredDirectoryStaysIgnored(t *testing.T) {
+	root := writeTestFiles(t, map[string]string{
+		".kclignore":  "out/\n!out/keep.k\n",
+		"main.k":      "",
+		"out/keep.k":  "",
+		"out/other.k": "",
+	})
+
+	var got []string
+	if err := walkSourceTree(root, nil, func(rel, _ string) error {
+		got = append(got, rel)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	// As in Git, a file cannot be re-included below an ignored directory
+	if want := []string{"main.k"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("walkSourceTree() = %q, want %q", got, want)
+	}
+}
+
+func TestHashSourceDir_IgnoresIgnoredFiles(t *testing.T) {
+	root := writeTestFiles(t, map[string]string{
+		".kclignore":       "*.log\n",
+		"main.k":           "a = 1\n",
+		"run.log":          "first\n",
+		"sub/.kclignore":   "gen/\n",
+		"sub/gen/schema.k": "first\n",
+	})
+	before, err := hashSourceDir(root, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, content := range map[string]string{"run.log": "second\n", "sub/gen/schema.k": "second\n"} {
+		if err := os.WriteFile(filepath.Join(root, filepath.FromSlash(name)), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if after, _ := hashSourceDir(root, nil); after != before {
+		t.Errorf("editing ignored files changed the hash")
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "main.k"), []byte("a = 2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if after, _ := hashSourceDir(root, nil); after == before {
+		t.Errorf("editing main.k did not change the hash")
+	}
+}
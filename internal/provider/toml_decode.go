@@ -0,0 +1,514 @@
+// internal/provider/toml_decode.go
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// decodeTOML parses a TOML document into the same shapes encoding/json
+// produces with UseNumber: tables become map[string]interface{}, arrays
+// []interface{} and numbers json.Number. Dates and times are kept as
+// strings. It covers the TOML that `kcl run --format toml` emits, which is
+// all of TOML 1.0 in practice.
+func decodeTOML(input string) (map[string]interface{}, error) {
+	p := &tomlParser{src: strings.ReplaceAll(input, "\r\n", "\n"), line: 1}
+	root := map[string]interface{}{}
+	current := root
+	// Tables defined by a header, which must not be defined twice
+	defined := map[string]bool{}
+
+	for {
+		p.skipWhitespaceAndComments(true)
+		if p.eof() {
+			return root, nil
+		}
+
+		switch {
+		case strings.HasPrefix(p.rest(), "[["):
+			p.pos += 2
+			keys, err := p.parseKey()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]]"); err != nil {
+				return nil, err
+			}
+			table, err := appendArrayTable(root, keys)
+			if err != nil {
+				return nil, p.errorf("%v", err)
+			}
+			current = table
+		case p.peek() == '[':
+			p.pos++
+			keys, err := p.parseKey()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			name := strings.Join(keys, "\x00")
+			if defined[name] {
+				return nil, p.errorf("table %s defined twice", strings.Join(keys, "."))
+			}
+			defined[name] = true
+			table, err := descend(root, keys)
+			if err != nil {
+				return nil, p.errorf("%v", err)
+			}
+			current = table
+		default:
+			if err := p.parseKeyValue(current); err != nil {
+				return nil, err
+			}
+		}
+
+		p.skipWhitespaceAndComments(false)
+		if !p.eof() && p.peek() != '\n' {
+			return nil, p.errorf("unexpected %q after value", p.peek())
+		}
+	}
+}
+
+// descend returns the table at keys below table, creating missing tables.
+// For an array of tables the last element is used.
+func descend(table map[string]interface{}, keys []string) (map[string]interface{}, error) {
+	for i, key := range keys {
+		switch next := table[key].(type) {
+		case nil:
+			created := map[string]interface{}{}
+			table[key] = created
+			table = created
+		case map[string]interface{}:
+			table = next
+		case []interface{}:
+			last, ok := lastTable(next)
+			if !ok {
+				return nil, fmt.Errorf("key %s is not a table", strings.Join(keys[:i+1], "."))
+			}
+			table = last
+		default:
+			return nil, fmt.Errorf("key %s is not a table", strings.Join(keys[:i+1], "."))
+		}
+	}
+	return table, nil
+}
+
+// appendArrayTable adds a new table to the array of tables at keys.
+func appendArrayTable(root map[string]interface{}, keys []string) (map[string]interface{}, error) {
+	parent, err := descend(root, keys[:len(keys)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	key := keys[len(keys)-1]
+	table := map[string]interface{}{}
+	switch existing := parent[key].(type) {
+	case nil:
+		parent[key] = []interface{}{table}
+	case []interface{}:
+		if _, ok := lastTable(existing); !ok {
+			return nil, fmt.Errorf("key %s is not an array of tables", strings.Join(keys, "."))
+		}
+		parent[key] = append(existing, table)
+	default:
+		return nil, fmt.Errorf("key %s is not an array of tables", strings.Join(keys, "."))
+	}
+	return table, nil
+}
+
+func lastTable(values []interface{}) (map[string]interface{}, bool) {
+	if len(values) == 0 {
+		return nil, false
+	}
+	table, ok := values[len(values)-1].(map[string]interface{})
+	return table, ok
+}
+
+type tomlParser struct {
+	src  string
+	pos  int
+	line int
+}
+
+func (p *tomlParser) eof() bool    { return p.pos >= len(p.src) }
+func (p *tomlParser) rest() string { return p.src[p.pos:] }
+
+func (p *tomlParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *tomlParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+func (p *tomlParser) expect(token string) error {
+	p.skipSpaces()
+	if !strings.HasPrefix(p.rest(), token) {
+		return p.errorf("expected %q", token)
+	}
+	p.pos += len(token)
+	return nil
+}
+
+func (p *tomlParser) skipSpaces() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+// skipWhitespaceAndComments skips blanks and a comment, and newlines too
+// when newlines is set.
+func (p *tomlParser) skipWhitespaceAndComments(newlines bool) {
+	for !p.eof() {
+		switch c := p.peek(); {
+		case c == ' ' || c == '\t':
+			p.pos++
+		case c == '#':
+			for !p.eof() && p.peek() != '\n' {
+				p.pos++
+			}
+		case c == '\n' && newlines:
+			p.pos++
+			p.line++
+		default:
+			return
+		}
+	}
+}
+
+// parseKey parses a possibly dotted key into its parts.
+func (p *tomlParser) parseKey() ([]string, error) {
+	var keys []string
+	for {
+		p.skipSpaces()
+		var (
+			key string
+			err error
+		)
+		switch p.peek() {
+		case '"':
+			key, err = p.parseBasicString()
+		case '\'':
+			key, err = p.parseLiteralString()
+		default:
+			start := p.pos
+			for !p.eof() && isBareKeyChar(p.peek()) {
+				p.pos++
+			}
+			if start == p.pos {
+				return nil, p.errorf("expected a key")
+			}
+			key = p.src[start:p.pos]
+		}
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+
+		p.skipSpaces()
+		if p.peek() != '.' {
+			return keys, nil
+		}
+		p.pos++
+	}
+}
+
+func isBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// parseKeyValue parses "key = value" into table.
+func (p *tomlParser) parseKeyValue(table map[string]interface{}) error {
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	if err := p.expect("="); err != nil {
+		return err
+	}
+	p.skipSpaces()
+	value, err := p.parseValue()
+	if err != nil {
+		return err
+	}
+
+	parent, err := descend(table, keys[:len(keys)-1])
+	if err != nil {
+		return p.errorf("%v", err)
+	}
+	key := keys[len(keys)-1]
+	if _, exists := parent[key]; exists {
+		return p.errorf("key %s defined twice", strings.Join(keys, "."))
+	}
+	parent[key] = value
+	return nil
+}
+
+func (p *tomlParser) parseValue() (interface{}, error) {
+	rest := p.rest()
+	switch {
+	case strings.HasPrefix(rest, `"""`):
+		return p.parseMultilineString(`"""`)
+	case strings.HasPrefix(rest, "'''"):
+		return p.parseMultilineString("'''")
+	case p.peek() == '"':
+		return p.parseBasicString()
+	case p.peek() == '\'':
+		return p.parseLiteralString()
+	case p.peek() == '[':
+		return p.parseArray()
+	case p.peek() == '{':
+		return p.parseInlineTable()
+	case strings.HasPrefix(rest, "true") && !isBareKeyChar(byteAt(rest, 4)):
+		p.pos += 4
+		return true, nil
+	case strings.HasPrefix(rest, "false") && !isBareKeyChar(byteAt(rest, 5)):
+		p.pos += 5
+		return false, nil
+	}
+	return p.parseScalar()
+}
+
+func byteAt(s string, i int) byte {
+	if i >= len(s) {
+		return 0
+	}
+	return s[i]
+}
+
+func (p *tomlParser) parseBasicString() (string, error) {
+	p.pos++
+	start := p.pos
+	for !p.eof() {
+		switch p.peek() {
+		case '\\':
+			p.pos += 2
+			continue
+		case '\n':
+			return "", p.errorf("unterminated string")
+		case '"':
+			raw := p.src[start:p.pos]
+			p.pos++
+			s, err := unescapeTOML(raw, false)
+			if err != nil {
+				return "", p.errorf("invalid string \"%s\": %v", raw, err)
+			}
+			return s, nil
+		}
+		p.pos++
+	}
+	return "", p.errorf("unterminated string")
+}
+
+func (p *tomlParser) parseLiteralString() (string, error) {
+	p.pos++
+	end := strings.IndexAny(p.rest(), "'\n")
+	if end < 0 || p.rest()[end] != '\'' {
+		return "", p.errorf("unterminated string")
+	}
+	s := p.rest()[:end]
+	p.pos += end + 1
+	return s, nil
+}
+
+func (p *tomlParser) parseMultilineString(delim string) (string, error) {
+	p.pos += len(delim)
+	rest := p.rest()
+
+	// The closing delimiter is the first one not escaped. Up to two quotes
+	// directly before it belong to the content.
+	end := -1
+	for i := 0; i < len(rest); i++ {
+		if delim == `"""` && rest[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.HasPrefix(rest[i:], delim) {
+			end = i
+			for n := 0; n < 2 && strings.HasPrefix(rest[end+1:], delim); n++ {
+				end++
+			}
+			break
+		}
+	}
+	if end < 0 {
+		return "", p.errorf("unterminated multi-line string")
+	}
+	raw := rest[:end]
+	p.line += strings.Count(raw, "\n")
+	p.pos += end + len(delim)
+
+	// A newline right after the opening delimiter is trimmed
+	raw = strings.TrimPrefix(raw, "\n")
+	if delim == "'''" {
+		return raw, nil
+	}
+
+	s, err := unescapeTOML(raw, true)
+	if err != nil {
+		return "", p.errorf("invalid multi-line string: %v", err)
+	}
+	return s, nil
+}
+
+// unescapeTOML resolves the escape sequences of a basic string's content.
+// In a multi-line string a backslash ending a line also removes the line
+// break and the whitespace that follows it.
+func unescapeTOML(raw string, multiline bool) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(raw); i++ {
+		if raw[i] != '\\' {
+			b.WriteByte(raw[i])
+			continue
+		}
+		i++
+		if i >= len(raw) {
+			return "", fmt.Errorf("unterminated escape sequence")
+		}
+
+		switch c := raw[i]; c {
+		case 'b':
+			b.WriteByte('\b')
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'f':
+			b.WriteByte('\f')
+		case 'r':
+			b.WriteByte('\r')
+		case 'e':
+			b.WriteByte('\x1b')
+		case '"', '\\':
+			b.WriteByte(c)
+		case 'u', 'U':
+			n := 4
+			if c == 'U' {
+				n = 8
+			}
+			if i+n >= len(raw) {
+				return "", fmt.Errorf("short unicode escape \\%s", raw[i:])
+			}
+			code, err := strconv.ParseUint(raw[i+1:i+1+n], 16, 32)
+			if err != nil || !utf8.ValidRune(rune(code)) {
+				return "", fmt.Errorf("invalid unicode escape \\%s", raw[i:i+1+n])
+			}
+			b.WriteRune(rune(code))
+			i += n
+		case ' ', '\t', '\n':
+			j := i
+			for j < len(raw) && (raw[j] == ' ' || raw[j] == '\t') {
+				j++
+			}
+			if !multiline || j >= len(raw) || raw[j] != '\n' {
+				return "", fmt.Errorf("invalid escape sequence \\%c", c)
+			}
+			for j < len(raw) && (raw[j] == ' ' || raw[j] == '\t' || raw[j] == '\n') {
+				j++
+			}
+			i = j - 1
+		default:
+			return "", fmt.Errorf("invalid escape sequence \\%c", c)
+		}
+	}
+	return b.String(), nil
+}
+
+func (p *tomlParser) parseArray() ([]interface{}, error) {
+	p.pos++
+	values := []interface{}{}
+	for {
+		p.skipWhitespaceAndComments(true)
+		if p.peek() == ']' {
+			p.pos++
+			return values, nil
+		}
+		if p.eof() {
+			return nil, p.errorf("unterminated array")
+		}
+
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		p.skipWhitespaceAndComments(true)
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+		default:
+			return nil, p.errorf("expected ',' or ']' in array")
+		}
+	}
+}
+
+func (p *tomlParser) parseInlineTable() (map[string]interface{}, error) {
+	p.pos++
+	table := map[string]interface{}{}
+	p.skipSpaces()
+	if p.peek() == '}' {
+		p.pos++
+		return table, nil
+	}
+	for {
+		if err := p.parseKeyValue(table); err != nil {
+			return nil, err
+		}
+		p.skipSpaces()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			return table, nil
+		default:
+			return nil, p.errorf("expected ',' or '}' in inline table")
+		}
+	}
+}
+
+// parseScalar parses a number, date or time up to the end of the value.
+func (p *tomlParser) parseScalar() (interface{}, error) {
+	start := p.pos
+	for !p.eof() && !strings.ContainsRune(",]}#\n", rune(p.peek())) {
+		p.pos++
+	}
+	raw := strings.TrimSpace(p.src[start:p.pos])
+	if raw == "" {
+		return nil, p.errorf("expected a value")
+	}
+
+	switch raw {
+	case "inf", "+inf", "-inf", "nan", "+nan", "-nan":
+		return nil, p.errorf("%s cannot be represented", raw)
+	}
+
+	clean := strings.ReplaceAll(raw, "_", "")
+	if len(clean) > 2 && clean[0] == '0' && strings.ContainsRune("xob", rune(clean[1])) {
+		base := map[byte]int{'x': 16, 'o': 8, 'b': 2}[clean[1]]
+		n, err := strconv.ParseUint(clean[2:], base, 64)
+		if err != nil {
+			return nil, p.errorf("invalid integer %s", raw)
+		}
+		return json.Number(strconv.FormatUint(n, 10)), nil
+	}
+	if _, err := strconv.ParseFloat(clean, 64); err == nil {
+		return json.Number(strings.TrimPrefix(clean, "+")), nil
+	}
+
+	// Dates, times and date-times are passed through as text
+	if len(raw) >= 8 && raw[0] >= '0' && raw[0] <= '9' && strings.ContainsAny(raw, "-:") {
+		return raw, nil
+	}
+	return nil, p.errorf("invalid value %s", raw)
+}
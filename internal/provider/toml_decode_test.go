@@ -0,0 +1,103 @@
+// internal/provider/toml_decode_test.go
+package provider
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDecodeTOML(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		// want is the decoded document encoded as JSON
+		want string
+	}{
+		{"empty", "", `{}`},
+		{"comments and blank lines", "# header\n\na = 1 # trailing\n", `{"a":1}`},
+		{"bare, quoted and dotted keys", "a-b_c = 1\n\"x y\" = 2\n'lit.key' = 3\nd.e.f = 4\n", `{"a-b_c":1,"d":{"e":{"f":4}},"lit.key":3,"x y":2}`},
+		{"booleans", "t = true\nf = false\n", `{"f":false,"t":true}`},
+		{"integers", "dec = +42\nneg = -7\nund = 1_000\nhex = 0xff\noct = 0o17\nbin = 0b101\n", `{"bin":5,"dec":42,"hex":255,"neg":-7,"oct":15,"und":1000}`},
+		{"floats", "f = 3.14\ne = 5e+22\nu = 9_224.5\n", `{"e":5e+22,"f":3.14,"u":9224.5}`},
+		{"dates kept as text", "d = 1979-05-27\nt = 07:32:00\ndt = 1979-05-27T07:32:00Z\n", `{"d":"1979-05-27","dt":"1979-05-27T07:32:00Z","t":"07:32:00"}`},
+		{"CRLF line endings", "a = 1\r\nb = \"x\"\r\n", `{"a":1,"b":"x"}`},
+
+		{"basic string escapes", `s = "tab\there \"quoted\" back\\slash \b\f\r\n"`, `{"s":"tab\there \"quoted\" back\\slash \b\f\r\n"}`},
+		{"unicode escapes", `s = "\u00e9 \U0001F600"`, `{"s":"é 😀"}`},
+		{"escape sequence", `s = "\e[0m"`, `{"s":"\u001b[0m"}`},
+		{"literal string", `s = 'C:\path\"raw"'`, `{"s":"C:\\path\\\"raw\""}`},
+
+		{"multi-line basic string", "s = \"\"\"\nline one\nline two\"\"\"\n", `{"s":"line one\nline two"}`},
+		{"multi-line escaped quote", `s = """say \"hi\" now"""`, `{"s":"say \"hi\" now"}`},
+		{"multi-line escaped closing delimiter", `s = """a \""" b"""`, `{"s":"a \"\"\" b"}`},
+		{"multi-line unescaped quotes", `s = """one " two "" three"""`, `{"s":"one \" two \"\" three"}`},
+		{"multi-line quotes before closing delimiter", `s = """ends with two quotes"""""`, `{"s":"ends with two quotes\"\""}`},
+		{"multi-line line ending backslash", "s = \"\"\"\nThe quick \\\n    brown \\   \n\n  fox\"\"\"\n", `{"s":"The quick brown fox"}`},
+		{"multi-line escaped backslash before newline", "s = \"\"\"a\\\\\nb\"\"\"\n", `{"s":"a\\\nb"}`},
+		{"multi-line escapes", `s = """tab\tunicode\u00e9"""`, `{"s":"tab\tunicodeé"}`},
+		{"multi-line literal string", "s = '''\nraw \\n \"\"\" text\n'''\n", `{"s":"raw \\n \"\"\" text\n"}`},
+		{"multi-line literal quotes before closing delimiter", "s = '''it''''", `{"s":"it'"}`},
+
+		{"arrays", "a = [1, \"two\", [3.5, true]]\nempty = []\n", `{"a":[1,"two",[3.5,true]],"empty":[]}`},
+		{"multi-line array with comments", "a = [\n  1, # one\n  2,\n]\n", `{"a":[1,2]}`},
+		{"inline tables", "p = { x = 1, y.z = \"w\" }\ne = {}\n", `{"e":{},"p":{"x":1,"y":{"z":"w"}}}`},
+		{"tables", "[server]\nhost = \"h\"\n[server.tls]\nport = 443\n[client]\nid = 1\n", `{"client":{"id":1},"server":{"host":"h","tls":{"port":443}}}`},
+		{"array of tables", "[[item]]\nname = \"a\"\n[[item]]\nname = \"b\"\n[item.meta]\nk = 1\n", `{"item":[{"name":"a"},{"meta":{"k":1},"name":"b"}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded, err := decodeTOML(tt.input)
+			if err != nil {
+				t.Fatalf("decodeTOML(%q): %v", tt.input, err)
+			}
+			got, err := json.Marshal(decoded)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("decodeTOML(%q) = %s, want %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeTOML_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		// want is a substring of the error
+		want string
+	}{
+		{"duplicate key", "a = 1\na = 2\n", "line 2: key a defined twice"},
+		{"table defined twice", "[t]\n[t]\n", "table t defined twice"},
+		{"key redefined as table", "a = 1\n[a.b]\n", "key a is not a table"},
+		{"array of tables over a value", "a = 1\n[[a]]\n", "key a is not an array of tables"},
+		{"infinity", "f = inf\n", "inf cannot be represented"},
+		{"nan", "f = nan\n", "nan cannot be represented"},
+		{"invalid escape", `s = "\q"`, `invalid escape sequence \q`},
+		{"invalid unicode escape", `s = "\uZZZZ"`, "invalid unicode escape"},
+		{"surrogate unicode escape", `s = "\uD800"`, "invalid unicode escape"},
+		{"short unicode escape", `s = "\u12"`, "short unicode escape"},
+		{"line ending backslash in basic string", "s = \"a \\\n\"", "invalid escape sequence"},
+		{"unterminated string", "s = \"abc\n", "unterminated string"},
+		{"unterminated multi-line string", "s = \"\"\"abc \\\"\"\"", "unterminated multi-line string"},
+		{"unterminated literal string", "s = 'abc\n", "unterminated string"},
+		{"unterminated array", "a = [1, 2", "expected ',' or ']' in array"},
+		{"missing separator in array", "a = [1 2]", "invalid value 1 2"},
+		{"missing separator in inline table", "a = { x = 1 y = 2 }", "invalid value 1 y = 2"},
+		{"value trailing garbage", "a = \"x\" y\n", `unexpected 'y' after value`},
+		{"missing value", "a = \n", "expected a value"},
+		{"missing key", "= 1\n", "expected a key"},
+		{"invalid value", "a = nope\n", "invalid value nope"},
+		{"invalid integer", "a = 0xZZ\n", "invalid integer 0xZZ"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeTOML(tt.input)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("decodeTOML(%q) error = %v, want it to contain %q", tt.input, err, tt.want)
+			}
+		})
+	}
+}
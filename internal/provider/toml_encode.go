@@ -0,0 +1,161 @@
+// internal/provider/toml_encode.go
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// encodeTOML renders a document decoded by encoding/json with UseNumber as
+// TOML, for KCL releases that cannot print TOML themselves. Nested objects
+// become [tables] and arrays of objects [[arrays of tables]]. TOML has no
+// null and a document is always a table, so either shape is an error.
+func encodeTOML(v interface{}) (string, error) {
+	table, ok := v.(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("a TOML document is a table, but the output is %s", tomlShape(v))
+	}
+	var b strings.Builder
+	if err := encodeTOMLTable(&b, nil, table); err != nil {
+		return "", err
+	}
+	return strings.TrimPrefix(b.String(), "\n"), nil
+}
+
+// encodeTOMLTable writes the key/value pairs of table, followed by its
+// subtables and arrays of tables under their full path.
+func encodeTOMLTable(b *strings.Builder, keys []string, table map[string]interface{}) error {
+	names := make([]string, 0, len(table))
+	for name := range table {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var tables, arrays []string
+	for _, name := range names {
+		switch value := table[name].(type) {
+		case map[string]interface{}:
+			tables = append(tables, name)
+			continue
+		case []interface{}:
+			if isTOMLArrayOfTables(value) {
+				arrays = append(arrays, name)
+				continue
+			}
+		}
+		inline, err := encodeTOMLValue(append(keys[:len(keys):len(keys)], name), table[name])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(b, "%s = %s\n", formatTomlKey(name), inline)
+	}
+
+	for _, name := range tables {
+		path := append(append([]string{}, keys...), name)
+		fmt.Fprintf(b, "\n[%s]\n", tomlKeyPath(path))
+		if err := encodeTOMLTable(b, path, table[name].(map[string]interface{})); err != nil {
+			return err
+		}
+	}
+	for _, name := range arrays {
+		path := append(append([]string{}, keys...), name)
+		for _, item := range table[name].([]interface{}) {
+			// Headers below an item name the array, which means its last item
+			fmt.Fprintf(b, "\n[[%s]]\n", tomlKeyPath(path))
+			if err := encodeTOMLTable(b, path, item.(map[string]interface{})); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// encodeTOMLValue renders v as an inline TOML value. keys locates v in the
+// document for error messages.
+func encodeTOMLValue(keys []string, v interface{}) (string, error) {
+	switch value := v.(type) {
+	case nil:
+		return "", fmt.Errorf("%s is null, which TOML cannot represent", tomlKeyPath(keys))
+	case string:
+		return tomlString(value), nil
+	case bool:
+		return fmt.Sprint(value), nil
+	case json.Number:
+		return value.String(), nil
+	case []interface{}:
+		items := make([]string, len(value))
+		for i, item := range value {
+			inline, err := encodeTOMLValue(append(keys[:len(keys):len(keys)], fmt.Sprint(i)), item)
+			if err != nil {
+				return "", err
+			}
+			items[i] = inline
+		}
+		return "[" + strings.Join(items, ", ") + "]", nil
+	case map[string]interface{}:
+		names := make([]string, 0, len(value))
+		for name := range value {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		pairs := make([]string, len(names))
+		for i, name := range names {
+			inline, err := encodeTOMLValue(append(keys[:len(keys):len(keys)], name), value[name])
+			if err != nil {
+				return "", err
+			}
+			pairs[i] = formatTomlKey(name) + " = " + inline
+		}
+		return "{" + strings.Join(pairs, ", ") + "}", nil
+	}
+	return "", fmt.Errorf("%s: unsupported value type %T", tomlKeyPath(keys), v)
+}
+
+// isTOMLArrayOfTables reports whether every item of a non-empty array is an
+// object, so it can be written as [[array of tables]].
+func isTOMLArrayOfTables(items []interface{}) bool {
+	for _, item := range items {
+		if _, ok := item.(map[string]interface{}); !ok {
+			return false
+		}
+	}
+	return len(items) > 0
+}
+
+func tomlKeyPath(keys []string) string {
+	formatted := make([]string, len(keys))
+	for i, key := range keys {
+		formatted[i] = formatTomlKey(key)
+	}
+	return strings.Join(formatted, ".")
+}
+
+// tomlShape names the JSON type of a value that cannot be a TOML document.
+func tomlShape(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case []interface{}:
+		return "a list"
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	case json.Number:
+		return "a number"
+	}
+	return fmt.Sprintf("a %T", v)
+}
+
+// jsonToTOML converts the JSON output of KCL into a TOML document.
+func jsonToTOML(output string) (string, error) {
+	dec := json.NewDecoder(strings.NewReader(output))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return "", jsonResultError(output, err)
+	}
+	return encodeTOML(v)
+}
@@ -0,0 +1,81 @@
+// internal/provider/toml_encode_test.go
+package provider
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestJSONToTOML(t *testing.T) {
+	input := `{"name": "app", "replicas": 3, "ratio": 0.5, "debug": false, "tags": ["a", "b"],
+		"odd key": "x\ty", "image": {"repo": "nginx", "pull": {"policy": "Always"}},
+		"ports": [{"port": 80}, {"port": 443, "tls": {"cert": "c"}}], "mixed": [1, {"a": 2}], "empty": {}}`
+	want := `debug = false
+mixed = [1, {a = 2}]
+name = "app"
+"odd key" = "x\ty"
+ratio = 0.5
+replicas = 3
+tags = ["a", "b"]
+
+[empty]
+
+[image]
+repo = "nginx"
+
+[image.pull]
+policy = "Always"
+
+[[ports]]
+port = 80
+
+[[ports]]
+port = 443
+
+[ports.tls]
+cert = "c"
+`
+	got, err := jsonToTOML(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("jsonToTOML() =\n%s\nwant\n%s", got, want)
+	}
+
+	// The TOML must decode to the document it was converted from
+	decoded, err := decodeTOML(got)
+	if err != nil {
+		t.Fatalf("decodeTOML() of the converted document: %v", err)
+	}
+	var original interface{}
+	if err := json.Unmarshal([]byte(input), &original); err != nil {
+		t.Fatal(err)
+	}
+	gotJSON, _ := json.Marshal(decoded)
+	wantJSON, _ := json.Marshal(original)
+	if string(gotJSON) != string(wantJSON) {
+		t.Errorf("round trip = %s, want %s", gotJSON, wantJSON)
+	}
+}
+
+func TestJSONToTOML_IncompatibleShape(t *testing.T) {
+	tests := []struct {
+		input   string
+		wantErr string
+	}{
+		{`[1, 2]`, "a TOML document is a table, but the output is a list"},
+		{`"text"`, "but the output is a string"},
+		{`null`, "but the output is null"},
+		{`{"a": {"b": null}}`, "a.b is null, which TOML cannot represent"},
+		{`{"a": [1, null]}`, "a.1 is null"},
+		{`{"a": `, "unexpected EOF"},
+	}
+	for _, tt := range tests {
+		_, err := jsonToTOML(tt.input)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("jsonToTOML(%s) error = %v, want %q", tt.input, err, tt.wantErr)
+		}
+	}
+}
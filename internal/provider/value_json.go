@@ -0,0 +1,79 @@
+// internal/provider/value_json.go
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+// attrValueToJSON converts a Terraform value into the equivalent plain Go
+// value for encoding/json. Numbers are kept as json.Number so large and
+// fractional values keep their exact representation.
+func attrValueToJSON(v attr.Value) (interface{}, error) {
+	if v == nil || v.IsNull() {
+		return nil, nil
+	}
+	if v.IsUnknown() {
+		return nil, fmt.Errorf("value is not known until apply")
+	}
+
+	switch val := v.(type) {
+	case basetypes.DynamicValue:
+		return attrValueToJSON(val.UnderlyingValue())
+	case basetypes.StringValue:
+		return val.ValueString(), nil
+	case basetypes.BoolValue:
+		return val.ValueBool(), nil
+	case basetypes.Int64Value:
+		return json.Number(fmt.Sprintf("%d", val.ValueInt64())), nil
+	case basetypes.Float64Value:
+		return json.Number(fmt.Sprintf("%v", val.ValueFloat64())), nil
+	case basetypes.NumberValue:
+		// Whole numbers are written as integers, which Text would put in
+		// exponent form from 16 digits on, making KCL read them as floats
+		f := val.ValueBigFloat()
+		if i, accuracy := f.Int64(); accuracy == big.Exact {
+			return json.Number(fmt.Sprintf("%d", i)), nil
+		}
+		return json.Number(f.Text('g', -1)), nil
+	case basetypes.ListValue:
+		return attrValuesToJSON(val.Elements())
+	case basetypes.SetValue:
+		return attrValuesToJSON(val.Elements())
+	case basetypes.TupleValue:
+		return attrValuesToJSON(val.Elements())
+	case basetypes.MapValue:
+		return attrValueMapToJSON(val.Elements())
+	case basetypes.ObjectValue:
+		return attrValueMapToJSON(val.Attributes())
+	}
+	return nil, fmt.Errorf("unsupported value type %T", v)
+}
+
+func attrValuesToJSON(elems []attr.Value) (interface{}, error) {
+	out := make([]interface{}, len(elems))
+	for i, elem := range elems {
+		v, err := attrValueToJSON(elem)
+		if err != nil {
+			return nil, fmt.Errorf("[%d]: %w", i, err)
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+func attrValueMapToJSON(elems map[string]attr.Value) (interface{}, error) {
+	out := make(map[string]interface{}, len(elems))
+	for k, elem := range elems {
+		v, err := attrValueToJSON(elem)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", k, err)
+		}
+		out[k] = v
+	}
+	return out, nil
+}
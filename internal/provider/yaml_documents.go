@@ -0,0 +1,184 @@
+// internal/provider/yaml_documents.go
+package provider
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v2"
+)
+
+// splitYAMLDocuments splits a YAML stream into its documents. Following the
+// YAML spec, only `---` (directives end) and `...` (document end) markers
+// starting at column 0 and followed by whitespace or the end of the line
+// separate documents; indented dashes, e.g. inside block scalars, are
+// content. Each document is trimmed, and documents holding nothing but
+// whitespace and comments are dropped.
+func splitYAMLDocuments(stream string) []string {
+	var (
+		docs    []string
+		current []string
+	)
+	flush := func() {
+		doc := strings.TrimSpace(strings.Join(current, "\n"))
+		current = nil
+		if !isEmptyYAMLDocument(doc) {
+			docs = append(docs, doc)
+		}
+	}
+
+	stream = strings.ReplaceAll(stream, "\r\n", "\n")
+	for _, line := range strings.Split(stream, "\n") {
+		switch {
+		case isYAMLMarker(line, "---"):
+			flush()
+			// Content may follow the marker on the same line, e.g. `--- !tag`
+			if rest := strings.TrimSpace(line[3:]); rest != "" {
+				current = append(current, rest)
+			}
+		case isYAMLMarker(line, "..."):
+			flush()
+		default:
+			current = append(current, line)
+		}
+	}
+	flush()
+
+	return docs
+}
+
+func isYAMLMarker(line, marker string) bool {
+	if !strings.HasPrefix(line, marker) {
+		return false
+	}
+	rest := line[len(marker):]
+	return rest == "" || rest[0] == ' ' || rest[0] == '\t'
+}
+
+func isEmptyYAMLDocument(doc string) bool {
+	for _, line := range strings.Split(doc, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			return false
+		}
+	}
+	return true
+}
+
+// documentsByKindName keys docs by "<kind>/<metadata.name>", as Kubernetes
+// manifests are identified. Documents without a string kind and name, that
+// do not parse, or whose key an earlier document already took are keyed by
+// their index in docs instead, so none is lost.
+func documentsByKindName(docs []string) map[string]string {
+	keyed := make(map[string]string, len(docs))
+	for i, key := range documentKeys(docs) {
+		keyed[key] = docs[i]
+	}
+	return keyed
+}
+
+// documentKeys returns the documents_by_kind_name key of each document.
+func documentKeys(docs []string) []string {
+	keys := make([]string, len(docs))
+	taken := make(map[string]bool, len(docs))
+	for i, doc := range docs {
+		key := manifestKey(doc)
+		if key == "" || taken[key] {
+			key = fmt.Sprintf("%d", i)
+		}
+		taken[key] = true
+		keys[i] = key
+	}
+	return keys
+}
+
+// manifestKey returns "<kind>/<metadata.name>" of a YAML document, or ""
+// when it has no such fields.
+func manifestKey(doc string) string {
+	kind, name := manifestKindName(doc)
+	if kind == "" || name == "" {
+		return ""
+	}
+	return kind + "/" + name
+}
+
+// manifestKindName returns the kind and metadata.name of a YAML document,
+// each "" when it is not a string.
+func manifestKindName(doc string) (kind, name string) {
+	var manifest struct {
+		Kind     interface{} `yaml:"kind"`
+		Metadata struct {
+			Name interface{} `yaml:"name"`
+		} `yaml:"metadata"`
+	}
+	if err := yaml.Unmarshal([]byte(doc), &manifest); err != nil {
+		return "", ""
+	}
+	kind, _ = manifest.Kind.(string)
+	name, _ = manifest.Metadata.Name.(string)
+	return kind, name
+}
+
+// documentFileData is what filename_template is executed with for each
+// document.
+type documentFileData struct {
+	// Index is the position of the document in documents.
+	Index int
+	// Kind and Name are kind and metadata.name, "" when missing.
+	Kind string
+	Name string
+	// Document is the decoded document, nil when it does not parse.
+	Document interface{}
+}
+
+// parseFilenameTemplate compiles a filename_template. Referencing a missing
+// key of Document is an error rather than an empty name part.
+func parseFilenameTemplate(text string) (*template.Template, error) {
+	return template.New("filename_template").Option("missingkey=error").Parse(text)
+}
+
+// documentFileNames returns the file each document is written to below
+// output_dir. Without tmpl the names are the documents_by_kind_name keys
+// with a .yaml extension. Names must be relative paths that stay below the
+// directory, and no two documents may share one.
+func documentFileNames(docs []string, tmpl *template.Template) ([]string, error) {
+	names := make([]string, len(docs))
+	if tmpl == nil {
+		for i, key := range documentKeys(docs) {
+			names[i] = key + ".yaml"
+		}
+	} else {
+		for i, doc := range docs {
+			data := documentFileData{Index: i}
+			data.Kind, data.Name = manifestKindName(doc)
+			if err := yaml.Unmarshal([]byte(doc), &data.Document); err != nil {
+				data.Document = nil
+			}
+
+			var b strings.Builder
+			if err := tmpl.Execute(&b, data); err != nil {
+				return nil, fmt.Errorf("document %d: %w", i, err)
+			}
+			names[i] = strings.TrimSpace(b.String())
+		}
+	}
+
+	seen := make(map[string]int, len(names))
+	for i, name := range names {
+		if name == "" {
+			return nil, fmt.Errorf("document %d: the file name is empty", i)
+		}
+		if !filepath.IsLocal(filepath.FromSlash(name)) {
+			return nil, fmt.Errorf("document %d: file name %q is not a relative path below the output directory", i, name)
+		}
+		clean := filepath.ToSlash(filepath.Clean(filepath.FromSlash(name)))
+		if first, taken := seen[clean]; taken {
+			return nil, fmt.Errorf("documents %d and %d are both named %q", first, i, name)
+		}
+		seen[clean] = i
+		names[i] = clean
+	}
+	return names, nil
+}
@@ -0,0 +1,61 @@
+// internal/provider/yaml_documents_test.go
+package provider
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestDocumentFileNames(t *testing.T) {
+	docs := []string{
+		"kind: Deployment\nmetadata:\n  name: web\n  namespace: prod",
+		"kind: Service\nmetadata:\n  name: web\n  namespace: prod",
+		"a: 1",
+	}
+	tests := []struct {
+		name     string
+		docs     []string
+		template string
+		want     []string
+		wantErr  string
+	}{
+		{name: "default", docs: docs, want: []string{"Deployment/web.yaml", "Service/web.yaml", "2.yaml"}},
+		{name: "template", docs: docs[:2], template: "{{ .Document.metadata.namespace }}/{{ .Kind }}-{{ .Name }}.yaml",
+			want: []string{"prod/Deployment-web.yaml", "prod/Service-web.yaml"}},
+		{name: "index", docs: docs, template: "./{{ .Index }}.yml\n", want: []string{"0.yml", "1.yml", "2.yml"}},
+		{name: "collision", docs: docs[:2], template: "{{ .Name }}.yaml", wantErr: `documents 0 and 1 are both named "web.yaml"`},
+		{name: "missing key", docs: docs, template: "{{ .Document.metadata.name }}.yaml", wantErr: "document 2: "},
+		{name: "empty name", docs: docs[2:], template: "{{ .Name }}", wantErr: "document 0: the file name is empty"},
+		{name: "outside the directory", docs: docs[:1], template: "../{{ .Name }}.yaml", wantErr: "not a relative path"},
+		{name: "absolute", docs: docs[:1], template: "/tmp/{{ .Name }}.yaml", wantErr: "not a relative path"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tmpl *template.Template
+			if tt.template != "" {
+				var err error
+				if tmpl, err = parseFilenameTemplate(tt.template); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := documentFileNames(tt.docs, tmpl)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("documentFileNames() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("documentFileNames() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFilenameTemplate_Invalid(t *testing.T) {
+	if _, err := parseFilenameTemplate("{{ .Name "); err == nil {
+		t.Error("parseFilenameTemplate() of an unclosed action succeeded")
+	}
+}